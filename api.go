@@ -2,8 +2,8 @@ package biligo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
@@ -13,14 +13,29 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/tidwall/gjson"
 )
 
 type baseClient struct {
-	debug  bool
-	client *http.Client
-	ua     string
-	logger *log.Logger
+	client      *http.Client
+	ua          string
+	logger      *log.Logger
+	wbi         *WbiSigner
+	limit       *RateLimit
+	retry       *RetryPolicy
+	middlewares []Middleware
+	endpoints   map[string]string
+
+	proxy ProxyProvider
+	// account 代理粘性会话使用的账号，CommClient 为nil
+	account func() string
+	// proxyClient 由proxyFrom派生，SetClient 后重新派生
+	proxyMu     sync.Mutex
+	proxyFrom   *http.Client
+	proxyClient *http.Client
 }
 
 type baseSetting struct {
@@ -40,6 +55,20 @@ type baseSetting struct {
 	Prefix string
 	// Logger 输出
 	Logger *log.Logger
+	// WBI签名器
+	//
+	// 默认从导航栏接口获取密钥
+	Wbi *WbiSigner
+	// 限流设置 nil不限流
+	RateLimit *RateLimit
+	// 重试策略 nil不重试
+	Retry *RetryPolicy
+	// 中间件 靠前的在外层
+	Middlewares []Middleware
+	// 替换域名 key为 BiliApiURL 等常量
+	Endpoints map[string]string
+	// 代理 nil不使用
+	Proxy ProxyProvider
 }
 
 func newBaseClient(setting *baseSetting) *baseClient {
@@ -56,17 +85,152 @@ func newBaseClient(setting *baseSetting) *baseClient {
 	if setting.Logger == nil {
 		setting.Logger = log.New(os.Stdout, setting.Prefix, log.LstdFlags)
 	}
-	return &baseClient{
-		debug:  setting.DebugMode,
+	h := &baseClient{
 		client: client,
 		ua:     ua,
 		logger: setting.Logger,
+		wbi:    setting.Wbi,
+		limit:  setting.RateLimit,
+		retry:  setting.Retry,
+		proxy:  setting.Proxy,
+	}
+	h.middlewares = append(h.middlewares, setting.Middlewares...)
+	h.endpoints = make(map[string]string, len(setting.Endpoints))
+	for k, v := range setting.Endpoints {
+		if v != "" && !strings.HasSuffix(v, "/") {
+			v += "/"
+		}
+		h.endpoints[k] = v
+	}
+	// debug输出放在最内层，能看到其他中间件修改后的请求
+	if setting.DebugMode {
+		h.middlewares = append(h.middlewares, DebugMiddleware(h.logger))
+	}
+	if h.wbi == nil {
+		h.wbi = NewWbiSigner(h.fetchWbiKeys)
+	}
+	return h
+}
+
+// request 经过中间件发出请求 payload为携带的参数，用于debug输出
+func (h *baseClient) request(base, endpoint string, req *http.Request, payload map[string]string) ([]byte, error) {
+	resp, err := h.roundTrip(base, endpoint, req, payload)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// roundTrip 同 request，返回带有Header的完整响应
+func (h *baseClient) roundTrip(base, endpoint string, req *http.Request, payload map[string]string) (*RawResponse, error) {
+	var rt RoundTrip = h.send
+	for i := len(h.middlewares) - 1; i >= 0; i-- {
+		rt = h.middlewares[i](rt)
+	}
+	return rt(&RawRequest{
+		Base:     base,
+		Endpoint: endpoint,
+		Payload:  payload,
+		HTTP:     req,
+	})
+}
+
+// send 中间件链的末端，负责限流、重试与wbi重新签名
+func (h *baseClient) send(r *RawRequest) (*RawResponse, error) {
+	resp, err := h.sendRetry(r.Base, r.HTTP)
+	if err != nil || !h.wbiSigned(r) || !isWbiRejected(resp.Body) {
+		return resp, err
+	}
+
+	// 签名被拒绝，可能是密钥已经更新，刷新后重试一次
+	req := r.HTTP.Clone(r.HTTP.Context())
+	q := req.URL.Query()
+	h.wbi.Invalidate()
+	if err = h.wbi.Sign(req.Context(), q); err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = q.Encode()
+	return h.sendRetry(r.Base, req)
+}
+
+// wbiSigned 请求是否带有可刷新密钥的wbi签名
+func (h *baseClient) wbiSigned(r *RawRequest) bool {
+	return r.HTTP.Method == http.MethodGet && h.wbi != nil && h.wbi.fetch != nil &&
+		isWbiEndpoint(r.Endpoint) && r.HTTP.URL.Query().Get("w_rid") != ""
+}
+
+// sendRetry 限流并按重试策略发出请求
+func (h *baseClient) sendRetry(base string, req *http.Request) (*RawResponse, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		hr := req
+		// 重试时需要重新获取body
+		if attempt > 0 {
+			hr = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				hr.Body = body
+			}
+		}
+
+		if h.limit != nil {
+			if err := h.limit.wait(ctx, base); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := h.do(hr)
+		if h.retry == nil || attempt >= h.retry.MaxRetries {
+			return resp, err
+		}
+		var (
+			status int
+			raw    []byte
+		)
+		if resp != nil {
+			status, raw = resp.StatusCode, resp.Body
+		}
+		e, ok := h.retry.shouldRetry(ctx, req.Method, status, raw, err)
+		if !ok {
+			return resp, err
+		}
+		e.Attempt = attempt + 1
+		e.Method = req.Method
+		e.URL = req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+		e.Delay = h.retry.delay(e.Attempt)
+		if h.retry.OnRetry != nil {
+			h.retry.OnRetry(e)
+		}
+		if err = sleep(ctx, e.Delay); err != nil {
+			return nil, err
+		}
 	}
 }
 
-// request v为携带的参数，用于debug输出
-func (h *baseClient) request(req *http.Request, v interface{}) ([]byte, error) {
-	resp, err := h.client.Do(req)
+// doStream 使用client与代理发出请求，不读取响应，也可用于直播流、视频等大文件
+//
+// 使用代理连接失败时标记代理不可用，返回使用的代理
+func (h *baseClient) doStream(req *http.Request) (*http.Response, *url.URL, error) {
+	client, req, proxy, err := h.withProxy(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if proxy != nil && req.Context().Err() == nil {
+			h.proxy.MarkBad(proxy, err)
+		}
+		return nil, nil, err
+	}
+	return resp, proxy, nil
+}
+
+// do 发出单次请求
+func (h *baseClient) do(req *http.Request) (*RawResponse, error) {
+	resp, proxy, err := h.doStream(req)
 	if err != nil {
 		return nil, err
 	}
@@ -77,20 +241,19 @@ func (h *baseClient) request(req *http.Request, v interface{}) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	if h.debug {
-		h.logger.Printf("%s %s  %+v", req.Method, req.URL, v)
-		h.logger.Printf("%s", string(raw))
+	// 代理的出口IP被风控
+	if proxy != nil && (resp.StatusCode == http.StatusPreconditionFailed ||
+		gjson.ValidBytes(raw) && gjson.GetBytes(raw, "code").Int() == int64(ErrRateLimited.Code)) {
+		h.proxy.MarkBad(proxy, ErrRateLimited)
 	}
 
-	return raw, nil
+	return &RawResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       raw,
+	}, nil
 }
-func (h *baseClient) raw(base, endpoint, method string, payload map[string]string, dAfter func(d *url.Values), reqAfter func(r *http.Request)) ([]byte, error) {
-	var (
-		req *http.Request
-		err error
-	)
-
+func (h *baseClient) raw(ctx context.Context, base, endpoint, method string, payload map[string]string, dAfter func(d *url.Values), reqAfter func(r *http.Request)) ([]byte, error) {
 	data := url.Values{}
 	for k, v := range payload {
 		data.Add(k, v)
@@ -101,15 +264,32 @@ func (h *baseClient) raw(base, endpoint, method string, payload map[string]strin
 		dAfter(&data)
 	}
 
-	link := base + endpoint
+	// wbi接口需要签名
+	wbi := method == http.MethodGet && h.wbi != nil && isWbiEndpoint(endpoint)
+	if wbi {
+		if err := h.wbi.Sign(ctx, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return h.rawRequest(ctx, base, endpoint, method, data, payload, reqAfter)
+}
+func (h *baseClient) rawRequest(ctx context.Context, base, endpoint, method string, data url.Values, payload map[string]string, reqAfter func(r *http.Request)) ([]byte, error) {
+	var (
+		req *http.Request
+		err error
+	)
+
+	link := h.resolve(base) + endpoint
+
 	switch method {
 	case http.MethodGet:
-		if req, err = http.NewRequest(method, link, nil); err != nil {
+		if req, err = http.NewRequestWithContext(ctx, method, link, nil); err != nil {
 			return nil, err
 		}
 		req.URL.RawQuery = data.Encode()
 	case http.MethodPost:
-		if req, err = http.NewRequest(method, link, strings.NewReader(data.Encode())); err != nil {
+		if req, err = http.NewRequestWithContext(ctx, method, link, strings.NewReader(data.Encode())); err != nil {
 			return nil, err
 		}
 	}
@@ -124,24 +304,50 @@ func (h *baseClient) raw(base, endpoint, method string, payload map[string]strin
 		reqAfter(req)
 	}
 
-	return h.request(req, payload)
+	return h.request(base, endpoint, req, payload)
+}
+
+// get 请求完整的地址，用于字幕文件等非API资源，同样经过中间件
+func (h *baseClient) get(ctx context.Context, link string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Referer", "https://www.bilibili.com")
+	req.Header.Add("User-Agent", h.ua)
+	return h.request("", link, req, nil)
+}
+
+// resolve 获取域名实际使用的地址，未替换时返回原值
+func (h *baseClient) resolve(base string) string {
+	if u, ok := h.endpoints[base]; ok && u != "" {
+		return u
+	}
+	return base
 }
-func (h *baseClient) parse(raw []byte) (*Response, error) {
+
+// parse 解析通用响应，Code不为0时返回 *APIError
+func (h *baseClient) parse(endpoint string, raw []byte) (*Response, error) {
 	var result = &Response{}
 	if err := json.Unmarshal(raw, &result); err != nil {
 		return nil, err
 	}
 	if result.Code != 0 {
-		return nil, fmt.Errorf("(%d) %s", result.Code, result.Message)
+		return nil, &APIError{
+			Code:     result.Code,
+			Message:  result.Message,
+			Endpoint: endpoint,
+			Raw:      raw,
+		}
 	}
 	return result, nil
 }
-func (h *baseClient) upload(base, endpoint string, payload map[string]string, files []*FileUpload, mAfter func(m *multipart.Writer) error, reqAfter func(r *http.Request)) ([]byte, error) {
+func (h *baseClient) upload(ctx context.Context, base, endpoint string, payload map[string]string, files []*FileUpload, mAfter func(m *multipart.Writer) error, reqAfter func(r *http.Request)) ([]byte, error) {
 	var (
 		req *http.Request
 		err error
 	)
-	link := base + endpoint
+	link := h.resolve(base) + endpoint
 
 	body := new(bytes.Buffer)
 	mp := multipart.NewWriter(body)
@@ -175,7 +381,7 @@ func (h *baseClient) upload(base, endpoint string, payload map[string]string, fi
 	}
 
 	// 只支持POST
-	if req, err = http.NewRequest(http.MethodPost, link, body); err != nil {
+	if req, err = http.NewRequestWithContext(ctx, http.MethodPost, link, body); err != nil {
 		return nil, err
 	}
 
@@ -190,5 +396,5 @@ func (h *baseClient) upload(base, endpoint string, payload map[string]string, fi
 	}
 
 	// 文件不输出，否则全是乱码
-	return h.request(req, payload)
+	return h.request(base, endpoint, req, payload)
 }
@@ -0,0 +1,268 @@
+package biligo
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ArticleBlock.Type
+const (
+	ArticleBlockParagraph = "paragraph"
+	ArticleBlockHeading   = "heading"
+	ArticleBlockImage     = "image"
+	ArticleBlockCard      = "card"
+	ArticleBlockQuote     = "quote"
+	ArticleBlockList      = "list"
+	ArticleBlockCode      = "code"
+	ArticleBlockDivider   = "divider" // 分割线
+)
+
+// articleCardTypes 卡片class前缀 -> ArticleCard.Type
+var articleCardTypes = map[string]string{
+	"fanju":      "bangumi",
+	"caricature": "manga",
+}
+
+// ParseArticleHTML 将专栏正文HTML解析为段落、图片、卡片等
+//
+// 行内样式会被忽略，段落中的图片会拆分为单独的块
+func ParseArticleHTML(s string) ([]*ArticleBlock, error) {
+	d := xml.NewDecoder(strings.NewReader("<root>" + s + "</root>"))
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	p := &articleParser{}
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			p.depth++
+			p.start(t)
+		case xml.EndElement:
+			p.end(t)
+			p.depth--
+		case xml.CharData:
+			p.char(string(t))
+		}
+	}
+	p.finish()
+	return p.blocks, nil
+}
+
+type articleParser struct {
+	blocks []*ArticleBlock
+	depth  int // 根元素为1，顶层元素为2
+
+	cur      *ArticleBlock // 当前的文本块
+	curDepth int
+	text     strings.Builder
+	item     strings.Builder
+
+	caption *ArticleImage // 正在读取说明的图片
+}
+
+func (p *articleParser) start(t xml.StartElement) {
+	name := strings.ToLower(t.Name.Local)
+	switch name {
+	case "img":
+		p.image(t)
+		return
+	case "hr":
+		p.flush()
+		p.blocks = append(p.blocks, &ArticleBlock{Type: ArticleBlockDivider})
+		return
+	case "br":
+		p.write("\n")
+		return
+	case "figcaption":
+		if n := len(p.blocks); n > 0 && p.blocks[n-1].Image != nil {
+			p.caption = p.blocks[n-1].Image
+		}
+		return
+	case "li":
+		p.flushItem()
+		return
+	case "pre":
+		if p.cur == nil {
+			p.begin(&ArticleBlock{Type: ArticleBlockCode})
+		}
+		return
+	}
+	if p.depth != 2 || p.cur != nil {
+		return
+	}
+	switch name {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		p.begin(&ArticleBlock{Type: ArticleBlockHeading, Level: int(name[1] - '0')})
+	case "blockquote":
+		p.begin(&ArticleBlock{Type: ArticleBlockQuote})
+	case "ul", "ol":
+		p.begin(&ArticleBlock{Type: ArticleBlockList})
+	case "figure":
+		// 图片与代码的容器，由内部元素决定类型
+	default:
+		p.begin(&ArticleBlock{Type: ArticleBlockParagraph})
+	}
+}
+
+func (p *articleParser) end(t xml.EndElement) {
+	switch strings.ToLower(t.Name.Local) {
+	case "figcaption":
+		if p.caption != nil {
+			p.caption.Caption = strings.TrimSpace(p.caption.Caption)
+			p.caption = nil
+		}
+	case "li":
+		p.flushItem()
+	case "p", "div":
+		// 引用与列表中的段落之间换行
+		if p.cur != nil && p.cur.Type == ArticleBlockQuote && p.depth > p.curDepth {
+			p.write("\n")
+		}
+	}
+	if p.cur != nil && p.depth <= p.curDepth {
+		p.finish()
+	}
+}
+
+func (p *articleParser) char(s string) {
+	if p.caption != nil {
+		p.caption.Caption += s
+		return
+	}
+	if p.cur == nil {
+		if strings.TrimSpace(s) == "" {
+			return
+		}
+		// 顶层的裸文本
+		p.begin(&ArticleBlock{Type: ArticleBlockParagraph})
+		p.curDepth = p.depth + 1
+	}
+	p.write(s)
+}
+
+func (p *articleParser) write(s string) {
+	if p.cur == nil {
+		return
+	}
+	if p.cur.Type == ArticleBlockList {
+		p.item.WriteString(s)
+		return
+	}
+	p.text.WriteString(s)
+}
+
+func (p *articleParser) begin(b *ArticleBlock) {
+	p.cur, p.curDepth = b, p.depth
+}
+
+func (p *articleParser) flushItem() {
+	if s := strings.TrimSpace(p.item.String()); s != "" && p.cur != nil {
+		p.cur.Items = append(p.cur.Items, s)
+	}
+	p.item.Reset()
+}
+
+// flush 将已读取的文本作为一个块输出，当前块未结束时继续读取
+func (p *articleParser) flush() {
+	if p.cur == nil {
+		return
+	}
+	b := p.cur
+	if b.Type == ArticleBlockList {
+		p.flushItem()
+	} else if b.Type == ArticleBlockCode {
+		b.Text = strings.Trim(p.text.String(), "\n")
+	} else {
+		b.Text = strings.TrimSpace(p.text.String())
+	}
+	p.text.Reset()
+	if b.Text != "" || len(b.Items) > 0 {
+		p.blocks = append(p.blocks, b)
+	}
+	p.cur = &ArticleBlock{Type: b.Type, Level: b.Level}
+}
+
+// finish 结束当前块
+func (p *articleParser) finish() {
+	p.flush()
+	p.cur = nil
+}
+
+func (p *articleParser) image(t xml.StartElement) {
+	var (
+		src, class, aid string
+		w, h            int
+	)
+	for _, a := range t.Attr {
+		switch strings.ToLower(a.Name.Local) {
+		case "data-src":
+			src = a.Value
+		case "src":
+			if src == "" {
+				src = a.Value
+			}
+		case "class":
+			class = a.Value
+		case "aid":
+			aid = a.Value
+		case "width":
+			w, _ = strconv.Atoi(a.Value)
+		case "height":
+			h, _ = strconv.Atoi(a.Value)
+		}
+	}
+	p.flush()
+	src = httpsURL(src)
+	for _, c := range strings.Fields(class) {
+		if strings.HasPrefix(c, "cut-off") {
+			p.blocks = append(p.blocks, &ArticleBlock{Type: ArticleBlockDivider})
+			return
+		}
+		if strings.HasSuffix(c, "-card") {
+			tp := strings.TrimSuffix(c, "-card")
+			if v, ok := articleCardTypes[tp]; ok {
+				tp = v
+			}
+			p.blocks = append(p.blocks, &ArticleBlock{Type: ArticleBlockCard, Card: &ArticleCard{Type: tp, ID: aid, Cover: src}})
+			return
+		}
+	}
+	if src == "" {
+		return
+	}
+	p.blocks = append(p.blocks, &ArticleBlock{Type: ArticleBlockImage, Image: &ArticleImage{URL: src, Width: w, Height: h}})
+}
+
+// articleSummaryLen 未设置摘要时从正文截取的字数
+const articleSummaryLen = 100
+
+// articleWords 正文字数与默认摘要，不含图片与卡片
+func articleWords(content string) (int, string, error) {
+	blocks, err := ParseArticleHTML(content)
+	if err != nil {
+		return 0, "", err
+	}
+	var sb strings.Builder
+	for _, b := range blocks {
+		sb.WriteString(b.Text)
+		for _, item := range b.Items {
+			sb.WriteString(item)
+		}
+	}
+	text := []rune(sb.String())
+	summary := text
+	if len(summary) > articleSummaryLen {
+		summary = summary[:articleSummaryLen]
+	}
+	return len(text), string(summary), nil
+}
@@ -0,0 +1,165 @@
+package biligo
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/iyear/biligo/biligotest"
+)
+
+const testArticleHTML = `<p>第一段<strong>加粗</strong> &amp;<br>换行</p>` +
+	`<h2>标题</h2>` +
+	`<figure class="img-box" contenteditable="false"><img data-src="//i0.hdslb.com/bfs/article/a.jpg" width="1280" height="720"><figcaption class="caption">图片说明</figcaption></figure>` +
+	`<figure class="img-box" contenteditable="false"><img src="//i0.hdslb.com/bfs/article/card.png" class="video-card nomal" aid="av170001"></figure>` +
+	`<figure class="img-box" contenteditable="false"><img src="//i0.hdslb.com/bfs/article/card.png" class="fanju-card" aid="ss33802"></figure>` +
+	`<blockquote><p>引用1</p><p>引用2</p></blockquote>` +
+	`<ul class=" list-paddingleft-2"><li><p>项1</p></li><li><p>项2</p></li></ul>` +
+	`<figure class="code-box"><pre class="language-go"><code>a &lt; b
+c</code></pre></figure>` +
+	`<figure class="img-box"><img src="//i0.hdslb.com/bfs/article/line.png" class="cut-off-5"></figure>` +
+	`<p>前<img src="//i0.hdslb.com/bfs/article/b.jpg">后</p>`
+
+func TestParseArticleHTML(t *testing.T) {
+	blocks, err := ParseArticleHTML(testArticleHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*ArticleBlock{
+		{Type: ArticleBlockParagraph, Text: "第一段加粗 &\n换行"},
+		{Type: ArticleBlockHeading, Text: "标题", Level: 2},
+		{Type: ArticleBlockImage, Image: &ArticleImage{URL: "https://i0.hdslb.com/bfs/article/a.jpg", Width: 1280, Height: 720, Caption: "图片说明"}},
+		{Type: ArticleBlockCard, Card: &ArticleCard{Type: "video", ID: "av170001", Cover: "https://i0.hdslb.com/bfs/article/card.png"}},
+		{Type: ArticleBlockCard, Card: &ArticleCard{Type: "bangumi", ID: "ss33802", Cover: "https://i0.hdslb.com/bfs/article/card.png"}},
+		{Type: ArticleBlockQuote, Text: "引用1\n引用2"},
+		{Type: ArticleBlockList, Items: []string{"项1", "项2"}},
+		{Type: ArticleBlockCode, Text: "a < b\nc"},
+		{Type: ArticleBlockDivider},
+		{Type: ArticleBlockParagraph, Text: "前"},
+		{Type: ArticleBlockImage, Image: &ArticleImage{URL: "https://i0.hdslb.com/bfs/article/b.jpg"}},
+		{Type: ArticleBlockParagraph, Text: "后"},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("got %d blocks", len(blocks))
+	}
+	for i, b := range blocks {
+		w := want[i]
+		ok := b.Type == w.Type && b.Text == w.Text && b.Level == w.Level && len(b.Items) == len(w.Items)
+		for j := range w.Items {
+			ok = ok && b.Items[j] == w.Items[j]
+		}
+		if w.Image != nil {
+			ok = ok && b.Image != nil && *b.Image == *w.Image
+		}
+		if w.Card != nil {
+			ok = ok && b.Card != nil && *b.Card == *w.Card
+		}
+		if !ok {
+			t.Errorf("%d: %+v", i, b)
+		}
+	}
+}
+
+func TestOffline_ArticleGetContent(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+	srv.HandleJSON(biligotest.BiliApiURL, "x/article/view", 0, "0", map[string]interface{}{
+		"id":      1,
+		"title":   "专栏",
+		"author":  map[string]interface{}{"mid": 2, "name": "碧诗"},
+		"stats":   map[string]interface{}{"view": 100},
+		"list":    map[string]interface{}{"id": 10, "name": "文集"},
+		"content": testArticleHTML,
+	})
+	r, err := c.ArticleGetContent(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ID != 1 || r.Author.MID != 2 || r.Stats.View != 100 || r.List.ID != 10 || len(r.Blocks) != 12 {
+		t.Errorf("%+v", r)
+	}
+
+	srv.HandleJSON(biligotest.BiliApiURL, "x/article/list/web/articles", 0, "0", map[string]interface{}{
+		"list":     map[string]interface{}{"id": 10, "name": "文集", "articles_count": 2},
+		"articles": []map[string]interface{}{{"id": 1}, {"id": 2}},
+		"author":   map[string]interface{}{"mid": 2},
+	})
+	list, err := c.ArticleGetReadList(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.List.ArticlesCount != 2 || len(list.Articles) != 2 || list.Articles[1].ID != 2 {
+		t.Errorf("%+v", list)
+	}
+}
+
+func TestOffline_SpaceArticleIter(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+	srv.HandleFunc(biligotest.BiliApiURL, "x/space/wbi/article", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("mid") != "2" || q.Get("sort") != "view" || q.Get("w_rid") == "" {
+			t.Errorf("%v", q)
+		}
+		pn, _ := strconv.Atoi(q.Get("pn"))
+		biligotest.WriteJSON(w, 0, "0", map[string]interface{}{
+			"articles": []map[string]interface{}{{"id": pn*10 + 1}, {"id": pn*10 + 2}},
+			"pn":       pn,
+			"ps":       2,
+			"count":    3,
+		})
+	})
+	it := c.SpaceArticleIter(2, "view", &IterOption{PageSize: 2})
+	var ids []int64
+	for it.Next() {
+		ids = append(ids, it.Item().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 4 || ids[0] != 11 || ids[3] != 22 {
+		t.Errorf("%v", ids)
+	}
+}
+
+func TestOffline_ArticlePublish(t *testing.T) {
+	b, srv := newFakeBiliClient(t)
+	srv.HandleJSON(biligotest.BiliApiURL, "x/article/creative/draft/addupdate", 0, "0", map[string]interface{}{"aid": 100})
+	srv.HandleJSON(biligotest.BiliApiURL, "x/article/creative/article/submit", 0, "0", map[string]interface{}{"aid": 200})
+	srv.HandleJSON(biligotest.BiliApiURL, "x/article/like", 0, "0", nil)
+	srv.HandleJSON(biligotest.BiliApiURL, "x/web-interface/coin/add", 0, "0", map[string]interface{}{"like": false})
+
+	d := &ArticleDraft{Title: "标题", Content: "<p>你好</p><p>world</p>", Category: 2, Tags: []string{"a", "b"}, Original: true}
+	id, err := b.ArticleSaveDraft(d)
+	if err != nil || id != 100 {
+		t.Fatal(id, err)
+	}
+	f := srv.LastRequest().Form
+	if f.Get("words") != "7" || f.Get("summary") != "你好world" || f.Get("tags") != "a,b" || f.Get("original") != "1" || f.Get("csrf") != "fake_bili_jct" || f.Get("aid") != "" {
+		t.Errorf("%v", f)
+	}
+
+	d.ID, d.Summary = id, "摘要"
+	cvid, err := b.ArticlePublish(d)
+	if err != nil || cvid != 200 {
+		t.Fatal(cvid, err)
+	}
+	if f = srv.LastRequest().Form; f.Get("aid") != "100" || f.Get("summary") != "摘要" {
+		t.Errorf("%v", f)
+	}
+
+	if err = b.ArticleLike(cvid, false); err != nil {
+		t.Fatal(err)
+	}
+	if f = srv.LastRequest().Form; f.Get("id") != "200" || f.Get("type") != "2" {
+		t.Errorf("%v", f)
+	}
+	if err = b.ArticleAddCoins(cvid, 2, 1); err != nil {
+		t.Fatal(err)
+	}
+	if f = srv.LastRequest().Form; f.Get("avtype") != "2" || f.Get("upid") != "2" || f.Get("aid") != "200" {
+		t.Errorf("%v", f)
+	}
+
+	if _, err = b.ArticlePublish(&ArticleDraft{}); err == nil {
+		t.Error("expected error for empty title")
+	}
+}
@@ -1,6 +1,7 @@
 package biligo
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/golang/protobuf/proto"
@@ -15,12 +16,20 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type BiliClient struct {
-	Me   *Account
-	auth *CookieAuth
+	Me *Account
+
+	auth      atomic.Value // *CookieAuth 刷新时整体替换
+	refreshMu sync.Mutex
+	onAuth    func(auth *CookieAuth)
+	store     CredentialStore
+	storeKey  string
 
 	*baseClient
 }
@@ -31,6 +40,7 @@ type CookieAuth struct {
 	SESSDATA        string // SESSDATA
 	BiliJCT         string // bili_jct
 	BUVID3          string // buvid3
+	RefreshToken    string // 登录时返回的refresh_token，用于刷新Cookie
 }
 
 // Cookie 获取字符串
@@ -59,34 +69,104 @@ type BiliSetting struct {
 	UserAgent string
 
 	Logger *log.Logger
+
+	// WBI签名器，请求路径带有wbi的接口会自动签名
+	//
+	// 默认从导航栏接口获取密钥并每日刷新，可使用 NewWbiSignerWithKeys 指定固定密钥
+	Wbi *WbiSigner
+
+	// 限流设置，可按全局和域名分别限流
+	//
+	// 默认nil 不限流
+	RateLimit *RateLimit
+
+	// 重试策略，网络错误、HTTP 5xx和风控错误码时重试，POST 等请求只在连接失败时重试
+	//
+	// 默认nil 不重试
+	Retry *RetryPolicy
+
+	// 中间件，可用于追踪、统计、注入Header、缓存等，靠前的在外层
+	//
+	// DebugMode为true时会在最内层添加 DebugMiddleware
+	Middlewares []Middleware
+
+	// 替换请求的域名，key为 BiliApiURL BiliLiveURL 等常量，value为新的地址
+	//
+	// 可用于指向 biligotest.Server 进行离线测试，或使用反向代理
+	Endpoints map[string]string
+
+	// 按请求选择代理，连接失败或返回-412时标记代理不可用，可使用 NewProxyPool
+	//
+	// 只作用于API请求，Client的Transport需要为 *http.Transport 或nil。默认nil 不使用代理
+	Proxy ProxyProvider
+
+	// Cookie刷新成功后调用，可用于保存新的登录信息
+	OnAuthRefresh func(auth *CookieAuth)
+
+	// 登录信息存储，不为nil时创建Client及刷新Cookie后自动保存
+	Store CredentialStore
+	// 保存到 Store 时使用的账户名，默认为DedeUserID
+	StoreKey string
 }
 
 // NewBiliClient
 //
 // 带有账户Cookie的Client，用于访问私人操作API
 func NewBiliClient(setting *BiliSetting) (*BiliClient, error) {
+	return NewBiliClientWithContext(context.Background(), setting)
+}
+
+// NewBiliClientWithContext 同 NewBiliClient，ctx 用于取消获取账户信息的请求
+func NewBiliClientWithContext(ctx context.Context, setting *BiliSetting) (*BiliClient, error) {
+	return newBiliClient(ctx, setting, true)
+}
+
+// newBiliClient save为true且设置了 Store 时，验证登录信息后保存
+func newBiliClient(ctx context.Context, setting *BiliSetting, save bool) (*BiliClient, error) {
 	if setting.Auth == nil {
 		return nil, errors.New("auth cannot be nil")
 	}
 
 	bili := &BiliClient{
-		auth: setting.Auth,
+		onAuth:   setting.OnAuthRefresh,
+		store:    setting.Store,
+		storeKey: setting.StoreKey,
 		baseClient: newBaseClient(&baseSetting{
-			Client:    setting.Client,
-			DebugMode: setting.DebugMode,
-			UserAgent: setting.UserAgent,
-			Prefix:    "BiliClient ",
-			Logger:    setting.Logger,
+			Client:      setting.Client,
+			DebugMode:   setting.DebugMode,
+			UserAgent:   setting.UserAgent,
+			Prefix:      "BiliClient ",
+			Logger:      setting.Logger,
+			Wbi:         setting.Wbi,
+			RateLimit:   setting.RateLimit,
+			Retry:       setting.Retry,
+			Middlewares: setting.Middlewares,
+			Endpoints:   setting.Endpoints,
+			Proxy:       setting.Proxy,
 		}),
 	}
 
-	account, err := bili.GetMe()
+	bili.auth.Store(setting.Auth)
+	bili.account = func() string { return bili.GetCookieAuth().DedeUserID }
+
+	account, err := bili.GetMeWithContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	bili.Me = account
 
+	if bili.store != nil {
+		if bili.storeKey == "" {
+			bili.storeKey = setting.Auth.DedeUserID
+		}
+		if save {
+			if err = bili.store.Save(bili.storeKey, setting.Auth); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	return bili, nil
 }
 
@@ -94,7 +174,12 @@ func NewBiliClient(setting *BiliSetting) (*BiliClient, error) {
 //
 // 获取个人基本信息
 func (b *BiliClient) GetMe() (*Account, error) {
-	resp, err := b.RawParse(BiliApiURL,
+	return b.GetMeWithContext(context.Background())
+}
+
+// GetMeWithContext 同 GetMe，ctx 用于取消请求或设置超时
+func (b *BiliClient) GetMeWithContext(ctx context.Context) (*Account, error) {
+	resp, err := b.RawParseWithContext(ctx, BiliApiURL,
 		"x/member/web/account",
 		"GET",
 		nil,
@@ -123,20 +208,33 @@ func (b *BiliClient) SetUA(ua string) {
 	b.ua = ua
 }
 
+// Use
+//
+// 添加中间件，添加在已有中间件的外层。非并发安全，请在发起请求前调用
+func (b *BiliClient) Use(mw ...Middleware) {
+	b.middlewares = append(append([]Middleware{}, mw...), b.middlewares...)
+}
+
 // Raw
 //
 // base末尾带/
 func (b *BiliClient) Raw(base, endpoint, method string, payload map[string]string) ([]byte, error) {
-	raw, err := b.raw(base, endpoint, method, payload,
+	return b.RawWithContext(context.Background(), base, endpoint, method, payload)
+}
+
+// RawWithContext 同 Raw，ctx 用于取消请求或设置超时
+func (b *BiliClient) RawWithContext(ctx context.Context, base, endpoint, method string, payload map[string]string) ([]byte, error) {
+	auth := b.GetCookieAuth()
+	raw, err := b.raw(ctx, base, endpoint, method, payload,
 		func(d *url.Values) {
 			switch method {
 			case "POST":
-				d.Add("csrf", b.auth.BiliJCT)
+				d.Add("csrf", auth.BiliJCT)
 			}
 		},
 		func(r *http.Request) {
 			r.Header.Add("Cookie", fmt.Sprintf("DedeUserID=%s;SESSDATA=%s;DedeUserID__ckMd5=%s",
-				b.auth.DedeUserID, b.auth.SESSDATA, b.auth.DedeUserIDCkMd5))
+				auth.DedeUserID, auth.SESSDATA, auth.DedeUserIDCkMd5))
 		})
 	if err != nil {
 		return nil, err
@@ -147,25 +245,38 @@ func (b *BiliClient) Raw(base, endpoint, method string, payload map[string]strin
 // RawParse
 //
 // base末尾带/
+//
+// 响应Code不为0时返回 *APIError
 func (b *BiliClient) RawParse(base, endpoint, method string, payload map[string]string) (*Response, error) {
-	raw, err := b.Raw(base, endpoint, method, payload)
+	return b.RawParseWithContext(context.Background(), base, endpoint, method, payload)
+}
+
+// RawParseWithContext 同 RawParse，ctx 用于取消请求或设置超时
+func (b *BiliClient) RawParseWithContext(ctx context.Context, base, endpoint, method string, payload map[string]string) (*Response, error) {
+	raw, err := b.RawWithContext(ctx, base, endpoint, method, payload)
 	if err != nil {
 		return nil, err
 	}
-	return b.parse(raw)
+	return b.parse(endpoint, raw)
 }
 
 // Upload 上传文件
 //
 // base末尾带/
 func (b *BiliClient) Upload(base, endpoint string, payload map[string]string, files []*FileUpload) ([]byte, error) {
-	raw, err := b.upload(base, endpoint, payload, files, func(m *multipart.Writer) error {
-		m.WriteField("csrf", b.auth.BiliJCT)
-		m.WriteField("csrf_token", b.auth.BiliJCT)
+	return b.UploadWithContext(context.Background(), base, endpoint, payload, files)
+}
+
+// UploadWithContext 同 Upload，ctx 用于取消请求或设置超时
+func (b *BiliClient) UploadWithContext(ctx context.Context, base, endpoint string, payload map[string]string, files []*FileUpload) ([]byte, error) {
+	auth := b.GetCookieAuth()
+	raw, err := b.upload(ctx, base, endpoint, payload, files, func(m *multipart.Writer) error {
+		m.WriteField("csrf", auth.BiliJCT)
+		m.WriteField("csrf_token", auth.BiliJCT)
 		return nil
 	}, func(r *http.Request) {
 		r.Header.Add("Cookie", fmt.Sprintf("DedeUserID=%s;SESSDATA=%s;DedeUserID__ckMd5=%s",
-			b.auth.DedeUserID, b.auth.SESSDATA, b.auth.DedeUserIDCkMd5))
+			auth.DedeUserID, auth.SESSDATA, auth.DedeUserIDCkMd5))
 	})
 	if err != nil {
 		return nil, err
@@ -176,26 +287,39 @@ func (b *BiliClient) Upload(base, endpoint string, payload map[string]string, fi
 // UploadParse 上传文件
 //
 // base末尾带/
+//
+// 响应Code不为0时返回 *APIError
 func (b *BiliClient) UploadParse(base, endpoint string, payload map[string]string, files []*FileUpload) (*Response, error) {
-	raw, err := b.Upload(base, endpoint, payload, files)
+	return b.UploadParseWithContext(context.Background(), base, endpoint, payload, files)
+}
+
+// UploadParseWithContext 同 UploadParse，ctx 用于取消请求或设置超时
+func (b *BiliClient) UploadParseWithContext(ctx context.Context, base, endpoint string, payload map[string]string, files []*FileUpload) (*Response, error) {
+	raw, err := b.UploadWithContext(ctx, base, endpoint, payload, files)
 	if err != nil {
 		return nil, err
 	}
-	return b.parse(raw)
+	return b.parse(endpoint, raw)
 }
 
 // GetCookieAuth
 //
-// 获取Cookie信息
+// 获取Cookie信息，刷新Cookie后返回新的值，不要修改返回值
 func (b *BiliClient) GetCookieAuth() *CookieAuth {
-	return b.auth
+	return b.auth.Load().(*CookieAuth)
 }
 
 // GetNavInfo
 //
 // 获取我的导航栏信息(大部分的用户信息都在这里了)
 func (b *BiliClient) GetNavInfo() (*NavInfo, error) {
-	resp, err := b.RawParse(
+	return b.GetNavInfoWithContext(context.Background())
+}
+
+// GetNavInfoWithContext 同 GetNavInfo，ctx 用于取消请求或设置超时
+func (b *BiliClient) GetNavInfoWithContext(ctx context.Context) (*NavInfo, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/web-interface/nav",
 		"GET",
@@ -215,7 +339,13 @@ func (b *BiliClient) GetNavInfo() (*NavInfo, error) {
 //
 // 获取我的状态数
 func (b *BiliClient) GetNavStat() (*NavStat, error) {
-	resp, err := b.RawParse(
+	return b.GetNavStatWithContext(context.Background())
+}
+
+// GetNavStatWithContext 同 GetNavStat，ctx 用于取消请求或设置超时
+func (b *BiliClient) GetNavStatWithContext(ctx context.Context) (*NavStat, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/web-interface/nav/stat",
 		"GET",
@@ -235,7 +365,13 @@ func (b *BiliClient) GetNavStat() (*NavStat, error) {
 //
 // 获取每日经验任务和认证相关任务的完成情况
 func (b *BiliClient) GetExpRewardStat() (*ExpRewardStat, error) {
-	resp, err := b.RawParse(
+	return b.GetExpRewardStatWithContext(context.Background())
+}
+
+// GetExpRewardStatWithContext 同 GetExpRewardStat，ctx 用于取消请求或设置超时
+func (b *BiliClient) GetExpRewardStatWithContext(ctx context.Context) (*ExpRewardStat, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/member/web/exp/reward",
 		"GET",
@@ -255,7 +391,13 @@ func (b *BiliClient) GetExpRewardStat() (*ExpRewardStat, error) {
 //
 // 获取每日投币获得经验数，不存在延迟 上限50经验
 func (b *BiliClient) GetExpCoinReward() (int, error) {
-	resp, err := b.Raw(
+	return b.GetExpCoinRewardWithContext(context.Background())
+}
+
+// GetExpCoinRewardWithContext 同 GetExpCoinReward，ctx 用于取消请求或设置超时
+func (b *BiliClient) GetExpCoinRewardWithContext(ctx context.Context) (int, error) {
+	resp, err := b.RawWithContext(
+		ctx,
 		BiliMainURL,
 		"plus/account/exp.php",
 		"GET",
@@ -272,7 +414,7 @@ func (b *BiliClient) GetExpCoinReward() (int, error) {
 		return -1, err
 	}
 	if tResp.Code != 0 {
-		return -1, fmt.Errorf("(%d) %s", tResp.Code, tResp.Message)
+		return -1, &APIError{Code: tResp.Code, Message: tResp.Message, Endpoint: "plus/account/exp.php", Raw: resp}
 	}
 	return tResp.Number, nil
 }
@@ -281,7 +423,13 @@ func (b *BiliClient) GetExpCoinReward() (int, error) {
 //
 // 获取大会员信息
 func (b *BiliClient) GetVipStat() (*VipStat, error) {
-	resp, err := b.RawParse(
+	return b.GetVipStatWithContext(context.Background())
+}
+
+// GetVipStatWithContext 同 GetVipStat，ctx 用于取消请求或设置超时
+func (b *BiliClient) GetVipStatWithContext(ctx context.Context) (*VipStat, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/vip/web/user/info",
 		"GET",
@@ -301,7 +449,13 @@ func (b *BiliClient) GetVipStat() (*VipStat, error) {
 //
 // 获取账户安全情况
 func (b *BiliClient) GetAccountSafetyStat() (*AccountSafetyStat, error) {
-	resp, err := b.RawParse(
+	return b.GetAccountSafetyStatWithContext(context.Background())
+}
+
+// GetAccountSafetyStatWithContext 同 GetAccountSafetyStat，ctx 用于取消请求或设置超时
+func (b *BiliClient) GetAccountSafetyStatWithContext(ctx context.Context) (*AccountSafetyStat, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliPassportURL,
 		"web/site/user/info",
 		"GET",
@@ -321,7 +475,13 @@ func (b *BiliClient) GetAccountSafetyStat() (*AccountSafetyStat, error) {
 //
 // 获取账户实名认证状态
 func (b *BiliClient) GetRealNameStat() (bool, error) {
-	resp, err := b.RawParse(
+	return b.GetRealNameStatWithContext(context.Background())
+}
+
+// GetRealNameStatWithContext 同 GetRealNameStat，ctx 用于取消请求或设置超时
+func (b *BiliClient) GetRealNameStatWithContext(ctx context.Context) (bool, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/member/realname/status",
 		"GET",
@@ -343,7 +503,13 @@ func (b *BiliClient) GetRealNameStat() (bool, error) {
 //
 // 获取账户实名认证详细信息
 func (b *BiliClient) GetRealNameInfo() (*RealNameInfo, error) {
-	resp, err := b.RawParse(
+	return b.GetRealNameInfoWithContext(context.Background())
+}
+
+// GetRealNameInfoWithContext 同 GetRealNameInfo，ctx 用于取消请求或设置超时
+func (b *BiliClient) GetRealNameInfoWithContext(ctx context.Context) (*RealNameInfo, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/member/realname/apply/status",
 		"GET",
@@ -363,7 +529,13 @@ func (b *BiliClient) GetRealNameInfo() (*RealNameInfo, error) {
 //
 // mid数组
 func (b *BiliClient) FollowingsGetMy() ([]int64, error) {
-	resp, err := b.RawParse(
+	return b.FollowingsGetMyWithContext(context.Background())
+}
+
+// FollowingsGetMyWithContext 同 FollowingsGetMy，ctx 用于取消请求或设置超时
+func (b *BiliClient) FollowingsGetMyWithContext(ctx context.Context) ([]int64, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliVcURL,
 		"feed/v1/feed/get_attention_list",
 		"GET",
@@ -390,11 +562,17 @@ func (b *BiliClient) FollowingsGetMy() ([]int64, error) {
 //
 // order 1:最常访问 2:最近关注
 func (b *BiliClient) FollowingsGetMyDetail(pn int, ps int, order int) (*FollowingsDetail, error) {
+	return b.FollowingsGetMyDetailWithContext(context.Background(), pn, ps, order)
+}
+
+// FollowingsGetMyDetailWithContext 同 FollowingsGetMyDetail，ctx 用于取消请求或设置超时
+func (b *BiliClient) FollowingsGetMyDetailWithContext(ctx context.Context, pn int, ps int, order int) (*FollowingsDetail, error) {
 	var o = map[int]string{
 		1: "attention",
 		2: "",
 	}
-	resp, err := b.RawParse(
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/relation/followings",
 		"GET",
@@ -420,7 +598,13 @@ func (b *BiliClient) FollowingsGetMyDetail(pn int, ps int, order int) (*Followin
 //
 // 获取最近一周的硬币变化情况
 func (b *BiliClient) GetCoinLogs() ([]*CoinLog, error) {
-	resp, err := b.RawParse(
+	return b.GetCoinLogsWithContext(context.Background())
+}
+
+// GetCoinLogsWithContext 同 GetCoinLogs，ctx 用于取消请求或设置超时
+func (b *BiliClient) GetCoinLogsWithContext(ctx context.Context) ([]*CoinLog, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/member/web/coin/log",
 		"GET",
@@ -442,7 +626,13 @@ func (b *BiliClient) GetCoinLogs() ([]*CoinLog, error) {
 //
 // 获取关系状态数
 func (b *BiliClient) GetRelationStat(mid int64) (*RelationStat, error) {
-	resp, err := b.RawParse(
+	return b.GetRelationStatWithContext(context.Background(), mid)
+}
+
+// GetRelationStatWithContext 同 GetRelationStat，ctx 用于取消请求或设置超时
+func (b *BiliClient) GetRelationStatWithContext(ctx context.Context, mid int64) (*RelationStat, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/relation/stat",
 		"GET",
@@ -464,7 +654,13 @@ func (b *BiliClient) GetRelationStat(mid int64) (*RelationStat, error) {
 //
 // 获取UP主状态数，该接口需要任意用户登录，否则不会返回任何数据
 func (b *BiliClient) GetUpStat(mid int64) (*UpStat, error) {
-	resp, err := b.RawParse(
+	return b.GetUpStatWithContext(context.Background(), mid)
+}
+
+// GetUpStatWithContext 同 GetUpStat，ctx 用于取消请求或设置超时
+func (b *BiliClient) GetUpStatWithContext(ctx context.Context, mid int64) (*UpStat, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/upstat",
 		"GET",
@@ -486,7 +682,13 @@ func (b *BiliClient) GetUpStat(mid int64) (*UpStat, error) {
 //
 // Link:https://github.com/SocialSisterYi/bilibili-API-collect/blob/master/message/msg.md#%E9%80%9A%E7%9F%A5%E6%B6%88%E6%81%AF
 func (b *BiliClient) GetMsgUnread() (*MsgUnRead, error) {
-	resp, err := b.RawParse(
+	return b.GetMsgUnreadWithContext(context.Background())
+}
+
+// GetMsgUnreadWithContext 同 GetMsgUnread，ctx 用于取消请求或设置超时
+func (b *BiliClient) GetMsgUnreadWithContext(ctx context.Context) (*MsgUnRead, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/msgfeed/unread",
 		"GET",
@@ -506,7 +708,13 @@ func (b *BiliClient) GetMsgUnread() (*MsgUnRead, error) {
 //
 // reason 备注 最大40字符
 func (b *BiliClient) SpaceSetTopArchive(aid int64, reason string) error {
-	_, err := b.RawParse(
+	return b.SpaceSetTopArchiveWithContext(context.Background(), aid, reason)
+}
+
+// SpaceSetTopArchiveWithContext 同 SpaceSetTopArchive，ctx 用于取消请求或设置超时
+func (b *BiliClient) SpaceSetTopArchiveWithContext(ctx context.Context, aid int64, reason string) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/top/arc/set",
 		"POST",
@@ -525,7 +733,13 @@ func (b *BiliClient) SpaceSetTopArchive(aid int64, reason string) error {
 //
 // 取消置顶视频
 func (b *BiliClient) SpaceCancelTopArchive() error {
-	_, err := b.RawParse(
+	return b.SpaceCancelTopArchiveWithContext(context.Background())
+}
+
+// SpaceCancelTopArchiveWithContext 同 SpaceCancelTopArchive，ctx 用于取消请求或设置超时
+func (b *BiliClient) SpaceCancelTopArchiveWithContext(ctx context.Context) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/top/arc/cancel",
 		"POST",
@@ -538,7 +752,13 @@ func (b *BiliClient) SpaceCancelTopArchive() error {
 //
 // reason 备注 最大40字符
 func (b *BiliClient) SpaceAddMasterpieces(aid int64, reason string) error {
-	_, err := b.RawParse(
+	return b.SpaceAddMasterpiecesWithContext(context.Background(), aid, reason)
+}
+
+// SpaceAddMasterpiecesWithContext 同 SpaceAddMasterpieces，ctx 用于取消请求或设置超时
+func (b *BiliClient) SpaceAddMasterpiecesWithContext(ctx context.Context, aid int64, reason string) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/masterpiece/add",
 		"POST",
@@ -554,7 +774,13 @@ func (b *BiliClient) SpaceAddMasterpieces(aid int64, reason string) error {
 //
 // 取消代表作视频
 func (b *BiliClient) SpaceCancelMasterpiece(aid int64) error {
-	_, err := b.RawParse(
+	return b.SpaceCancelMasterpieceWithContext(context.Background(), aid)
+}
+
+// SpaceCancelMasterpieceWithContext 同 SpaceCancelMasterpiece，ctx 用于取消请求或设置超时
+func (b *BiliClient) SpaceCancelMasterpieceWithContext(ctx context.Context, aid int64) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/masterpiece/cancel",
 		"POST",
@@ -573,7 +799,13 @@ func (b *BiliClient) SpaceCancelMasterpiece(aid int64) error {
 //
 // 感觉这个功能已经被废弃了
 func (b *BiliClient) SpaceSetTags(tags []string) error {
-	_, err := b.RawParse(
+	return b.SpaceSetTagsWithContext(context.Background(), tags)
+}
+
+// SpaceSetTagsWithContext 同 SpaceSetTags，ctx 用于取消请求或设置超时
+func (b *BiliClient) SpaceSetTagsWithContext(ctx context.Context, tags []string) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/acc/tags/set",
 		"POST",
@@ -588,7 +820,13 @@ func (b *BiliClient) SpaceSetTags(tags []string) error {
 //
 // 删除公告留空即可 少于150字符
 func (b *BiliClient) SpaceSetNotice(notice string) error {
-	_, err := b.RawParse(
+	return b.SpaceSetNoticeWithContext(context.Background(), notice)
+}
+
+// SpaceSetNoticeWithContext 同 SpaceSetNotice，ctx 用于取消请求或设置超时
+func (b *BiliClient) SpaceSetNoticeWithContext(ctx context.Context, notice string) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/notice/set",
 		"POST",
@@ -603,7 +841,13 @@ func (b *BiliClient) SpaceSetNotice(notice string) error {
 //
 // 获取我的空间近期玩的游戏
 func (b *BiliClient) SpaceGetMyLastPlayGame() ([]*SpaceGame, error) {
-	resp, err := b.RawParse(
+	return b.SpaceGetMyLastPlayGameWithContext(context.Background())
+}
+
+// SpaceGetMyLastPlayGameWithContext 同 SpaceGetMyLastPlayGame，ctx 用于取消请求或设置超时
+func (b *BiliClient) SpaceGetMyLastPlayGameWithContext(ctx context.Context) ([]*SpaceGame, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/lastplaygame",
 		"GET",
@@ -625,7 +869,13 @@ func (b *BiliClient) SpaceGetMyLastPlayGame() ([]*SpaceGame, error) {
 //
 // 获取我的最近投币的视频明细
 func (b *BiliClient) SpaceGetMyLastVideoCoin() ([]*SpaceVideoCoin, error) {
-	resp, err := b.RawParse(
+	return b.SpaceGetMyLastVideoCoinWithContext(context.Background())
+}
+
+// SpaceGetMyLastVideoCoinWithContext 同 SpaceGetMyLastVideoCoin，ctx 用于取消请求或设置超时
+func (b *BiliClient) SpaceGetMyLastVideoCoinWithContext(ctx context.Context) ([]*SpaceVideoCoin, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/coin/video",
 		"GET",
@@ -650,7 +900,13 @@ func (b *BiliClient) SpaceGetMyLastVideoCoin() ([]*SpaceVideoCoin, error) {
 //
 // 获取我的频道列表
 func (b *BiliClient) ChanGetMy() (*ChannelList, error) {
-	resp, err := b.RawParse(
+	return b.ChanGetMyWithContext(context.Background())
+}
+
+// ChanGetMyWithContext 同 ChanGetMy，ctx 用于取消请求或设置超时
+func (b *BiliClient) ChanGetMyWithContext(ctx context.Context) (*ChannelList, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/channel/list",
 		"GET",
@@ -676,7 +932,13 @@ func (b *BiliClient) ChanGetMy() (*ChannelList, error) {
 //
 // intro 频道介绍
 func (b *BiliClient) ChanAdd(name string, intro string) (int64, error) {
-	resp, err := b.RawParse(
+	return b.ChanAddWithContext(context.Background(), name, intro)
+}
+
+// ChanAddWithContext 同 ChanAdd，ctx 用于取消请求或设置超时
+func (b *BiliClient) ChanAddWithContext(ctx context.Context, name string, intro string) (int64, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/channel/add",
 		"POST",
@@ -705,7 +967,13 @@ func (b *BiliClient) ChanAdd(name string, intro string) (int64, error) {
 //
 // intro 频道介绍
 func (b *BiliClient) ChanEdit(cid int64, name string, intro string) error {
-	_, err := b.RawParse(
+	return b.ChanEditWithContext(context.Background(), cid, name, intro)
+}
+
+// ChanEditWithContext 同 ChanEdit，ctx 用于取消请求或设置超时
+func (b *BiliClient) ChanEditWithContext(ctx context.Context, cid int64, name string, intro string) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/channel/edit",
 		"POST",
@@ -722,7 +990,13 @@ func (b *BiliClient) ChanEdit(cid int64, name string, intro string) error {
 //
 // cid 频道id
 func (b *BiliClient) ChanDel(cid int64) error {
-	_, err := b.RawParse(
+	return b.ChanDelWithContext(context.Background(), cid)
+}
+
+// ChanDelWithContext 同 ChanDel，ctx 用于取消请求或设置超时
+func (b *BiliClient) ChanDelWithContext(ctx context.Context, cid int64) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/channel/del",
 		"POST",
@@ -739,7 +1013,13 @@ func (b *BiliClient) ChanDel(cid int64) error {
 //
 // 如添加多个视频，仅会添加正确的
 func (b *BiliClient) ChanAddVideo(cid int64, aids []int64) ([]int64, error) {
-	resp, err := b.RawParse(
+	return b.ChanAddVideoWithContext(context.Background(), cid, aids)
+}
+
+// ChanAddVideoWithContext 同 ChanAddVideo，ctx 用于取消请求或设置超时
+func (b *BiliClient) ChanAddVideoWithContext(ctx context.Context, cid int64, aids []int64) ([]int64, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/channel/video/add",
 		"POST",
@@ -757,7 +1037,7 @@ func (b *BiliClient) ChanAddVideo(cid int64, aids []int64) ([]int64, error) {
 		return nil, err
 	}
 	// 完成后需要使用接口「查询用户频道中的视频」刷新
-	if _, err := b.ChanGetMyVideo(cid, 1, 1); err != nil {
+	if _, err := b.ChanGetMyVideoWithContext(ctx, cid, 1, 1); err != nil {
 		return nil, err
 	}
 	return result, nil
@@ -767,7 +1047,13 @@ func (b *BiliClient) ChanAddVideo(cid int64, aids []int64) ([]int64, error) {
 //
 // 删除频道视频
 func (b *BiliClient) ChanDelVideo(cid int64, aid int64) error {
-	_, err := b.RawParse(
+	return b.ChanDelVideoWithContext(context.Background(), cid, aid)
+}
+
+// ChanDelVideoWithContext 同 ChanDelVideo，ctx 用于取消请求或设置超时
+func (b *BiliClient) ChanDelVideoWithContext(ctx context.Context, cid int64, aid int64) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/channel/video/del",
 		"POST",
@@ -780,7 +1066,7 @@ func (b *BiliClient) ChanDelVideo(cid int64, aid int64) error {
 		return err
 	}
 	// 完成后需要使用接口「查询用户频道中的视频」刷新
-	if _, err = b.ChanGetMyVideo(cid, 1, 1); err != nil {
+	if _, err = b.ChanGetMyVideoWithContext(ctx, cid, 1, 1); err != nil {
 		return err
 	}
 	return nil
@@ -790,7 +1076,13 @@ func (b *BiliClient) ChanDelVideo(cid int64, aid int64) error {
 //
 // to 视频排序倒数位置 1为列表底部，视频总数为首端，与显示顺序恰好相反
 func (b *BiliClient) ChanSetVideoSort(cid int64, aid int64, to int) error {
-	_, err := b.RawParse(
+	return b.ChanSetVideoSortWithContext(context.Background(), cid, aid, to)
+}
+
+// ChanSetVideoSortWithContext 同 ChanSetVideoSort，ctx 用于取消请求或设置超时
+func (b *BiliClient) ChanSetVideoSortWithContext(ctx context.Context, cid int64, aid int64, to int) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/channel/video/sort",
 		"POST",
@@ -809,7 +1101,13 @@ func (b *BiliClient) ChanSetVideoSort(cid int64, aid int64, to int) error {
 //
 // 若Err为nil则没有无效视频
 func (b *BiliClient) ChanHasInvalidVideo(cid int64) error {
-	_, err := b.RawParse(
+	return b.ChanHasInvalidVideoWithContext(context.Background(), cid)
+}
+
+// ChanHasInvalidVideoWithContext 同 ChanHasInvalidVideo，ctx 用于取消请求或设置超时
+func (b *BiliClient) ChanHasInvalidVideoWithContext(ctx context.Context, cid int64) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/channel/video/check",
 		"GET",
@@ -830,7 +1128,13 @@ func (b *BiliClient) ChanHasInvalidVideo(cid int64) error {
 //
 // ps 每页项数
 func (b *BiliClient) ChanGetMyVideo(cid int64, pn int, ps int) (*ChanVideo, error) {
-	resp, err := b.RawParse(
+	return b.ChanGetMyVideoWithContext(context.Background(), cid, pn, ps)
+}
+
+// ChanGetMyVideoWithContext 同 ChanGetMyVideo，ctx 用于取消请求或设置超时
+func (b *BiliClient) ChanGetMyVideoWithContext(ctx context.Context, cid int64, pn int, ps int) (*ChanVideo, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/channel/video",
 		"GET",
@@ -855,7 +1159,13 @@ func (b *BiliClient) ChanGetMyVideo(cid int64, pn int, ps int) (*ChanVideo, erro
 //
 // 获取我的收藏夹列表
 func (b *BiliClient) FavGetMy() (*FavoritesList, error) {
-	resp, err := b.RawParse(
+	return b.FavGetMyWithContext(context.Background())
+}
+
+// FavGetMyWithContext 同 FavGetMy，ctx 用于取消请求或设置超时
+func (b *BiliClient) FavGetMyWithContext(ctx context.Context) (*FavoritesList, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v3/fav/folder/created/list-all",
 		"GET",
@@ -877,7 +1187,13 @@ func (b *BiliClient) FavGetMy() (*FavoritesList, error) {
 //
 // 获取收藏夹详细信息
 func (b *BiliClient) FavGetDetail(mlid int64) (*FavDetail, error) {
-	resp, err := b.RawParse(
+	return b.FavGetDetailWithContext(context.Background(), mlid)
+}
+
+// FavGetDetailWithContext 同 FavGetDetail，ctx 用于取消请求或设置超时
+func (b *BiliClient) FavGetDetailWithContext(ctx context.Context, mlid int64) (*FavDetail, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v3/fav/folder/info",
 		"GET",
@@ -905,7 +1221,13 @@ func (b *BiliClient) FavGetDetail(mlid int64) (*FavDetail, error) {
 //
 // cover 封面图url，会审核，不需要请留空
 func (b *BiliClient) FavAdd(title string, intro string, privacy bool, cover string) (*FavDetail, error) {
-	resp, err := b.RawParse(
+	return b.FavAddWithContext(context.Background(), title, intro, privacy, cover)
+}
+
+// FavAddWithContext 同 FavAdd，ctx 用于取消请求或设置超时
+func (b *BiliClient) FavAddWithContext(ctx context.Context, title string, intro string, privacy bool, cover string) (*FavDetail, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v3/fav/folder/add",
 		"POST",
@@ -930,7 +1252,13 @@ func (b *BiliClient) FavAdd(title string, intro string, privacy bool, cover stri
 //
 // 编辑收藏夹 参数注释与 FavAdd 相同
 func (b *BiliClient) FavEdit(mlid int64, title string, intro string, privacy bool, cover string) (*FavDetail, error) {
-	resp, err := b.RawParse(
+	return b.FavEditWithContext(context.Background(), mlid, title, intro, privacy, cover)
+}
+
+// FavEditWithContext 同 FavEdit，ctx 用于取消请求或设置超时
+func (b *BiliClient) FavEditWithContext(ctx context.Context, mlid int64, title string, intro string, privacy bool, cover string) (*FavDetail, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v3/fav/folder/edit",
 		"POST",
@@ -956,7 +1284,13 @@ func (b *BiliClient) FavEdit(mlid int64, title string, intro string, privacy boo
 //
 // 删除收藏夹，传入需要删除的mlid数组
 func (b *BiliClient) FavDel(mlids []int64) error {
-	_, err := b.RawParse(
+	return b.FavDelWithContext(context.Background(), mlids)
+}
+
+// FavDelWithContext 同 FavDel，ctx 用于取消请求或设置超时
+func (b *BiliClient) FavDelWithContext(ctx context.Context, mlids []int64) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v3/fav/folder/del",
 		"POST",
@@ -971,7 +1305,13 @@ func (b *BiliClient) FavDel(mlids []int64) error {
 //
 // 获取收藏夹全部内容id
 func (b *BiliClient) FavGetRes(mlid int64) ([]*FavRes, error) {
-	resp, err := b.RawParse(
+	return b.FavGetResWithContext(context.Background(), mlid)
+}
+
+// FavGetResWithContext 同 FavGetRes，ctx 用于取消请求或设置超时
+func (b *BiliClient) FavGetResWithContext(ctx context.Context, mlid int64) ([]*FavRes, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v3/fav/resource/ids",
 		"GET",
@@ -1007,7 +1347,13 @@ func (b *BiliClient) FavGetRes(mlid int64) ([]*FavRes, error) {
 //
 // ps 每页项数 ps不能太大，会报错
 func (b *BiliClient) FavGetResDetail(mlid int64, tid int, keyword string, order string, tp int, pn int, ps int) (*FavResDetail, error) {
-	resp, err := b.RawParse(
+	return b.FavGetResDetailWithContext(context.Background(), mlid, tid, keyword, order, tp, pn, ps)
+}
+
+// FavGetResDetailWithContext 同 FavGetResDetail，ctx 用于取消请求或设置超时
+func (b *BiliClient) FavGetResDetailWithContext(ctx context.Context, mlid int64, tid int, keyword string, order string, tp int, pn int, ps int) (*FavResDetail, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v3/fav/resource/list",
 		"GET",
@@ -1059,7 +1405,13 @@ func (b *BiliClient) FavGetResDetail(mlid int64, tid int, keyword string, order
 //
 // 视频合集：视频合集id
 func (b *BiliClient) FavCopyRes(from int64, to int64, mid int64, resources []string) error {
-	_, err := b.RawParse(
+	return b.FavCopyResWithContext(context.Background(), from, to, mid, resources)
+}
+
+// FavCopyResWithContext 同 FavCopyRes，ctx 用于取消请求或设置超时
+func (b *BiliClient) FavCopyResWithContext(ctx context.Context, from int64, to int64, mid int64, resources []string) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v3/fav/resource/copy",
 		"POST",
@@ -1078,7 +1430,13 @@ func (b *BiliClient) FavCopyRes(from int64, to int64, mid int64, resources []str
 //
 // 参数说明同 FavCopyRes
 func (b *BiliClient) FavMoveRes(from int64, to int64, mid int64, resources []string) error {
-	_, err := b.RawParse(
+	return b.FavMoveResWithContext(context.Background(), from, to, mid, resources)
+}
+
+// FavMoveResWithContext 同 FavMoveRes，ctx 用于取消请求或设置超时
+func (b *BiliClient) FavMoveResWithContext(ctx context.Context, from int64, to int64, mid int64, resources []string) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v3/fav/resource/move",
 		"POST",
@@ -1097,7 +1455,13 @@ func (b *BiliClient) FavMoveRes(from int64, to int64, mid int64, resources []str
 //
 // resources 同 FavCopyRes
 func (b *BiliClient) FavDelRes(mlid int64, resources []string) error {
-	_, err := b.RawParse(
+	return b.FavDelResWithContext(context.Background(), mlid, resources)
+}
+
+// FavDelResWithContext 同 FavDelRes，ctx 用于取消请求或设置超时
+func (b *BiliClient) FavDelResWithContext(ctx context.Context, mlid int64, resources []string) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v3/fav/resource/batch-del",
 		"POST",
@@ -1113,7 +1477,13 @@ func (b *BiliClient) FavDelRes(mlid int64, resources []string) error {
 //
 // 清除收藏夹失效内容
 func (b *BiliClient) FavCleanRes(mlid int64) error {
-	_, err := b.RawParse(
+	return b.FavCleanResWithContext(context.Background(), mlid)
+}
+
+// FavCleanResWithContext 同 FavCleanRes，ctx 用于取消请求或设置超时
+func (b *BiliClient) FavCleanResWithContext(ctx context.Context, mlid int64) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v3/fav/resource/clean",
 		"POST",
@@ -1128,7 +1498,12 @@ func (b *BiliClient) FavCleanRes(mlid int64) error {
 //
 // 更新个性签名
 func (b *BiliClient) SignUpdate(sign string) error {
-	_, err := b.RawParse(BiliApiURL,
+	return b.SignUpdateWithContext(context.Background(), sign)
+}
+
+// SignUpdateWithContext 同 SignUpdate，ctx 用于取消请求或设置超时
+func (b *BiliClient) SignUpdateWithContext(ctx context.Context, sign string) error {
+	_, err := b.RawParseWithContext(ctx, BiliApiURL,
 		"x/member/web/sign/update",
 		"POST",
 		map[string]string{"user_sign": sign},
@@ -1140,7 +1515,12 @@ func (b *BiliClient) SignUpdate(sign string) error {
 //
 // 点赞稿件
 func (b *BiliClient) VideoAddLike(aid int64, like bool) error {
-	_, err := b.RawParse(BiliApiURL,
+	return b.VideoAddLikeWithContext(context.Background(), aid, like)
+}
+
+// VideoAddLikeWithContext 同 VideoAddLike，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoAddLikeWithContext(ctx context.Context, aid int64, like bool) error {
+	_, err := b.RawParseWithContext(ctx, BiliApiURL,
 		"x/web-interface/archive/like",
 		"POST",
 		map[string]string{
@@ -1155,7 +1535,12 @@ func (b *BiliClient) VideoAddLike(aid int64, like bool) error {
 //
 // 获取稿件是否被点赞
 func (b *BiliClient) VideoIsLiked(aid int64) (bool, error) {
-	resp, err := b.RawParse(BiliApiURL,
+	return b.VideoIsLikedWithContext(context.Background(), aid)
+}
+
+// VideoIsLikedWithContext 同 VideoIsLiked，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoIsLikedWithContext(ctx context.Context, aid int64) (bool, error) {
+	resp, err := b.RawParseWithContext(ctx, BiliApiURL,
 		"x/web-interface/archive/has/like",
 		"GET",
 		map[string]string{
@@ -1182,7 +1567,12 @@ func (b *BiliClient) VideoIsLiked(aid int64) (bool, error) {
 //
 // like 是否附加点赞
 func (b *BiliClient) VideoAddCoins(aid int64, num int, like bool) error {
-	_, err := b.RawParse(BiliApiURL,
+	return b.VideoAddCoinsWithContext(context.Background(), aid, num, like)
+}
+
+// VideoAddCoinsWithContext 同 VideoAddCoins，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoAddCoinsWithContext(ctx context.Context, aid int64, num int, like bool) error {
+	_, err := b.RawParseWithContext(ctx, BiliApiURL,
 		"x/web-interface/coin/add",
 		"POST",
 		map[string]string{
@@ -1198,7 +1588,12 @@ func (b *BiliClient) VideoAddCoins(aid int64, num int, like bool) error {
 //
 // 返回投币数
 func (b *BiliClient) VideoIsAddedCoins(aid int64) (int, error) {
-	resp, err := b.RawParse(BiliApiURL,
+	return b.VideoIsAddedCoinsWithContext(context.Background(), aid)
+}
+
+// VideoIsAddedCoinsWithContext 同 VideoIsAddedCoins，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoIsAddedCoinsWithContext(ctx context.Context, aid int64) (int, error) {
+	resp, err := b.RawParseWithContext(ctx, BiliApiURL,
 		"x/web-interface/archive/coins",
 		"GET",
 		map[string]string{
@@ -1225,7 +1620,12 @@ func (b *BiliClient) VideoIsAddedCoins(aid int64) (int, error) {
 //
 // delMediaLists 需要取消的收藏夹id 非必须 传入空切片或nil留空
 func (b *BiliClient) VideoSetFavour(aid int64, addLists []int64, delLists []int64) (bool, error) {
-	resp, err := b.RawParse(BiliApiURL,
+	return b.VideoSetFavourWithContext(context.Background(), aid, addLists, delLists)
+}
+
+// VideoSetFavourWithContext 同 VideoSetFavour，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoSetFavourWithContext(ctx context.Context, aid int64, addLists []int64, delLists []int64) (bool, error) {
+	resp, err := b.RawParseWithContext(ctx, BiliApiURL,
 		"medialist/gateway/coll/resource/deal",
 		"POST",
 		map[string]string{
@@ -1253,7 +1653,12 @@ func (b *BiliClient) VideoSetFavour(aid int64, addLists []int64, delLists []int6
 //
 // 返回 是否被收藏
 func (b *BiliClient) VideoIsFavoured(aid int64) (bool, error) {
-	resp, err := b.RawParse(BiliApiURL,
+	return b.VideoIsFavouredWithContext(context.Background(), aid)
+}
+
+// VideoIsFavouredWithContext 同 VideoIsFavoured，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoIsFavouredWithContext(ctx context.Context, aid int64) (bool, error) {
+	resp, err := b.RawParseWithContext(ctx, BiliApiURL,
 		"x/v2/fav/video/favoured",
 		"GET",
 		map[string]string{
@@ -1279,7 +1684,12 @@ func (b *BiliClient) VideoIsFavoured(aid int64) (bool, error) {
 //
 // 返回是否点赞成功、投币成功、收藏成功和投币枚数
 func (b *BiliClient) VideoTriple(aid int64) (like, coin, favour bool, multiply int, e error) {
-	resp, err := b.RawParse(BiliApiURL,
+	return b.VideoTripleWithContext(context.Background(), aid)
+}
+
+// VideoTripleWithContext 同 VideoTriple，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoTripleWithContext(ctx context.Context, aid int64) (like, coin, favour bool, multiply int, e error) {
+	resp, err := b.RawParseWithContext(ctx, BiliApiURL,
 		"x/web-interface/archive/like/triple",
 		"POST",
 		map[string]string{
@@ -1307,7 +1717,12 @@ func (b *BiliClient) VideoTriple(aid int64) (like, coin, favour bool, multiply i
 //
 // 完成分享并返回该视频当前分享数
 func (b *BiliClient) VideoShare(aid int64) (int, error) {
-	resp, err := b.RawParse(BiliApiURL,
+	return b.VideoShareWithContext(context.Background(), aid)
+}
+
+// VideoShareWithContext 同 VideoShare，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoShareWithContext(ctx context.Context, aid int64) (int, error) {
+	resp, err := b.RawParseWithContext(ctx, BiliApiURL,
 		"x/web-interface/share/add",
 		"POST",
 		map[string]string{
@@ -1330,7 +1745,12 @@ func (b *BiliClient) VideoShare(aid int64) (int, error) {
 //
 // 返回视频详细信息，数据较多，可以使用单独的接口获取部分数据
 func (b *BiliClient) VideoGetInfo(aid int64) (*VideoInfo, error) {
-	resp, err := b.RawParse(BiliApiURL,
+	return b.VideoGetInfoWithContext(context.Background(), aid)
+}
+
+// VideoGetInfoWithContext 同 VideoGetInfo，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoGetInfoWithContext(ctx context.Context, aid int64) (*VideoInfo, error) {
+	resp, err := b.RawParseWithContext(ctx, BiliApiURL,
 		"x/web-interface/view",
 		"GET",
 		map[string]string{
@@ -1353,7 +1773,12 @@ func (b *BiliClient) VideoGetInfo(aid int64) (*VideoInfo, error) {
 //
 // 不是心跳包，应该就是个历史记录和下次播放自动跳转的功能，一般是关闭当前视频页时请求
 func (b *BiliClient) VideoReportProgress(aid int64, cid int64, progress int64) error {
-	_, err := b.RawParse(BiliApiURL,
+	return b.VideoReportProgressWithContext(context.Background(), aid, cid, progress)
+}
+
+// VideoReportProgressWithContext 同 VideoReportProgress，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoReportProgressWithContext(ctx context.Context, aid int64, cid int64, progress int64) error {
+	_, err := b.RawParseWithContext(ctx, BiliApiURL,
 		"x/v2/history/report",
 		"POST",
 		map[string]string{
@@ -1369,7 +1794,13 @@ func (b *BiliClient) VideoReportProgress(aid int64, cid int64, progress int64) e
 //
 // 所有参数、返回信息和取流方法的说明请直接前往：https://github.com/SocialSisterYi/bilibili-API-collect/blob/master/video/videostream_url.md
 func (b *BiliClient) VideoGetPlayURL(aid int64, cid int64, qn int, fnval int) (*VideoPlayURLResult, error) {
-	resp, err := b.RawParse(
+	return b.VideoGetPlayURLWithContext(context.Background(), aid, cid, qn, fnval)
+}
+
+// VideoGetPlayURLWithContext 同 VideoGetPlayURL，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoGetPlayURLWithContext(ctx context.Context, aid int64, cid int64, qn int, fnval int) (*VideoPlayURLResult, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/player/playurl",
 		"GET",
@@ -1400,7 +1831,13 @@ func (b *BiliClient) VideoGetPlayURL(aid int64, cid int64, qn int, fnval int) (*
 //
 // 包含了 VideoReportProgress 的功能(应该)
 func (b *BiliClient) VideoHeartBeat(aid int64, cid int64, playedTime int64) error {
-	_, err := b.RawParse(
+	return b.VideoHeartBeatWithContext(context.Background(), aid, cid, playedTime)
+}
+
+// VideoHeartBeatWithContext 同 VideoHeartBeat，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoHeartBeatWithContext(ctx context.Context, aid int64, cid int64, playedTime int64) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/click-interface/web/heartbeat",
 		"POST",
@@ -1419,7 +1856,12 @@ func (b *BiliClient) VideoHeartBeat(aid int64, cid int64, playedTime int64) erro
 //
 // 获取稿件Tags
 func (b *BiliClient) VideoGetTags(aid int64) ([]*VideoTag, error) {
-	resp, err := b.RawParse(BiliApiURL,
+	return b.VideoGetTagsWithContext(context.Background(), aid)
+}
+
+// VideoGetTagsWithContext 同 VideoGetTags，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoGetTagsWithContext(ctx context.Context, aid int64) ([]*VideoTag, error) {
+	resp, err := b.RawParseWithContext(ctx, BiliApiURL,
 		"x/tag/archive/tags",
 		"GET",
 		map[string]string{
@@ -1440,7 +1882,12 @@ func (b *BiliClient) VideoGetTags(aid int64) ([]*VideoTag, error) {
 //
 // 重复请求为取消
 func (b *BiliClient) VideoLikeTag(aid int64, tagID int64) error {
-	_, err := b.RawParse(BiliApiURL,
+	return b.VideoLikeTagWithContext(context.Background(), aid, tagID)
+}
+
+// VideoLikeTagWithContext 同 VideoLikeTag，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoLikeTagWithContext(ctx context.Context, aid int64, tagID int64) error {
+	_, err := b.RawParseWithContext(ctx, BiliApiURL,
 		"x/tag/archive/like2",
 		"POST",
 		map[string]string{
@@ -1455,7 +1902,12 @@ func (b *BiliClient) VideoLikeTag(aid int64, tagID int64) error {
 //
 // 重复请求为取消
 func (b *BiliClient) VideoHateTag(aid int64, tagID int64) error {
-	_, err := b.RawParse(BiliApiURL,
+	return b.VideoHateTagWithContext(context.Background(), aid, tagID)
+}
+
+// VideoHateTagWithContext 同 VideoHateTag，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoHateTagWithContext(ctx context.Context, aid int64, tagID int64) error {
+	_, err := b.RawParseWithContext(ctx, BiliApiURL,
 		"x/tag/archive/hate2",
 		"POST",
 		map[string]string{
@@ -1480,7 +1932,13 @@ func (b *BiliClient) VideoHateTag(aid int64, tagID int64) error {
 //
 // parent: 二级评论同根评论id 大于二级评论为要回复的评论id
 func (b *BiliClient) CommentSend(oid int64, tp int, content string, platform int, root int64, parent int64) (*CommentSend, error) {
-	resp, err := b.RawParse(
+	return b.CommentSendWithContext(context.Background(), oid, tp, content, platform, root, parent)
+}
+
+// CommentSendWithContext 同 CommentSend，ctx 用于取消请求或设置超时
+func (b *BiliClient) CommentSendWithContext(ctx context.Context, oid int64, tp int, content string, platform int, root int64, parent int64) (*CommentSend, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/reply/add",
 		"POST",
@@ -1512,7 +1970,13 @@ func (b *BiliClient) CommentSend(oid int64, tp int, content string, platform int
 //
 // like: true为点赞，false为取消点赞
 func (b *BiliClient) CommentLike(oid int64, tp int, rpid int64, like bool) error {
-	_, err := b.RawParse(
+	return b.CommentLikeWithContext(context.Background(), oid, tp, rpid, like)
+}
+
+// CommentLikeWithContext 同 CommentLike，ctx 用于取消请求或设置超时
+func (b *BiliClient) CommentLikeWithContext(ctx context.Context, oid int64, tp int, rpid int64, like bool) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/reply/action",
 		"POST",
@@ -1534,7 +1998,13 @@ func (b *BiliClient) CommentLike(oid int64, tp int, rpid int64, like bool) error
 //
 // like: true为点踩，false为取消点踩
 func (b *BiliClient) CommentHate(oid int64, tp int, rpid int64, hate bool) error {
-	_, err := b.RawParse(
+	return b.CommentHateWithContext(context.Background(), oid, tp, rpid, hate)
+}
+
+// CommentHateWithContext 同 CommentHate，ctx 用于取消请求或设置超时
+func (b *BiliClient) CommentHateWithContext(ctx context.Context, oid int64, tp int, rpid int64, hate bool) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/reply/hate",
 		"POST",
@@ -1554,7 +2024,13 @@ func (b *BiliClient) CommentHate(oid int64, tp int, rpid int64, hate bool) error
 //
 // rpid: 评论ID
 func (b *BiliClient) CommentDel(oid int64, tp int, rpid int64) error {
-	_, err := b.RawParse(
+	return b.CommentDelWithContext(context.Background(), oid, tp, rpid)
+}
+
+// CommentDelWithContext 同 CommentDel，ctx 用于取消请求或设置超时
+func (b *BiliClient) CommentDelWithContext(ctx context.Context, oid int64, tp int, rpid int64) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/reply/del",
 		"POST",
@@ -1575,7 +2051,13 @@ func (b *BiliClient) CommentDel(oid int64, tp int, rpid int64) error {
 //
 // top: true为置顶，false为取消置顶
 func (b *BiliClient) CommentSetTop(oid int64, tp int, rpid int64, top bool) error {
-	_, err := b.RawParse(
+	return b.CommentSetTopWithContext(context.Background(), oid, tp, rpid, top)
+}
+
+// CommentSetTopWithContext 同 CommentSetTop，ctx 用于取消请求或设置超时
+func (b *BiliClient) CommentSetTopWithContext(ctx context.Context, oid int64, tp int, rpid int64, top bool) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/reply/top",
 		"POST",
@@ -1599,7 +2081,13 @@ func (b *BiliClient) CommentSetTop(oid int64, tp int, rpid int64, top bool) erro
 //
 // content: 其他举报备注 reason=0时有效 不需要时留空
 func (b *BiliClient) CommentReport(oid int64, tp int, rpid int64, reason int, content string) error {
-	_, err := b.RawParse(
+	return b.CommentReportWithContext(context.Background(), oid, tp, rpid, reason, content)
+}
+
+// CommentReportWithContext 同 CommentReport，ctx 用于取消请求或设置超时
+func (b *BiliClient) CommentReportWithContext(ctx context.Context, oid int64, tp int, rpid int64, reason int, content string) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/reply/report",
 		"POST",
@@ -1618,7 +2106,13 @@ func (b *BiliClient) CommentReport(oid int64, tp int, rpid int64, reason int, co
 //
 // 获取历史弹幕日期，返回的日期代表有历史弹幕，用于请求历史弹幕
 func (b *BiliClient) DanmakuGetHistoryIndex(cid int64, year int, month int) ([]string, error) {
-	resp, err := b.RawParse(
+	return b.DanmakuGetHistoryIndexWithContext(context.Background(), cid, year, month)
+}
+
+// DanmakuGetHistoryIndexWithContext 同 DanmakuGetHistoryIndex，ctx 用于取消请求或设置超时
+func (b *BiliClient) DanmakuGetHistoryIndexWithContext(ctx context.Context, cid int64, year int, month int) ([]string, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/dm/history/index",
 		"GET",
@@ -1644,7 +2138,13 @@ func (b *BiliClient) DanmakuGetHistoryIndex(cid int64, year int, month int) ([]s
 //
 // date 历史日期 YYYY-MM-DD
 func (b *BiliClient) DanmakuGetHistory(cid int64, date string) (*DanmakuResp, error) {
-	resp, err := b.Raw(
+	return b.DanmakuGetHistoryWithContext(context.Background(), cid, date)
+}
+
+// DanmakuGetHistoryWithContext 同 DanmakuGetHistory，ctx 用于取消请求或设置超时
+func (b *BiliClient) DanmakuGetHistoryWithContext(ctx context.Context, cid int64, date string) (*DanmakuResp, error) {
+	resp, err := b.RawWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/dm/web/history/seg.so",
 		"GET",
@@ -1720,7 +2220,13 @@ func (b *BiliClient) DanmakuGetHistory(cid int64, date string) (*DanmakuResp, er
 // 7:高级弹幕
 // 9:BAS弹幕（pool必须为2）
 func (b *BiliClient) DanmakuPost(tp int, aid int64, cid int64, msg string, progress int64, color int, fontsize int, pool int, mode int) (*DanmakuPostResult, error) {
-	resp, err := b.RawParse(
+	return b.DanmakuPostWithContext(context.Background(), tp, aid, cid, msg, progress, color, fontsize, pool, mode)
+}
+
+// DanmakuPostWithContext 同 DanmakuPost，ctx 用于取消请求或设置超时
+func (b *BiliClient) DanmakuPostWithContext(ctx context.Context, tp int, aid int64, cid int64, msg string, progress int64, color int, fontsize int, pool int, mode int) (*DanmakuPostResult, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/dm/post",
 		"POST",
@@ -1754,7 +2260,13 @@ func (b *BiliClient) DanmakuPost(tp int, aid int64, cid int64, msg string, progr
 //
 // 成功后显示剩余次数的文本信息 如 "撤回成功，你还有2次撤回机会"
 func (b *BiliClient) DanmakuRecall(cid int64, dmid uint64) (string, error) {
-	resp, err := b.RawParse(
+	return b.DanmakuRecallWithContext(context.Background(), cid, dmid)
+}
+
+// DanmakuRecallWithContext 同 DanmakuRecall，ctx 用于取消请求或设置超时
+func (b *BiliClient) DanmakuRecallWithContext(ctx context.Context, cid int64, dmid uint64) (string, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/dm/recall",
 		"POST",
@@ -1776,7 +2288,13 @@ func (b *BiliClient) DanmakuRecall(cid int64, dmid uint64) (string, error) {
 //
 // 返回一个map，key为dmid，value为相关信息
 func (b *BiliClient) DanmakuGetLikes(cid int64, dmids []uint64) (map[uint64]*DanmakuGetLikesResult, error) {
-	resp, err := b.RawParse(
+	return b.DanmakuGetLikesWithContext(context.Background(), cid, dmids)
+}
+
+// DanmakuGetLikesWithContext 同 DanmakuGetLikes，ctx 用于取消请求或设置超时
+func (b *BiliClient) DanmakuGetLikesWithContext(ctx context.Context, cid int64, dmids []uint64) (map[uint64]*DanmakuGetLikesResult, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/dm/thumbup/stats",
 		"GET",
@@ -1805,7 +2323,13 @@ func (b *BiliClient) DanmakuGetLikes(cid int64, dmids []uint64) (map[uint64]*Dan
 //
 // op 1:点赞 2:取消点赞
 func (b *BiliClient) DanmakuLike(cid int64, dmid uint64, op int) error {
-	_, err := b.RawParse(
+	return b.DanmakuLikeWithContext(context.Background(), cid, dmid, op)
+}
+
+// DanmakuLikeWithContext 同 DanmakuLike，ctx 用于取消请求或设置超时
+func (b *BiliClient) DanmakuLikeWithContext(ctx context.Context, cid int64, dmid uint64, op int) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/dm/thumbup/add",
 		"POST",
@@ -1838,7 +2362,13 @@ func (b *BiliClient) DanmakuLike(cid int64, dmid uint64, op int) error {
 //
 // content 其他举报备注(可空) reason=11时有效
 func (b *BiliClient) DanmakuReport(cid int64, dmid uint64, reason int, content string) error {
-	_, err := b.RawParse(
+	return b.DanmakuReportWithContext(context.Background(), cid, dmid, reason, content)
+}
+
+// DanmakuReportWithContext 同 DanmakuReport，ctx 用于取消请求或设置超时
+func (b *BiliClient) DanmakuReportWithContext(ctx context.Context, cid int64, dmid uint64, reason int, content string) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/dm/report/add",
 		"POST",
@@ -1865,7 +2395,13 @@ func (b *BiliClient) DanmakuReport(cid int64, dmid uint64, reason int, content s
 // 2:弹幕保护
 // 3:取消保护
 func (b *BiliClient) DanmakuEditState(tp int, cid int64, dmids []uint64, state int) error {
-	_, err := b.RawParse(
+	return b.DanmakuEditStateWithContext(context.Background(), tp, cid, dmids, state)
+}
+
+// DanmakuEditStateWithContext 同 DanmakuEditState，ctx 用于取消请求或设置超时
+func (b *BiliClient) DanmakuEditStateWithContext(ctx context.Context, tp int, cid int64, dmids []uint64, state int) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/dm/edit/state",
 		"POST",
@@ -1891,7 +2427,13 @@ func (b *BiliClient) DanmakuEditState(tp int, cid int64, dmids []uint64, state i
 // 0:移出字幕池
 // 1:移入字幕池
 func (b *BiliClient) DanmakuEditPool(tp int, cid int64, dmids []uint64, pool int) error {
-	_, err := b.RawParse(
+	return b.DanmakuEditPoolWithContext(context.Background(), tp, cid, dmids, pool)
+}
+
+// DanmakuEditPoolWithContext 同 DanmakuEditPool，ctx 用于取消请求或设置超时
+func (b *BiliClient) DanmakuEditPoolWithContext(ctx context.Context, tp int, cid int64, dmids []uint64, pool int) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/dm/edit/pool",
 		"POST",
@@ -1929,7 +2471,13 @@ func (b *BiliClient) DanmakuEditPool(tp int, cid int64, dmids []uint64, pool int
 //
 // dmid 修改互动弹幕的弹幕id 不需要传入0即可 注意:修改弹幕platform必须为8
 func (b *BiliClient) DanmakuCommandPost(tp int, aid int64, cid int64, progress int64, platform int, data string, dmid uint64) (*DanmakuCommandPostResult, error) {
-	resp, err := b.RawParse(
+	return b.DanmakuCommandPostWithContext(context.Background(), tp, aid, cid, progress, platform, data, dmid)
+}
+
+// DanmakuCommandPostWithContext 同 DanmakuCommandPost，ctx 用于取消请求或设置超时
+func (b *BiliClient) DanmakuCommandPostWithContext(ctx context.Context, tp int, aid int64, cid int64, progress int64, platform int, data string, dmid uint64) (*DanmakuCommandPostResult, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/dm/command/post",
 		"POST",
@@ -1958,7 +2506,13 @@ func (b *BiliClient) DanmakuCommandPost(tp int, aid int64, cid int64, progress i
 //
 // 弹幕个人配置修改
 func (b *BiliClient) DanmakuSetConfig(conf *DanmakuConfig) error {
-	_, err := b.RawParse(
+	return b.DanmakuSetConfigWithContext(context.Background(), conf)
+}
+
+// DanmakuSetConfigWithContext 同 DanmakuSetConfig，ctx 用于取消请求或设置超时
+func (b *BiliClient) DanmakuSetConfigWithContext(ctx context.Context, conf *DanmakuConfig) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/dm/web/config",
 		"POST",
@@ -1993,7 +2547,13 @@ func (b *BiliClient) DanmakuSetConfig(conf *DanmakuConfig) error {
 //
 // business 使用场景	reply：评论区 dynamic：动态
 func (b *BiliClient) EmotePackGetMy(business string) ([]*EmotePack, error) {
-	resp, err := b.RawParse(
+	return b.EmotePackGetMyWithContext(context.Background(), business)
+}
+
+// EmotePackGetMyWithContext 同 EmotePackGetMy，ctx 用于取消请求或设置超时
+func (b *BiliClient) EmotePackGetMyWithContext(ctx context.Context, business string) ([]*EmotePack, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/emote/user/panel/web",
 		"GET",
@@ -2019,7 +2579,13 @@ func (b *BiliClient) EmotePackGetMy(business string) ([]*EmotePack, error) {
 //
 // B站接口导致必须登录才能获取
 func (b *BiliClient) EmotePackGetAll(business string) ([]*EmotePack, error) {
-	resp, err := b.RawParse(
+	return b.EmotePackGetAllWithContext(context.Background(), business)
+}
+
+// EmotePackGetAllWithContext 同 EmotePackGetAll，ctx 用于取消请求或设置超时
+func (b *BiliClient) EmotePackGetAllWithContext(ctx context.Context, business string) ([]*EmotePack, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/emote/setting/panel",
 		"GET",
@@ -2047,7 +2613,13 @@ func (b *BiliClient) EmotePackGetAll(business string) ([]*EmotePack, error) {
 //
 // business 使用场景	reply：评论区 dynamic：动态
 func (b *BiliClient) EmotePackAdd(id int64, business string) error {
-	_, err := b.RawParse(
+	return b.EmotePackAddWithContext(context.Background(), id, business)
+}
+
+// EmotePackAddWithContext 同 EmotePackAdd，ctx 用于取消请求或设置超时
+func (b *BiliClient) EmotePackAddWithContext(ctx context.Context, id int64, business string) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/emote/package/add",
 		"POST",
@@ -2065,7 +2637,13 @@ func (b *BiliClient) EmotePackAdd(id int64, business string) error {
 //
 // business 使用场景	reply：评论区 dynamic：动态
 func (b *BiliClient) EmotePackRemove(id int64, business string) error {
-	_, err := b.RawParse(
+	return b.EmotePackRemoveWithContext(context.Background(), id, business)
+}
+
+// EmotePackRemoveWithContext 同 EmotePackRemove，ctx 用于取消请求或设置超时
+func (b *BiliClient) EmotePackRemoveWithContext(ctx context.Context, id int64, business string) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/emote/package/remove",
 		"POST",
@@ -2081,7 +2659,13 @@ func (b *BiliClient) EmotePackRemove(id int64, business string) error {
 //
 // 获取音频信息
 func (b *BiliClient) AudioGetInfo(auid int64) (*AudioInfo, error) {
-	resp, err := b.RawParse(
+	return b.AudioGetInfoWithContext(context.Background(), auid)
+}
+
+// AudioGetInfoWithContext 同 AudioGetInfo，ctx 用于取消请求或设置超时
+func (b *BiliClient) AudioGetInfoWithContext(ctx context.Context, auid int64) (*AudioInfo, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliMainURL,
 		"audio/music-service-c/web/song/info",
 		"GET",
@@ -2105,7 +2689,13 @@ func (b *BiliClient) AudioGetInfo(auid int64) (*AudioInfo, error) {
 //
 // ps 每页项数
 func (b *BiliClient) AudioGetMyFavLists(pn int, ps int) (*AudioMyFavLists, error) {
-	resp, err := b.RawParse(
+	return b.AudioGetMyFavListsWithContext(context.Background(), pn, ps)
+}
+
+// AudioGetMyFavListsWithContext 同 AudioGetMyFavLists，ctx 用于取消请求或设置超时
+func (b *BiliClient) AudioGetMyFavListsWithContext(ctx context.Context, pn int, ps int) (*AudioMyFavLists, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliMainURL,
 		"audio/music-service-c/web/collections/list",
 		"GET",
@@ -2138,7 +2728,13 @@ func (b *BiliClient) AudioGetMyFavLists(pn int, ps int) (*AudioMyFavLists, error
 //
 // 取流：https://github.com/SocialSisterYi/bilibili-API-collect/blob/master/audio/musicstream_url.md#%E9%9F%B3%E9%A2%91%E6%B5%81%E7%9A%84%E8%8E%B7%E5%8F%96
 func (b *BiliClient) AudioGetPlayURL(auid int64, qn int) (*AudioPlayURL, error) {
-	resp, err := b.RawParse(
+	return b.AudioGetPlayURLWithContext(context.Background(), auid, qn)
+}
+
+// AudioGetPlayURLWithContext 同 AudioGetPlayURL，ctx 用于取消请求或设置超时
+func (b *BiliClient) AudioGetPlayURLWithContext(ctx context.Context, auid int64, qn int) (*AudioPlayURL, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"audio/music-service-c/url",
 		"GET",
@@ -2164,7 +2760,13 @@ func (b *BiliClient) AudioGetPlayURL(auid int64, qn int) (*AudioPlayURL, error)
 //
 // 查询音频是否被收藏
 func (b *BiliClient) AudioIsFavored(auid int64) (bool, error) {
-	resp, err := b.RawParse(
+	return b.AudioIsFavoredWithContext(context.Background(), auid)
+}
+
+// AudioIsFavoredWithContext 同 AudioIsFavored，ctx 用于取消请求或设置超时
+func (b *BiliClient) AudioIsFavoredWithContext(ctx context.Context, auid int64) (bool, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliMainURL,
 		"audio/music-service-c/web/collections/songs-coll",
 		"GET",
@@ -2186,7 +2788,13 @@ func (b *BiliClient) AudioIsFavored(auid int64) (bool, error) {
 //
 // 返回投币数
 func (b *BiliClient) AudioIsCoined(auid int64) (int, error) {
-	resp, err := b.RawParse(
+	return b.AudioIsCoinedWithContext(context.Background(), auid)
+}
+
+// AudioIsCoinedWithContext 同 AudioIsCoined，ctx 用于取消请求或设置超时
+func (b *BiliClient) AudioIsCoinedWithContext(ctx context.Context, auid int64) (int, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliMainURL,
 		"audio/music-service-c/web/coin/audio",
 		"GET",
@@ -2214,7 +2822,13 @@ func (b *BiliClient) AudioIsCoined(auid int64) (int, error) {
 //
 // oid 充电来源代码 空间充电：充电对象用户mid 视频充电：稿件aid
 func (b *BiliClient) ChargeTradeCreateBp(num int, mid int64, otype string, oid int64) (*ChargeBpResult, error) {
-	resp, err := b.RawParse(
+	return b.ChargeTradeCreateBpWithContext(context.Background(), num, mid, otype, oid)
+}
+
+// ChargeTradeCreateBpWithContext 同 ChargeTradeCreateBp，ctx 用于取消请求或设置超时
+func (b *BiliClient) ChargeTradeCreateBpWithContext(ctx context.Context, num int, mid int64, otype string, oid int64) (*ChargeBpResult, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/ugcpay/web/v2/trade/elec/pay/quick",
 		"POST",
@@ -2240,7 +2854,13 @@ func (b *BiliClient) ChargeTradeCreateBp(num int, mid int64, otype string, oid i
 //
 // order 订单号，从充电成功的响应中获取
 func (b *BiliClient) ChargeSetMessage(order string, message string) error {
-	_, err := b.RawParse(
+	return b.ChargeSetMessageWithContext(context.Background(), order, message)
+}
+
+// ChargeSetMessageWithContext 同 ChargeSetMessage，ctx 用于取消请求或设置超时
+func (b *BiliClient) ChargeSetMessageWithContext(ctx context.Context, order string, message string) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/ugcpay/trade/elec/message",
 		"POST",
@@ -2266,7 +2886,13 @@ func (b *BiliClient) ChargeSetMessage(order string, message string) error {
 //
 // 整个支付流程请看：https://github.com/SocialSisterYi/bilibili-API-collect/blob/master/electric/WeChat&Alipay.md
 func (b *BiliClient) ChargeTradeCreateQrCode(num int, prior bool, mid int64, otype string, oid int64) (*ChargeCreateQrCode, error) {
-	resp, err := b.RawParse(
+	return b.ChargeTradeCreateQrCodeWithContext(context.Background(), num, prior, mid, otype, oid)
+}
+
+// ChargeTradeCreateQrCodeWithContext 同 ChargeTradeCreateQrCode，ctx 用于取消请求或设置超时
+func (b *BiliClient) ChargeTradeCreateQrCodeWithContext(ctx context.Context, num int, prior bool, mid int64, otype string, oid int64) (*ChargeCreateQrCode, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/ugcpay/web/v2/trade/elec/pay/qr_code/create",
 		"POST",
@@ -2292,7 +2918,13 @@ func (b *BiliClient) ChargeTradeCreateQrCode(num int, prior bool, mid int64, oty
 //
 // token ChargeTradeCreateQrCode 中返回的token
 func (b *BiliClient) ChargeTradeCheckQrCode(token string) (*ChargeQrCodeStatus, error) {
-	resp, err := b.RawParse(
+	return b.ChargeTradeCheckQrCodeWithContext(context.Background(), token)
+}
+
+// ChargeTradeCheckQrCodeWithContext 同 ChargeTradeCheckQrCode，ctx 用于取消请求或设置超时
+func (b *BiliClient) ChargeTradeCheckQrCodeWithContext(ctx context.Context, token string) (*ChargeQrCodeStatus, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/ugcpay/trade/elec/pay/order/status",
 		"GET",
@@ -2316,7 +2948,13 @@ func (b *BiliClient) ChargeTradeCheckQrCode(token string) (*ChargeQrCodeStatus,
 //
 // follow true:关注 false:取消关注
 func (b *BiliClient) FollowUser(mid int64, follow bool) error {
-	_, err := b.RawParse(
+	return b.FollowUserWithContext(context.Background(), mid, follow)
+}
+
+// FollowUserWithContext 同 FollowUser，ctx 用于取消请求或设置超时
+func (b *BiliClient) FollowUserWithContext(ctx context.Context, mid int64, follow bool) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/relation/modify",
 		"POST",
@@ -2346,6 +2984,11 @@ func (b *BiliClient) FollowUser(mid int64, follow bool) error {
 //
 // 话题直接用 #xxx# 包裹即可
 func (b *BiliClient) DynaCreatePlain(content string, at map[string]int64) (int64, error) {
+	return b.DynaCreatePlainWithContext(context.Background(), content, at)
+}
+
+// DynaCreatePlainWithContext 同 DynaCreatePlain，ctx 用于取消请求或设置超时
+func (b *BiliClient) DynaCreatePlainWithContext(ctx context.Context, content string, at map[string]int64) (int64, error) {
 	var ids []int64
 	for _, id := range at {
 		ids = append(ids, id)
@@ -2355,7 +2998,8 @@ func (b *BiliClient) DynaCreatePlain(content string, at map[string]int64) (int64
 	if err != nil {
 		return -1, err
 	}
-	resp, err := b.RawParse(
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliVcURL,
 		"dynamic_svr/v1/dynamic_svr/create",
 		"POST",
@@ -2385,7 +3029,13 @@ func (b *BiliClient) DynaCreatePlain(content string, at map[string]int64) (int64
 //
 // like true:点赞 false: 不点赞
 func (b *BiliClient) DynaLike(dyid int64, like bool) error {
-	_, err := b.RawParse(
+	return b.DynaLikeWithContext(context.Background(), dyid, like)
+}
+
+// DynaLikeWithContext 同 DynaLike，ctx 用于取消请求或设置超时
+func (b *BiliClient) DynaLikeWithContext(ctx context.Context, dyid int64, like bool) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliVcURL,
 		"dynamic_like/v1/dynamic_like/thumb",
 		"POST",
@@ -2403,10 +3053,16 @@ func (b *BiliClient) DynaLike(dyid int64, like bool) error {
 //
 // 返回的结构体用于创建
 func (b *BiliClient) DynaUploadPics(pics []io.Reader) ([]*DynaUploadPic, error) {
+	return b.DynaUploadPicsWithContext(context.Background(), pics)
+}
+
+// DynaUploadPicsWithContext 同 DynaUploadPics，ctx 用于取消请求或设置超时
+func (b *BiliClient) DynaUploadPicsWithContext(ctx context.Context, pics []io.Reader) ([]*DynaUploadPic, error) {
 	var results []*DynaUploadPic
 	for _, p := range pics {
 		// 该接口一次只能传一张，循环发送
-		resp, err := b.UploadParse(
+		resp, err := b.UploadParseWithContext(
+			ctx,
 			BiliApiURL,
 			"x/dynamic/feed/draw/upload_bfs",
 			map[string]string{
@@ -2440,14 +3096,20 @@ type SendMessageResp struct {
 
 // SendMessage 发文字消息
 func (b *BiliClient) SendMessage(uid int64, content, devID string) (*SendMessageResp, error) {
+	return b.SendMessageWithContext(context.Background(), uid, content, devID)
+}
+
+// SendMessageWithContext 同 SendMessage，ctx 用于取消请求或设置超时
+func (b *BiliClient) SendMessageWithContext(ctx context.Context, uid int64, content, devID string) (*SendMessageResp, error) {
 	if devID == "" {
 		devID = uuid.NewV4().String()
 	}
-	resp, err := b.UploadParse(
+	resp, err := b.UploadParseWithContext(
+		ctx,
 		BiliVcURL,
 		"web_im/v1/web_im/send_msg",
 		map[string]string{
-			"msg[sender_uid]":       b.auth.DedeUserID,
+			"msg[sender_uid]":       b.GetCookieAuth().DedeUserID,
 			"msg[receiver_id]":      fmt.Sprint(uid),
 			"msg[receiver_type]":    "1",
 			"msg[msg_type]":         "1",
@@ -2508,24 +3170,17 @@ func (b *BiliClient) SendMessage(uid int64, content, devID string) (*SendMessage
 //	return r, nil
 //}
 
-func getMixinKey(e string) string {
-	t := []rune{}
-	indices := []int{46, 47, 18, 2, 53, 8, 23, 32, 15, 50, 10, 31, 58, 3, 45, 35, 27, 43, 5, 49, 33, 9, 42, 19, 29, 28, 14, 39, 12, 38, 41, 13, 37, 48, 7, 16, 24, 55, 40, 61, 26, 17, 0, 1, 60, 51, 30, 4, 22, 25, 54, 21, 56, 59, 6, 63, 57, 62, 11, 36, 20, 34, 44, 52}
-	for _, r := range indices {
-		if r < len(e) {
-			t = append(t, rune(e[r]))
-		}
-	}
-
-	return string(t)[:32]
-}
-
 // DynaCreateDraw 创建图片动态
 //
 // content,at 同 DynaCreatePlain
 //
 // pics 从 DynaUploadPics 获取
 func (b *BiliClient) DynaCreateDraw(content string, at map[string]int64, pic []*DynaUploadPic) (int64, error) {
+	return b.DynaCreateDrawWithContext(context.Background(), content, at, pic)
+}
+
+// DynaCreateDrawWithContext 同 DynaCreateDraw，ctx 用于取消请求或设置超时
+func (b *BiliClient) DynaCreateDrawWithContext(ctx context.Context, content string, at map[string]int64, pic []*DynaUploadPic) (int64, error) {
 	var ids []int64
 	for _, id := range at {
 		ids = append(ids, id)
@@ -2540,7 +3195,8 @@ func (b *BiliClient) DynaCreateDraw(content string, at map[string]int64, pic []*
 	if err != nil {
 		return -1, err
 	}
-	resp, err := b.RawParse(
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliVcURL,
 		"dynamic_svr/v1/dynamic_svr/create_draw",
 		"POST",
@@ -2571,6 +3227,11 @@ func (b *BiliClient) DynaCreateDraw(content string, at map[string]int64, pic []*
 //
 // dyid 为转发的动态ID
 func (b *BiliClient) DynaRepost(dyid int64, content string, at map[string]int64) error {
+	return b.DynaRepostWithContext(context.Background(), dyid, content, at)
+}
+
+// DynaRepostWithContext 同 DynaRepost，ctx 用于取消请求或设置超时
+func (b *BiliClient) DynaRepostWithContext(ctx context.Context, dyid int64, content string, at map[string]int64) error {
 	var ids []int64
 	for _, id := range at {
 		ids = append(ids, id)
@@ -2580,7 +3241,8 @@ func (b *BiliClient) DynaRepost(dyid int64, content string, at map[string]int64)
 	if err != nil {
 		return err
 	}
-	_, err = b.RawParse(
+	_, err = b.RawParseWithContext(
+		ctx,
 		BiliVcURL,
 		"dynamic_repost/v1/dynamic_repost/repost",
 		"POST",
@@ -2598,7 +3260,13 @@ func (b *BiliClient) DynaRepost(dyid int64, content string, at map[string]int64)
 //
 // 删除动态
 func (b *BiliClient) DynaDel(dyid int64) error {
-	_, err := b.RawParse(
+	return b.DynaDelWithContext(context.Background(), dyid)
+}
+
+// DynaDelWithContext 同 DynaDel，ctx 用于取消请求或设置超时
+func (b *BiliClient) DynaDelWithContext(ctx context.Context, dyid int64) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliVcURL,
 		"dynamic_svr/v1/dynamic_svr/rm_dynamic",
 		"POST",
@@ -2619,6 +3287,11 @@ func (b *BiliClient) DynaDel(dyid int64) error {
 //
 // publish 为指定发布的时间戳,换算后为东八区
 func (b *BiliClient) DynaCreateDraft(content string, at map[string]int64, pic []*DynaUploadPic, publish int64) (int64, error) {
+	return b.DynaCreateDraftWithContext(context.Background(), content, at, pic, publish)
+}
+
+// DynaCreateDraftWithContext 同 DynaCreateDraft，ctx 用于取消请求或设置超时
+func (b *BiliClient) DynaCreateDraftWithContext(ctx context.Context, content string, at map[string]int64, pic []*DynaUploadPic, publish int64) (int64, error) {
 	var ids []int64
 	for _, id := range at {
 		ids = append(ids, id)
@@ -2648,7 +3321,8 @@ func (b *BiliClient) DynaCreateDraft(content string, at map[string]int64, pic []
 	if err != nil {
 		return -1, err
 	}
-	resp, err := b.RawParse(
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliVcURL,
 		"dynamic_draft/v1/dynamic_draft/add_draft",
 		"POST",
@@ -2677,6 +3351,11 @@ func (b *BiliClient) DynaCreateDraft(content string, at map[string]int64, pic []
 //
 // 其他参数同 DynaCreateDraft
 func (b *BiliClient) DynaModifyDraft(dfid int64, content string, at map[string]int64, pic []*DynaUploadPic, publish int64) error {
+	return b.DynaModifyDraftWithContext(context.Background(), dfid, content, at, pic, publish)
+}
+
+// DynaModifyDraftWithContext 同 DynaModifyDraft，ctx 用于取消请求或设置超时
+func (b *BiliClient) DynaModifyDraftWithContext(ctx context.Context, dfid int64, content string, at map[string]int64, pic []*DynaUploadPic, publish int64) error {
 	var ids []int64
 	for _, id := range at {
 		ids = append(ids, id)
@@ -2707,7 +3386,8 @@ func (b *BiliClient) DynaModifyDraft(dfid int64, content string, at map[string]i
 		return err
 	}
 
-	_, err = b.RawParse(
+	_, err = b.RawParseWithContext(
+		ctx,
 		BiliVcURL,
 		"dynamic_draft/v1/dynamic_draft/modify_draft",
 		"POST",
@@ -2725,7 +3405,13 @@ func (b *BiliClient) DynaModifyDraft(dfid int64, content string, at map[string]i
 //
 // dfid 定时发布ID
 func (b *BiliClient) DynaDelDraft(dfid int64) error {
-	_, err := b.RawParse(
+	return b.DynaDelDraftWithContext(context.Background(), dfid)
+}
+
+// DynaDelDraftWithContext 同 DynaDelDraft，ctx 用于取消请求或设置超时
+func (b *BiliClient) DynaDelDraftWithContext(ctx context.Context, dfid int64) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliVcURL,
 		"dynamic_draft/v1/dynamic_draft/rm_draft",
 		"POST",
@@ -2742,7 +3428,13 @@ func (b *BiliClient) DynaDelDraft(dfid int64) error {
 //
 // dfid 定时发布ID
 func (b *BiliClient) DynaPublishDraft(dfid int64) (int64, error) {
-	resp, err := b.RawParse(
+	return b.DynaPublishDraftWithContext(context.Background(), dfid)
+}
+
+// DynaPublishDraftWithContext 同 DynaPublishDraft，ctx 用于取消请求或设置超时
+func (b *BiliClient) DynaPublishDraftWithContext(ctx context.Context, dfid int64) (int64, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliVcURL,
 		"dynamic_draft/v1/dynamic_draft/publish_now",
 		"POST",
@@ -2762,7 +3454,12 @@ func (b *BiliClient) DynaPublishDraft(dfid int64) (int64, error) {
 	}
 	// 一些特殊情况导致发布失败，还有一层错误需要判断
 	if r.CreateEc != 0 {
-		return -1, fmt.Errorf("(%d) publish error", r.CreateEc)
+		return -1, &APIError{
+			Code:     r.CreateEc,
+			Message:  "publish error",
+			Endpoint: "dynamic_draft/v1/dynamic_draft/publish_now",
+			Raw:      resp.Data,
+		}
 	}
 	return r.DynamicID, nil
 }
@@ -2771,7 +3468,13 @@ func (b *BiliClient) DynaPublishDraft(dfid int64) (int64, error) {
 //
 // 获取所有定时发布动态
 func (b *BiliClient) DynaGetDrafts() (*DynaGetDraft, error) {
-	resp, err := b.RawParse(
+	return b.DynaGetDraftsWithContext(context.Background())
+}
+
+// DynaGetDraftsWithContext 同 DynaGetDrafts，ctx 用于取消请求或设置超时
+func (b *BiliClient) DynaGetDraftsWithContext(ctx context.Context) (*DynaGetDraft, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliVcURL,
 		"dynamic_draft/v1/dynamic_draft/get_drafts",
 		"GET",
@@ -2801,7 +3504,13 @@ func (b *BiliClient) DynaGetDrafts() (*DynaGetDraft, error) {
 //
 // bubble: 气泡弹幕?默认0
 func (b *BiliClient) LiveSendDanmaku(roomID int64, color int64, fontsize int, mode int, msg string, bubble int) error {
-	resp, err := b.RawParse(
+	return b.LiveSendDanmakuWithContext(context.Background(), roomID, color, fontsize, mode, msg, bubble)
+}
+
+// LiveSendDanmakuWithContext 同 LiveSendDanmaku，ctx 用于取消请求或设置超时
+func (b *BiliClient) LiveSendDanmakuWithContext(ctx context.Context, roomID int64, color int64, fontsize int, mode int, msg string, bubble int) error {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"msg/send",
 		"POST",
@@ -2827,7 +3536,13 @@ func (b *BiliClient) LiveSendDanmaku(roomID int64, color int64, fontsize int, mo
 	return nil
 }
 func (b *BiliClient) UserGetInfo(mid int64) (*UserInfo, error) {
-	resp, err := b.RawParse(
+	return b.UserGetInfoWithContext(context.Background(), mid)
+}
+
+// UserGetInfoWithContext 同 UserGetInfo，ctx 用于取消请求或设置超时
+func (b *BiliClient) UserGetInfoWithContext(ctx context.Context, mid int64) (*UserInfo, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/acc/info",
 		"GET",
@@ -2956,7 +3671,13 @@ type MyInfoResp struct {
 }
 
 func (b *BiliClient) MyInfo() (*MyInfoResp, error) {
-	resp, err := b.RawParse(
+	return b.MyInfoWithContext(context.Background())
+}
+
+// MyInfoWithContext 同 MyInfo，ctx 用于取消请求或设置超时
+func (b *BiliClient) MyInfoWithContext(ctx context.Context) (*MyInfoResp, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/v2/myinfo",
 		"GET",
@@ -3054,7 +3775,13 @@ type GuardTabTopListResp struct {
 }
 
 func (b *BiliClient) GuardTabTopList(roomID, rUID, page, pageSize int64) (*GuardTabTopListResp, error) {
-	resp, err := b.RawParse(
+	return b.GuardTabTopListWithContext(context.Background(), roomID, rUID, page, pageSize)
+}
+
+// GuardTabTopListWithContext 同 GuardTabTopList，ctx 用于取消请求或设置超时
+func (b *BiliClient) GuardTabTopListWithContext(ctx context.Context, roomID, rUID, page, pageSize int64) (*GuardTabTopListResp, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/app-room/v2/guardTab/topList",
 		"GET",
@@ -3081,7 +3808,13 @@ type FingerSpiResp struct {
 }
 
 func (b *BiliClient) FingerSpi() (*FingerSpiResp, error) {
-	resp, err := b.RawParse(
+	return b.FingerSpiWithContext(context.Background())
+}
+
+// FingerSpiWithContext 同 FingerSpi，ctx 用于取消请求或设置超时
+func (b *BiliClient) FingerSpiWithContext(ctx context.Context) (*FingerSpiResp, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/frontend/finger/spi",
 		"GET",
@@ -3101,7 +3834,13 @@ type LikeReportV3Response struct {
 }
 
 func (b *BiliClient) LikeReportV3(clickTime, roomID, uid, anchorID int64) error {
-	resp, err := b.RawParse(
+	return b.LikeReportV3WithContext(context.Background(), clickTime, roomID, uid, anchorID)
+}
+
+// LikeReportV3WithContext 同 LikeReportV3，ctx 用于取消请求或设置超时
+func (b *BiliClient) LikeReportV3WithContext(ctx context.Context, clickTime, roomID, uid, anchorID int64) error {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/app-ucenter/v1/like_info_v3/like/likeReportV3",
 		"POST",
@@ -3124,7 +3863,13 @@ func (b *BiliClient) LikeReportV3(clickTime, roomID, uid, anchorID int64) error
 
 // LiveSendGold 送礼物
 func (b *BiliClient) LiveSendGold(uid, gift_id, ruid, send_ruid, gift_num, biz_id, price int64) error {
-	_, err := b.RawParse(
+	return b.LiveSendGoldWithContext(context.Background(), uid, gift_id, ruid, send_ruid, gift_num, biz_id, price)
+}
+
+// LiveSendGoldWithContext 同 LiveSendGold，ctx 用于取消请求或设置超时
+func (b *BiliClient) LiveSendGoldWithContext(ctx context.Context, uid, gift_id, ruid, send_ruid, gift_num, biz_id, price int64) error {
+	_, err := b.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/revenue/v1/gift/sendGold",
 		"POST",
@@ -3194,7 +3939,13 @@ type QueryContributionRankResp struct {
 
 // QueryContributionRank 查贡献
 func (b *BiliClient) QueryContributionRank(uid, room_id int64, typ, sw string) (*QueryContributionRankResp, error) {
-	resp, err := b.RawParse(
+	return b.QueryContributionRankWithContext(context.Background(), uid, room_id, typ, sw)
+}
+
+// QueryContributionRankWithContext 同 QueryContributionRank，ctx 用于取消请求或设置超时
+func (b *BiliClient) QueryContributionRankWithContext(ctx context.Context, uid, room_id int64, typ, sw string) (*QueryContributionRankResp, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/general-interface/v1/rank/queryContributionRank",
 		"GET",
@@ -3219,7 +3970,13 @@ func (b *BiliClient) QueryContributionRank(uid, room_id int64, typ, sw string) (
 
 // GetInfoByRoom 查房间信息
 func (b *BiliClient) GetInfoByRoom(roomID int64) (*GetInfoByRoomResp, error) {
-	resp, err := b.RawParse(
+	return b.GetInfoByRoomWithContext(context.Background(), roomID)
+}
+
+// GetInfoByRoomWithContext 同 GetInfoByRoom，ctx 用于取消请求或设置超时
+func (b *BiliClient) GetInfoByRoomWithContext(ctx context.Context, roomID int64) (*GetInfoByRoomResp, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/web-room/v1/index/getInfoByRoom",
 		"GET",
@@ -3236,3 +3993,176 @@ func (b *BiliClient) GetInfoByRoom(roomID int64) (*GetInfoByRoomResp, error) {
 	}
 	return ret, nil
 }
+
+// ArticleGetInfo 获取专栏文章信息，包含当前用户的点赞、投币、收藏状态
+//
+// cvid: 专栏ID，不含cv前缀
+func (b *BiliClient) ArticleGetInfo(cvid int64) (*ArticleInfo, error) {
+	return b.ArticleGetInfoWithContext(context.Background(), cvid)
+}
+
+// ArticleGetInfoWithContext 同 ArticleGetInfo，ctx 用于取消请求或设置超时
+func (b *BiliClient) ArticleGetInfoWithContext(ctx context.Context, cvid int64) (*ArticleInfo, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
+		BiliApiURL,
+		"x/article/viewinfo",
+		"GET",
+		map[string]string{
+			"id": strconv.FormatInt(cvid, 10),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	var r = &ArticleInfo{}
+	if err = json.Unmarshal(resp.Data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ArticleLike 点赞或取消点赞专栏
+func (b *BiliClient) ArticleLike(cvid int64, like bool) error {
+	return b.ArticleLikeWithContext(context.Background(), cvid, like)
+}
+
+// ArticleLikeWithContext 同 ArticleLike，ctx 用于取消请求或设置超时
+func (b *BiliClient) ArticleLikeWithContext(ctx context.Context, cvid int64, like bool) error {
+	_, err := b.RawParseWithContext(
+		ctx,
+		BiliApiURL,
+		"x/article/like",
+		"POST",
+		map[string]string{
+			"id":   strconv.FormatInt(cvid, 10),
+			"type": util.IF(like, "1", "2").(string),
+		},
+	)
+	return err
+}
+
+// ArticleAddCoins 专栏投币
+//
+// upid: 作者mid
+//
+// num: 投币数量,上限为2
+func (b *BiliClient) ArticleAddCoins(cvid int64, upid int64, num int) error {
+	return b.ArticleAddCoinsWithContext(context.Background(), cvid, upid, num)
+}
+
+// ArticleAddCoinsWithContext 同 ArticleAddCoins，ctx 用于取消请求或设置超时
+func (b *BiliClient) ArticleAddCoinsWithContext(ctx context.Context, cvid int64, upid int64, num int) error {
+	_, err := b.RawParseWithContext(
+		ctx,
+		BiliApiURL,
+		"x/web-interface/coin/add",
+		"POST",
+		map[string]string{
+			"aid":      strconv.FormatInt(cvid, 10),
+			"upid":     strconv.FormatInt(upid, 10),
+			"multiply": strconv.Itoa(num),
+			"avtype":   "2",
+		},
+	)
+	return err
+}
+
+// ArticleUploadImage 上传专栏图片，返回的地址可用于正文、头图与封面
+func (b *BiliClient) ArticleUploadImage(img io.Reader) (string, error) {
+	return b.ArticleUploadImageWithContext(context.Background(), img)
+}
+
+// ArticleUploadImageWithContext 同 ArticleUploadImage，ctx 用于取消请求或设置超时
+func (b *BiliClient) ArticleUploadImageWithContext(ctx context.Context, img io.Reader) (string, error) {
+	resp, err := b.UploadParseWithContext(
+		ctx,
+		BiliApiURL,
+		"x/article/creative/article/upcover",
+		map[string]string{},
+		[]*FileUpload{{
+			Field: "binary",
+			Name:  "1.jpg", // 同 DynaUploadPics，通过文件头判断类型
+			File:  img,
+		}},
+	)
+	if err != nil {
+		return "", err
+	}
+	var r struct {
+		URL string `json:"url"`
+	}
+	if err = json.Unmarshal(resp.Data, &r); err != nil {
+		return "", err
+	}
+	return r.URL, nil
+}
+
+// ArticleSaveDraft 新建或更新专栏草稿，返回草稿ID
+//
+// d.ID 为0时新建草稿
+func (b *BiliClient) ArticleSaveDraft(d *ArticleDraft) (int64, error) {
+	return b.ArticleSaveDraftWithContext(context.Background(), d)
+}
+
+// ArticleSaveDraftWithContext 同 ArticleSaveDraft，ctx 用于取消请求或设置超时
+func (b *BiliClient) ArticleSaveDraftWithContext(ctx context.Context, d *ArticleDraft) (int64, error) {
+	return b.articleSubmit(ctx, "x/article/creative/draft/addupdate", d)
+}
+
+// ArticlePublish 发布专栏，返回cvid
+//
+// d.ID 不为0时发布对应的草稿
+func (b *BiliClient) ArticlePublish(d *ArticleDraft) (int64, error) {
+	return b.ArticlePublishWithContext(context.Background(), d)
+}
+
+// ArticlePublishWithContext 同 ArticlePublish，ctx 用于取消请求或设置超时
+func (b *BiliClient) ArticlePublishWithContext(ctx context.Context, d *ArticleDraft) (int64, error) {
+	return b.articleSubmit(ctx, "x/article/creative/article/submit", d)
+}
+
+func (b *BiliClient) articleSubmit(ctx context.Context, endpoint string, d *ArticleDraft) (int64, error) {
+	if d.Title == "" {
+		return 0, errors.New("empty article title")
+	}
+	words, summary, err := articleWords(d.Content)
+	if err != nil {
+		return 0, err
+	}
+	if d.Summary != "" {
+		summary = d.Summary
+	}
+	payload := map[string]string{
+		"title":             d.Title,
+		"banner_url":        d.BannerURL,
+		"content":           d.Content,
+		"summary":           summary,
+		"words":             strconv.Itoa(words),
+		"category":          strconv.Itoa(d.Category),
+		"list_id":           strconv.FormatInt(d.ListID, 10),
+		"tid":               "4",
+		"reprint":           "0",
+		"original":          util.IF(d.Original, "1", "0").(string),
+		"tags":              strings.Join(d.Tags, ","),
+		"image_urls":        strings.Join(d.ImageURLs, ","),
+		"origin_image_urls": strings.Join(d.ImageURLs, ","),
+		"dynamic_intro":     "",
+		"media_id":          "0",
+		"spoiler":           "0",
+	}
+	if d.ID != 0 {
+		payload["aid"] = strconv.FormatInt(d.ID, 10)
+	}
+	resp, err := b.RawParseWithContext(ctx, BiliApiURL, endpoint, "POST", payload)
+	if err != nil {
+		return 0, err
+	}
+	var r struct {
+		AID int64 `json:"aid"`
+	}
+	if err = json.Unmarshal(resp.Data, &r); err != nil {
+		return 0, err
+	}
+	return r.AID, nil
+}
@@ -0,0 +1,430 @@
+package biligo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// ErrNoAvailableAccount 连接池中没有可用的账号，或可用账号的预算均已用完
+var ErrNoAvailableAccount = errors.New("no available account in pool")
+
+// AccountState 账号状态
+type AccountState int
+
+const (
+	AccountUnchecked      AccountState = iota // 尚未验证
+	AccountValid                              // 可用
+	AccountExpired                            // 登录失效(-101)，需要重新登录后使用 BiliClientPool.Add 替换
+	AccountRiskControlled                     // 被风控(-412,-509,-352)，隔离一段时间后恢复
+	AccountFailed                             // 验证时出现其他错误(如网络错误)，可使用 BiliClientPool.Validate 重新验证
+)
+
+func (s AccountState) String() string {
+	switch s {
+	case AccountUnchecked:
+		return "unchecked"
+	case AccountValid:
+		return "valid"
+	case AccountExpired:
+		return "expired"
+	case AccountRiskControlled:
+		return "risk-controlled"
+	case AccountFailed:
+		return "failed"
+	}
+	return fmt.Sprintf("AccountState(%d)", int(s))
+}
+
+// riskCodes 视为风控的错误码
+var riskCodes = []int{-352, -412, -509}
+
+// PoolStrategy 选取账号的策略
+type PoolStrategy int
+
+const (
+	PoolRoundRobin        PoolStrategy = iota // 轮询
+	PoolLeastRecentlyUsed                     // 选取最久未使用的账号
+)
+
+// PoolBudget 每个账号在滑动窗口内允许的操作次数
+type PoolBudget struct {
+	Limit  int           // 窗口内允许的次数
+	Window time.Duration // 窗口长度
+}
+
+// BiliClientPoolOption 连接池设置
+type BiliClientPoolOption struct {
+	// 创建每个 BiliClient 的公共设置，Auth 与 StoreKey 会被忽略，可为nil
+	Setting *BiliSetting
+	// 并发验证的账号数，默认5
+	Concurrency int
+	// 选取账号的策略，默认轮询
+	Strategy PoolStrategy
+	// 每个账号的操作预算，key为 BiliClientPool.Get 的action，如 "comment": {Limit: 30, Window: time.Hour}
+	Budgets map[string]PoolBudget
+	// 被风控后的隔离时长，默认30分钟
+	Quarantine time.Duration
+	// 账号状态变化时调用，不要在回调中调用连接池的方法
+	OnStateChange func(a *PoolAccount)
+}
+
+// PoolAccount 账号信息
+type PoolAccount struct {
+	ID               string       // DedeUserID
+	State            AccountState // 当前状态
+	Err              error        // 最近一次导致状态变化的错误
+	QuarantinedUntil time.Time    // 风控隔离的结束时间
+	LastUsed         time.Time    // 最近一次被 BiliClientPool.Get 选取的时间
+}
+
+type poolAccount struct {
+	PoolAccount
+	auth   *CookieAuth
+	client *BiliClient
+	// 各action在窗口内的使用时间，按时间排序
+	used map[string][]time.Time
+	// setState 的调用次数，用于判断状态是否在某次请求期间被更新
+	updates int
+}
+
+// BiliClientPool 多账号连接池，并发安全
+//
+// 连接池为每个账号的 BiliClient 添加中间件，请求返回未登录或风控时自动更新账号状态，
+// 风控的账号在隔离期间不会被选取
+type BiliClientPool struct {
+	opt      BiliClientPoolOption
+	mu       sync.Mutex
+	accounts []*poolAccount
+	next     int
+
+	// now 当前时间，测试时替换
+	now func() time.Time
+}
+
+// NewBiliClientPool 创建连接池并发验证全部账号，验证失败的账号保留在连接池中并记录状态，opt可为nil
+func NewBiliClientPool(auths []*CookieAuth, opt *BiliClientPoolOption) (*BiliClientPool, error) {
+	return NewBiliClientPoolWithContext(context.Background(), auths, opt)
+}
+
+// NewBiliClientPoolWithContext 同 NewBiliClientPool，ctx 用于取消验证请求
+func NewBiliClientPoolWithContext(ctx context.Context, auths []*CookieAuth, opt *BiliClientPoolOption) (*BiliClientPool, error) {
+	p := &BiliClientPool{now: time.Now}
+	if opt != nil {
+		p.opt = *opt
+	}
+	if p.opt.Concurrency <= 0 {
+		p.opt.Concurrency = 5
+	}
+	if p.opt.Quarantine <= 0 {
+		p.opt.Quarantine = 30 * time.Minute
+	}
+	for _, auth := range auths {
+		if auth == nil {
+			return nil, errors.New("auth cannot be nil")
+		}
+		if p.find(auth.DedeUserID) != nil {
+			return nil, fmt.Errorf("duplicate account %s", auth.DedeUserID)
+		}
+		p.accounts = append(p.accounts, &poolAccount{PoolAccount: PoolAccount{ID: auth.DedeUserID}, auth: auth})
+	}
+	if err := p.validate(ctx, p.accounts); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate 重新验证状态为 AccountUnchecked、AccountFailed 与隔离已结束的 AccountRiskControlled 的账号
+func (p *BiliClientPool) Validate(ctx context.Context) error {
+	p.mu.Lock()
+	var list []*poolAccount
+	now := p.now()
+	for _, a := range p.accounts {
+		switch a.State {
+		case AccountUnchecked, AccountFailed:
+			list = append(list, a)
+		case AccountRiskControlled:
+			if !now.Before(a.QuarantinedUntil) {
+				list = append(list, a)
+			}
+		}
+	}
+	p.mu.Unlock()
+	return p.validate(ctx, list)
+}
+
+// Add 添加账号并验证，已存在时替换原账号(如重新登录后)
+func (p *BiliClientPool) Add(ctx context.Context, auth *CookieAuth) error {
+	if auth == nil {
+		return errors.New("auth cannot be nil")
+	}
+	a := &poolAccount{PoolAccount: PoolAccount{ID: auth.DedeUserID}, auth: auth}
+	if err := p.validate(ctx, []*poolAccount{a}); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, old := range p.accounts {
+		if old.ID == a.ID {
+			// 同一账号沿用已消耗的预算
+			if old.used != nil && a.used != nil {
+				a.used, a.LastUsed = old.used, old.LastUsed
+			}
+			p.accounts[i] = a
+			return nil
+		}
+	}
+	p.accounts = append(p.accounts, a)
+	return nil
+}
+
+// Remove 移除账号
+func (p *BiliClientPool) Remove(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, a := range p.accounts {
+		if a.ID == id {
+			p.accounts = append(p.accounts[:i], p.accounts[i+1:]...)
+			if p.next > i {
+				p.next--
+			}
+			return
+		}
+	}
+}
+
+// Accounts 全部账号信息的快照
+func (p *BiliClientPool) Accounts() []PoolAccount {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r := make([]PoolAccount, 0, len(p.accounts))
+	for _, a := range p.accounts {
+		r = append(r, a.PoolAccount)
+	}
+	return r
+}
+
+// Client 按ID获取账号的 BiliClient ，不检查状态与预算，账号不存在或未通过验证时返回nil
+func (p *BiliClientPool) Client(id string) *BiliClient {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if a := p.find(id); a != nil {
+		return a.client
+	}
+	return nil
+}
+
+// Get 按策略选取一个可用的账号，并消耗该账号action的一次预算
+//
+// action为空或未设置预算时不限制次数。没有可用账号时返回 ErrNoAvailableAccount
+func (p *BiliClientPool) Get(action string) (*BiliClient, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	budget, limited := p.opt.Budgets[action]
+	limited = limited && action != ""
+
+	var (
+		n      = len(p.accounts)
+		picked = -1
+	)
+	for i := 0; i < n; i++ {
+		idx := i
+		if p.opt.Strategy == PoolRoundRobin {
+			idx = (p.next + i) % n
+		}
+		a := p.accounts[idx]
+		if !p.available(a, now) {
+			continue
+		}
+		if limited && len(a.window(action, budget.Window, now)) >= budget.Limit {
+			continue
+		}
+		if p.opt.Strategy == PoolRoundRobin {
+			picked = idx
+			break
+		}
+		if picked < 0 || a.LastUsed.Before(p.accounts[picked].LastUsed) {
+			picked = idx
+		}
+	}
+	if picked < 0 {
+		return nil, ErrNoAvailableAccount
+	}
+
+	a := p.accounts[picked]
+	a.LastUsed = now
+	if limited {
+		a.used[action] = append(a.used[action], now)
+	}
+	p.next = (picked + 1) % n
+	return a.client, nil
+}
+
+func (p *BiliClientPool) find(id string) *poolAccount {
+	for _, a := range p.accounts {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// available 隔离结束的账号恢复为可用，需持有锁
+func (p *BiliClientPool) available(a *poolAccount, now time.Time) bool {
+	if a.State == AccountRiskControlled && !now.Before(a.QuarantinedUntil) && a.client != nil {
+		a.QuarantinedUntil = time.Time{}
+		p.setState(a, AccountValid, nil)
+	}
+	return a.State == AccountValid
+}
+
+// window 清理窗口外的记录并返回窗口内的使用时间，需持有锁
+func (a *poolAccount) window(action string, d time.Duration, now time.Time) []time.Time {
+	used := a.used[action]
+	i := 0
+	for i < len(used) && !used[i].After(now.Add(-d)) {
+		i++
+	}
+	used = used[i:]
+	a.used[action] = used
+	return used
+}
+
+// setState 需持有锁
+func (p *BiliClientPool) setState(a *poolAccount, state AccountState, err error) {
+	changed := a.State != state
+	a.State, a.Err = state, err
+	a.updates++
+	// 隔离期间再次被风控时延长隔离
+	if state == AccountRiskControlled {
+		a.QuarantinedUntil = p.now().Add(p.opt.Quarantine)
+	}
+	if changed && p.opt.OnStateChange != nil {
+		acc := a.PoolAccount
+		p.opt.OnStateChange(&acc)
+	}
+}
+
+func (p *BiliClientPool) validate(ctx context.Context, list []*poolAccount) error {
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, p.opt.Concurrency)
+	)
+	for _, a := range list {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func(a *poolAccount) {
+			defer func() { <-sem; wg.Done() }()
+			p.validateOne(ctx, a)
+		}(a)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (p *BiliClientPool) validateOne(ctx context.Context, a *poolAccount) {
+	var s BiliSetting
+	if p.opt.Setting != nil {
+		s = *p.opt.Setting
+	}
+	s.Auth = a.auth
+	s.StoreKey = ""
+	s.Middlewares = append(append([]Middleware{}, s.Middlewares...), p.middleware(a))
+
+	p.mu.Lock()
+	before := a.updates
+	p.mu.Unlock()
+
+	client, err := newBiliClient(ctx, &s, false)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		// 中间件已经根据本次响应设置了状态
+		if a.updates != before {
+			return
+		}
+		// 网络错误等，不保留之前的状态，避免隔离结束后被 available 恢复为可用
+		p.setState(a, classifyAccountErr(err), err)
+		return
+	}
+	a.client = client
+	if a.used == nil {
+		a.used = make(map[string][]time.Time)
+	}
+	a.QuarantinedUntil = time.Time{}
+	p.setState(a, AccountValid, nil)
+}
+
+// middleware 根据响应更新账号状态
+func (p *BiliClientPool) middleware(a *poolAccount) Middleware {
+	return func(next RoundTrip) RoundTrip {
+		return func(req *RawRequest) (*RawResponse, error) {
+			resp, err := next(req)
+			if err != nil {
+				return resp, err
+			}
+			state, code := classifyAccountResp(resp)
+			if state != AccountValid {
+				p.mu.Lock()
+				msg := gjson.GetBytes(resp.Body, "message").String()
+				if msg == "" {
+					msg = http.StatusText(resp.StatusCode)
+				}
+				p.setState(a, state, &APIError{Code: code, Message: msg, Endpoint: req.Endpoint, Raw: resp.Body})
+				p.mu.Unlock()
+			}
+			return resp, err
+		}
+	}
+}
+
+// classifyAccountResp 不影响账号状态的响应返回 AccountValid
+func classifyAccountResp(resp *RawResponse) (AccountState, int) {
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return AccountRiskControlled, -412
+	}
+	if !gjson.ValidBytes(resp.Body) {
+		return AccountValid, 0
+	}
+	code := int(gjson.GetBytes(resp.Body, "code").Int())
+	if code == ErrNotLoggedIn.Code {
+		return AccountExpired, code
+	}
+	for _, c := range riskCodes {
+		if c == code {
+			return AccountRiskControlled, code
+		}
+	}
+	return AccountValid, code
+}
+
+func classifyAccountErr(err error) AccountState {
+	code, ok := ErrorCode(err)
+	if !ok {
+		return AccountFailed
+	}
+	if code == ErrNotLoggedIn.Code {
+		return AccountExpired
+	}
+	for _, c := range riskCodes {
+		if c == code {
+			return AccountRiskControlled
+		}
+	}
+	return AccountFailed
+}
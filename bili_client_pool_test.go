@@ -0,0 +1,240 @@
+package biligo
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iyear/biligo/biligotest"
+)
+
+// newFakePool 账号的SESSDATA决定 x/member/web/account 的响应：expired 未登录，risk 风控，其余正常
+func newFakePool(t *testing.T, opt *BiliClientPoolOption, ids ...string) (*BiliClientPool, *biligotest.Server) {
+	srv := biligotest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.HandleFunc(biligotest.BiliApiURL, "x/member/web/account", func(w http.ResponseWriter, r *http.Request) {
+		cookie := r.Header.Get("Cookie")
+		switch {
+		case strings.Contains(cookie, "SESSDATA=expired"):
+			biligotest.WriteJSON(w, -101, "账号未登录", nil)
+		case strings.Contains(cookie, "SESSDATA=risk"):
+			w.WriteHeader(http.StatusPreconditionFailed)
+		default:
+			biligotest.WriteJSON(w, 0, "0", map[string]interface{}{"mid": 2, "uname": "fake"})
+		}
+	})
+	if opt == nil {
+		opt = &BiliClientPoolOption{}
+	}
+	opt.Setting = &BiliSetting{Endpoints: srv.Endpoints()}
+
+	auths := make([]*CookieAuth, 0, len(ids))
+	for _, id := range ids {
+		sess := "fake_sessdata"
+		if i := strings.IndexByte(id, ':'); i >= 0 {
+			id, sess = id[:i], id[i+1:]
+		}
+		auths = append(auths, &CookieAuth{DedeUserID: id, SESSDATA: sess, BiliJCT: "fake_bili_jct"})
+	}
+	p, err := NewBiliClientPool(auths, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, srv
+}
+
+func poolStates(p *BiliClientPool) map[string]AccountState {
+	m := make(map[string]AccountState)
+	for _, a := range p.Accounts() {
+		m[a.ID] = a.State
+	}
+	return m
+}
+
+func TestOffline_BiliClientPool_Validate(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		changes []string
+	)
+	p, _ := newFakePool(t, &BiliClientPoolOption{
+		OnStateChange: func(a *PoolAccount) {
+			mu.Lock()
+			changes = append(changes, a.ID+":"+a.State.String())
+			mu.Unlock()
+		},
+	}, "1", "2:expired", "3:risk", "4")
+
+	states := poolStates(p)
+	if states["1"] != AccountValid || states["2"] != AccountExpired || states["3"] != AccountRiskControlled || states["4"] != AccountValid {
+		t.Fatalf("%v", states)
+	}
+	for _, a := range p.Accounts() {
+		if a.ID == "2" && !IsNotLoggedIn(a.Err) {
+			t.Errorf("%v", a.Err)
+		}
+		if a.ID == "3" && (!IsRateLimited(a.Err) || a.QuarantinedUntil.IsZero()) {
+			t.Errorf("%v %v", a.Err, a.QuarantinedUntil)
+		}
+	}
+	if len(changes) != 4 {
+		t.Errorf("%v", changes)
+	}
+	if p.Client("1") == nil || p.Client("2") != nil {
+		t.Error("client")
+	}
+
+	// 重新登录后替换
+	if err := p.Add(context.Background(), &CookieAuth{DedeUserID: "2", SESSDATA: "new_sessdata"}); err != nil {
+		t.Fatal(err)
+	}
+	if states = poolStates(p); states["2"] != AccountValid || len(states) != 4 {
+		t.Errorf("%v", states)
+	}
+	if p.Client("2").GetCookieAuth().SESSDATA != "new_sessdata" {
+		t.Error("account not replaced")
+	}
+}
+
+func TestOffline_BiliClientPool_Get(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	p, srv := newFakePool(t, &BiliClientPoolOption{
+		Budgets:    map[string]PoolBudget{"comment": {Limit: 2, Window: time.Hour}},
+		Quarantine: time.Minute,
+	}, "1", "2", "3:expired")
+	p.now = func() time.Time { return now }
+
+	// 轮询跳过失效账号
+	var got []string
+	for i := 0; i < 4; i++ {
+		b, err := p.Get("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, b.GetCookieAuth().DedeUserID)
+	}
+	if strings.Join(got, ",") != "1,2,1,2" {
+		t.Errorf("%v", got)
+	}
+
+	// 每个账号每小时2次
+	for i := 0; i < 4; i++ {
+		if _, err := p.Get("comment"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := p.Get("comment"); err != ErrNoAvailableAccount {
+		t.Fatalf("expected ErrNoAvailableAccount, got %v", err)
+	}
+	now = now.Add(time.Hour + time.Second)
+	if _, err := p.Get("comment"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 请求被风控时自动隔离
+	srv.HandleJSON(biligotest.BiliApiURL, "x/space/top/arc/cancel", -412, "请求被拦截", nil)
+	if err := p.Client("1").SpaceCancelTopArchive(); !IsRateLimited(err) {
+		t.Fatalf("%v", err)
+	}
+	if s := poolStates(p); s["1"] != AccountRiskControlled {
+		t.Fatalf("%v", s)
+	}
+	for i := 0; i < 3; i++ {
+		b, err := p.Get("")
+		if err != nil || b.GetCookieAuth().DedeUserID != "2" {
+			t.Fatalf("%v", err)
+		}
+	}
+	// 隔离结束后恢复
+	now = now.Add(time.Minute)
+	b1, _ := p.Get("")
+	b2, _ := p.Get("")
+	if b1.GetCookieAuth().DedeUserID == b2.GetCookieAuth().DedeUserID {
+		t.Error("quarantine not released")
+	}
+
+	p.Remove("2")
+	p.Remove("1")
+	if _, err := p.Get(""); err != ErrNoAvailableAccount {
+		t.Errorf("expected ErrNoAvailableAccount, got %v", err)
+	}
+}
+
+func TestOffline_BiliClientPool_LRU(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	p, _ := newFakePool(t, &BiliClientPoolOption{Strategy: PoolLeastRecentlyUsed}, "1", "2", "3")
+	p.now = func() time.Time { now = now.Add(time.Second); return now }
+
+	for _, id := range []string{"1", "2", "3"} {
+		if b, _ := p.Get(""); b.GetCookieAuth().DedeUserID != id {
+			t.Fatalf("%s: %s", id, b.GetCookieAuth().DedeUserID)
+		}
+	}
+	// 直接使用的账号不影响顺序，只有 Get 会更新使用时间
+	p.Client("1")
+	if b, _ := p.Get(""); b.GetCookieAuth().DedeUserID != "1" {
+		t.Error(b.GetCookieAuth().DedeUserID)
+	}
+}
+
+func TestOffline_BiliClientPool_RevalidateNetworkError(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	p, srv := newFakePool(t, &BiliClientPoolOption{Quarantine: time.Minute}, "1")
+	p.now = func() time.Time { return now }
+
+	srv.HandleJSON(biligotest.BiliApiURL, "x/space/top/arc/cancel", -412, "请求被拦截", nil)
+	if err := p.Client("1").SpaceCancelTopArchive(); !IsRateLimited(err) {
+		t.Fatalf("%v", err)
+	}
+	// 隔离结束后重新验证时连接断开
+	srv.HandleFunc(biligotest.BiliApiURL, "x/member/web/account", func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_ = conn.Close()
+	})
+	now = now.Add(time.Minute)
+	_ = p.Validate(context.Background())
+	if s := poolStates(p); s["1"] != AccountFailed {
+		t.Fatalf("%v", s)
+	}
+	if _, err := p.Get(""); err != ErrNoAvailableAccount {
+		t.Fatalf("expected ErrNoAvailableAccount, got %v", err)
+	}
+}
+
+func TestOffline_BiliClientPool_Wbi(t *testing.T) {
+	p, srv := newFakePool(t, &BiliClientPoolOption{Quarantine: time.Minute}, "1")
+
+	// 获取wbi密钥的请求不带Cookie，返回-101不影响账号状态
+	b := p.Client("1")
+	if _, err := b.RawParse(BiliApiURL, "x/space/wbi/arc/search", "GET", map[string]string{"mid": "122541"}); err != nil {
+		t.Fatal(err)
+	}
+	if s := poolStates(p); s["1"] != AccountValid {
+		t.Fatalf("%v", s)
+	}
+
+	// 签名被拒绝后重新签名成功，不隔离
+	calls := 0
+	srv.HandleFunc(biligotest.BiliApiURL, "x/space/wbi/arc/search", func(w http.ResponseWriter, r *http.Request) {
+		if calls++; calls == 1 {
+			biligotest.WriteJSON(w, -352, "风控校验失败", nil)
+			return
+		}
+		biligotest.WriteJSON(w, 0, "0", map[string]interface{}{})
+	})
+	if _, err := b.RawParse(BiliApiURL, "x/space/wbi/arc/search", "GET", map[string]string{"mid": "122541"}); err != nil {
+		t.Fatal(err)
+	}
+	if s := poolStates(p); calls != 2 || s["1"] != AccountValid {
+		t.Fatalf("calls %d %v", calls, s)
+	}
+	if _, err := p.Get(""); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,216 @@
+// Package biligotest 提供一个本地的哔哩哔哩假服务器，用于离线测试 biligo
+//
+//	srv := biligotest.NewServer()
+//	defer srv.Close()
+//	c := biligo.NewCommClient(&biligo.CommSetting{Endpoints: srv.Endpoints()})
+//
+// 每个域名映射为服务器下的一个目录，如 https://api.bilibili.com/x/web-interface/view
+// 对应 /api.bilibili.com/x/web-interface/view
+//
+// 未通过 Handle 注册的接口将返回 fixtures 目录下录制的响应，不存在时返回-404
+package biligotest
+
+import (
+	"embed"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+// 与 biligo 中的域名常量保持一致
+const (
+	BiliApiURL      = "https://api.bilibili.com/"
+	BiliMainURL     = "https://www.bilibili.com/"
+	BiliPassportURL = "https://passport.bilibili.com/"
+	BiliElecURL     = "https://elec.bilibili.com/"
+	BiliLiveURL     = "https://api.live.bilibili.com/"
+	BiliVcURL       = "https://api.vc.bilibili.com/"
+	BiliMemberURL   = "https://member.bilibili.com/"
+)
+
+var hosts = []string{BiliApiURL, BiliMainURL, BiliPassportURL, BiliElecURL, BiliLiveURL, BiliVcURL, BiliMemberURL}
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// Request 服务器收到的请求
+type Request struct {
+	Method   string
+	Base     string // 请求的域名，如 BiliApiURL
+	Endpoint string // 请求的接口，如 x/web-interface/view
+	Query    url.Values
+	Form     url.Values // POST表单，multipart请求不含文件
+	Header   http.Header
+}
+
+// Server 哔哩哔哩假服务器
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	handlers map[string]http.Handler
+	requests []*Request
+}
+
+// NewServer 创建并启动服务器，使用完毕后调用 Close
+func NewServer() *Server {
+	s := &Server{handlers: map[string]http.Handler{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Endpoints 用于 CommSetting.Endpoints 和 BiliSetting.Endpoints
+func (s *Server) Endpoints() map[string]string {
+	m := make(map[string]string, len(hosts))
+	for _, h := range hosts {
+		m[h] = s.URL + "/" + hostOf(h) + "/"
+	}
+	return m
+}
+
+// Handle 注册接口处理函数，覆盖录制的响应
+//
+// base 如 BiliApiURL，endpoint 如 x/web-interface/view
+//
+// endpoint 以 / 结尾时匹配其下的所有路径，如 correspond/1/ ，精确匹配优先
+func (s *Server) Handle(base, endpoint string, h http.Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[hostOf(base)+"/"+strings.TrimPrefix(endpoint, "/")] = h
+}
+
+// HandleFunc 同 Handle
+func (s *Server) HandleFunc(base, endpoint string, h func(w http.ResponseWriter, r *http.Request)) {
+	s.Handle(base, endpoint, http.HandlerFunc(h))
+}
+
+// HandleJSON 注册接口，返回 {"code":code,"message":message,"data":data}
+func (s *Server) HandleJSON(base, endpoint string, code int, message string, data interface{}) {
+	s.HandleFunc(base, endpoint, func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, code, message, data)
+	})
+}
+
+// Requests 获取收到的全部请求
+func (s *Server) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Request(nil), s.requests...)
+}
+
+// LastRequest 获取最后一个请求，没有时返回nil
+func (s *Server) LastRequest() *Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		return nil
+	}
+	return s.requests[len(s.requests)-1]
+}
+
+// WriteJSON 按哔哩哔哩通用格式写入响应
+func WriteJSON(w http.ResponseWriter, code int, message string, data interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":    code,
+		"message": message,
+		"ttl":     1,
+		"data":    data,
+	})
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	p := strings.TrimPrefix(r.URL.Path, "/")
+	host := p
+	endpoint := ""
+	if i := strings.Index(p, "/"); i >= 0 {
+		host, endpoint = p[:i], p[i+1:]
+	}
+
+	req := &Request{
+		Method:   r.Method,
+		Base:     "https://" + host + "/",
+		Endpoint: endpoint,
+		Query:    r.URL.Query(),
+		Header:   r.Header.Clone(),
+	}
+	if r.Method == http.MethodPost {
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+			if err := r.ParseMultipartForm(32 << 20); err == nil {
+				req.Form = url.Values(r.MultipartForm.Value)
+			}
+		} else if err := r.ParseForm(); err == nil {
+			req.Form = r.PostForm
+		}
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	h, ok := s.handlers[p]
+	if !ok {
+		h, ok = s.prefixHandler(p)
+	}
+	s.mu.Unlock()
+
+	if ok {
+		h.ServeHTTP(w, r)
+		return
+	}
+	serveFixture(w, p)
+}
+
+// prefixHandler 查找以 / 结尾且最长的匹配，调用时需持有锁
+func (s *Server) prefixHandler(p string) (http.Handler, bool) {
+	var (
+		h    http.Handler
+		best string
+	)
+	for k, v := range s.handlers {
+		if strings.HasSuffix(k, "/") && strings.HasPrefix(p, k) && len(k) > len(best) {
+			h, best = v, k
+		}
+	}
+	return h, h != nil
+}
+
+// serveFixture 查找 fixtures/<host>/<endpoint>，不存在时尝试 .json 后缀
+func serveFixture(w http.ResponseWriter, p string) {
+	for _, name := range []string{p, p + ".json"} {
+		b, err := fixtures.ReadFile(path.Join("fixtures", name))
+		if err != nil {
+			continue
+		}
+		ct := mime.TypeByExtension(path.Ext(name))
+		if ct == "" {
+			ct = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", ct)
+		_, _ = w.Write(b)
+		return
+	}
+	WriteJSON(w, -404, "啥都木有", nil)
+}
+
+// Fixture 读取录制的响应内容，name如 api.bilibili.com/x/web-interface/view.json
+func Fixture(name string) ([]byte, error) {
+	f, err := fixtures.Open(path.Join("fixtures", name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+func hostOf(base string) string {
+	u, err := url.Parse(base)
+	if err != nil || u.Host == "" {
+		return strings.Trim(base, "/")
+	}
+	return u.Host
+}
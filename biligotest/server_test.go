@@ -0,0 +1,63 @@
+package biligotest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func get(t *testing.T, u string) map[string]interface{} {
+	resp, err := http.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	var r map[string]interface{}
+	if err = json.Unmarshal(b, &r); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+func TestServer_Fixture(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	r := get(t, srv.Endpoints()[BiliApiURL]+"x/web-interface/view?aid=170001")
+	if r["code"].(float64) != 0 {
+		t.Error(r)
+	}
+	req := srv.LastRequest()
+	if req.Base != BiliApiURL || req.Endpoint != "x/web-interface/view" || req.Query.Get("aid") != "170001" {
+		t.Errorf("%+v", req)
+	}
+	if r = get(t, srv.Endpoints()[BiliApiURL]+"x/not/exist"); r["code"].(float64) != -404 {
+		t.Error(r)
+	}
+}
+func TestServer_HandleJSON(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.HandleJSON(BiliLiveURL, "/room/v1/Danmu/getConf", -412, "请求被拦截", nil)
+
+	if r := get(t, srv.Endpoints()[BiliLiveURL]+"room/v1/Danmu/getConf"); r["code"].(float64) != -412 {
+		t.Error(r)
+	}
+	if len(srv.Requests()) != 1 {
+		t.Error(srv.Requests())
+	}
+}
+func TestServer_HandlePrefix(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.HandleJSON(BiliMainURL, "correspond/", 1, "prefix", nil)
+	srv.HandleJSON(BiliMainURL, "correspond/1/", 2, "longer", nil)
+	srv.HandleJSON(BiliMainURL, "correspond/1/abc", 3, "exact", nil)
+
+	for path, code := range map[string]float64{"correspond/2/x": 1, "correspond/1/x": 2, "correspond/1/abc": 3} {
+		if r := get(t, srv.Endpoints()[BiliMainURL]+path); r["code"].(float64) != code {
+			t.Errorf("%s: %v", path, r)
+		}
+	}
+}
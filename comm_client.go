@@ -1,19 +1,21 @@
 package biligo
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/golang/protobuf/proto"
 	"github.com/iyear/biligo/internal/util"
 	"github.com/iyear/biligo/proto/dm"
 	"github.com/pkg/errors"
-	qrcode "github.com/skip2/go-qrcode"
 	"github.com/tidwall/gjson"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
+	"sync"
 )
 
 type CommClient struct {
@@ -38,6 +40,36 @@ type CommSetting struct {
 
 	// Logger ...
 	Logger *log.Logger
+
+	// WBI签名器，请求路径带有wbi的接口会自动签名
+	//
+	// 默认从导航栏接口获取密钥并每日刷新，可使用 NewWbiSignerWithKeys 指定固定密钥
+	Wbi *WbiSigner
+
+	// 限流设置，可按全局和域名分别限流
+	//
+	// 默认nil 不限流
+	RateLimit *RateLimit
+
+	// 重试策略，网络错误、HTTP 5xx和风控错误码时重试，POST 等请求只在连接失败时重试
+	//
+	// 默认nil 不重试
+	Retry *RetryPolicy
+
+	// 中间件，可用于追踪、统计、注入Header、缓存等，靠前的在外层
+	//
+	// DebugMode为true时会在最内层添加 DebugMiddleware
+	Middlewares []Middleware
+
+	// 替换请求的域名，key为 BiliApiURL BiliLiveURL 等常量，value为新的地址
+	//
+	// 可用于指向 biligotest.Server 进行离线测试，或使用反向代理
+	Endpoints map[string]string
+
+	// 按请求选择代理，连接失败或返回-412时标记代理不可用，可使用 NewProxyPool
+	//
+	// 只作用于API请求，Client的Transport需要为 *http.Transport 或nil。默认nil 不使用代理
+	Proxy ProxyProvider
 }
 
 // NewCommClient
@@ -45,11 +77,17 @@ type CommSetting struct {
 // Setting的Auth属性可以随意填写或传入nil，Auth不起到作用，用于访问公共API
 func NewCommClient(setting *CommSetting) *CommClient {
 	return &CommClient{baseClient: newBaseClient(&baseSetting{
-		Client:    setting.Client,
-		DebugMode: setting.DebugMode,
-		UserAgent: setting.UserAgent,
-		Prefix:    "CommClient ",
-		Logger:    setting.Logger,
+		Client:      setting.Client,
+		DebugMode:   setting.DebugMode,
+		UserAgent:   setting.UserAgent,
+		Prefix:      "CommClient ",
+		Logger:      setting.Logger,
+		Wbi:         setting.Wbi,
+		RateLimit:   setting.RateLimit,
+		Retry:       setting.Retry,
+		Middlewares: setting.Middlewares,
+		Endpoints:   setting.Endpoints,
+		Proxy:       setting.Proxy,
 	})}
 }
 
@@ -67,12 +105,24 @@ func (c *CommClient) SetUA(ua string) {
 	c.ua = ua
 }
 
+// Use
+//
+// 添加中间件，添加在已有中间件的外层。非并发安全，请在发起请求前调用
+func (c *CommClient) Use(mw ...Middleware) {
+	c.middlewares = append(append([]Middleware{}, mw...), c.middlewares...)
+}
+
 // Raw
 //
 // base末尾带/
 func (c *CommClient) Raw(base, endpoint, method string, payload map[string]string) ([]byte, error) {
+	return c.RawWithContext(context.Background(), base, endpoint, method, payload)
+}
+
+// RawWithContext 同 Raw，ctx 用于取消请求或设置超时
+func (c *CommClient) RawWithContext(ctx context.Context, base, endpoint, method string, payload map[string]string) ([]byte, error) {
 	// 不用侵入处理则传入nil
-	raw, err := c.raw(base, endpoint, method, payload, nil, nil)
+	raw, err := c.raw(ctx, base, endpoint, method, payload, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -82,19 +132,31 @@ func (c *CommClient) Raw(base, endpoint, method string, payload map[string]strin
 // RawParse
 //
 // base末尾带/
+//
+// 响应Code不为0时返回 *APIError
 func (c *CommClient) RawParse(base, endpoint, method string, payload map[string]string) (*Response, error) {
-	raw, err := c.Raw(base, endpoint, method, payload)
+	return c.RawParseWithContext(context.Background(), base, endpoint, method, payload)
+}
+
+// RawParseWithContext 同 RawParse，ctx 用于取消请求或设置超时
+func (c *CommClient) RawParseWithContext(ctx context.Context, base, endpoint, method string, payload map[string]string) (*Response, error) {
+	raw, err := c.RawWithContext(ctx, base, endpoint, method, payload)
 	if err != nil {
 		return nil, err
 	}
-	return c.parse(raw)
+	return c.parse(endpoint, raw)
 }
 
 // GetGeoInfo 调用哔哩哔哩API获取地理位置等信息
 //
 // 会受到自定义 http.Client 代理的影响
 func (c *CommClient) GetGeoInfo() (*GeoInfo, error) {
-	resp, err := c.RawParse(BiliApiURL,
+	return c.GetGeoInfoWithContext(context.Background())
+}
+
+// GetGeoInfoWithContext 同 GetGeoInfo，ctx 用于取消请求或设置超时
+func (c *CommClient) GetGeoInfoWithContext(ctx context.Context) (*GeoInfo, error) {
+	resp, err := c.RawParseWithContext(ctx, BiliApiURL,
 		"x/web-interface/zone",
 		"GET",
 		nil,
@@ -115,7 +177,13 @@ func (c *CommClient) GetGeoInfo() (*GeoInfo, error) {
 //
 // ps 每页项数，最大50
 func (c *CommClient) FollowingsGetDetail(mid int64, pn int, ps int) (*FollowingsDetail, error) {
-	resp, err := c.RawParse(
+	return c.FollowingsGetDetailWithContext(context.Background(), mid, pn, ps)
+}
+
+// FollowingsGetDetailWithContext 同 FollowingsGetDetail，ctx 用于取消请求或设置超时
+func (c *CommClient) FollowingsGetDetailWithContext(ctx context.Context, mid int64, pn int, ps int) (*FollowingsDetail, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/relation/followings",
 		"GET",
@@ -140,7 +208,12 @@ func (c *CommClient) FollowingsGetDetail(mid int64, pn int, ps int) (*Followings
 //
 // 获取稿件状态数
 func (c *CommClient) VideoGetStat(aid int64) (*VideoSingleStat, error) {
-	resp, err := c.RawParse(BiliApiURL,
+	return c.VideoGetStatWithContext(context.Background(), aid)
+}
+
+// VideoGetStatWithContext 同 VideoGetStat，ctx 用于取消请求或设置超时
+func (c *CommClient) VideoGetStatWithContext(ctx context.Context, aid int64) (*VideoSingleStat, error) {
+	resp, err := c.RawParseWithContext(ctx, BiliApiURL,
 		"x/web-interface/archive/stat",
 		"GET",
 		map[string]string{
@@ -161,7 +234,12 @@ func (c *CommClient) VideoGetStat(aid int64) (*VideoSingleStat, error) {
 //
 // 限制游客访问的视频会返回错误，请使用 BiliClient 发起请求
 func (c *CommClient) VideoGetInfo(aid int64) (*VideoInfo, error) {
-	resp, err := c.RawParse(BiliApiURL,
+	return c.VideoGetInfoWithContext(context.Background(), aid)
+}
+
+// VideoGetInfoWithContext 同 VideoGetInfo，ctx 用于取消请求或设置超时
+func (c *CommClient) VideoGetInfoWithContext(ctx context.Context, aid int64) (*VideoInfo, error) {
+	resp, err := c.RawParseWithContext(ctx, BiliApiURL,
 		"x/web-interface/view",
 		"GET",
 		map[string]string{
@@ -182,7 +260,12 @@ func (c *CommClient) VideoGetInfo(aid int64) (*VideoInfo, error) {
 //
 // 获取稿件简介
 func (c *CommClient) VideoGetDescription(aid int64) (string, error) {
-	resp, err := c.RawParse(BiliApiURL,
+	return c.VideoGetDescriptionWithContext(context.Background(), aid)
+}
+
+// VideoGetDescriptionWithContext 同 VideoGetDescription，ctx 用于取消请求或设置超时
+func (c *CommClient) VideoGetDescriptionWithContext(ctx context.Context, aid int64) (string, error) {
+	resp, err := c.RawParseWithContext(ctx, BiliApiURL,
 		"x/web-interface/archive/desc",
 		"GET",
 		map[string]string{
@@ -203,7 +286,12 @@ func (c *CommClient) VideoGetDescription(aid int64) (string, error) {
 //
 // 获取分P列表
 func (c *CommClient) VideoGetPageList(aid int64) ([]*VideoPage, error) {
-	resp, err := c.RawParse(BiliApiURL,
+	return c.VideoGetPageListWithContext(context.Background(), aid)
+}
+
+// VideoGetPageListWithContext 同 VideoGetPageList，ctx 用于取消请求或设置超时
+func (c *CommClient) VideoGetPageListWithContext(ctx context.Context, aid int64) ([]*VideoPage, error) {
+	resp, err := c.RawParseWithContext(ctx, BiliApiURL,
 		"x/player/pagelist",
 		"GET",
 		map[string]string{
@@ -224,7 +312,12 @@ func (c *CommClient) VideoGetPageList(aid int64) ([]*VideoPage, error) {
 //
 // 返回所有终端总计在线观看人数和WEB端在线观看人数 (用类似10万+的文字表示) cid用于分P标识
 func (c *CommClient) VideoGetOnlineNum(aid int64, cid int64) (total string, web string, e error) {
-	resp, err := c.RawParse(BiliApiURL,
+	return c.VideoGetOnlineNumWithContext(context.Background(), aid, cid)
+}
+
+// VideoGetOnlineNumWithContext 同 VideoGetOnlineNum，ctx 用于取消请求或设置超时
+func (c *CommClient) VideoGetOnlineNumWithContext(ctx context.Context, aid int64, cid int64) (total string, web string, e error) {
+	resp, err := c.RawParseWithContext(ctx, BiliApiURL,
 		"x/player/online/total",
 		"GET",
 		map[string]string{
@@ -249,7 +342,12 @@ func (c *CommClient) VideoGetOnlineNum(aid int64, cid int64) (total string, web
 //
 // 未登录无法获取 IsAtten,Liked,Hated 字段
 func (c *CommClient) VideoTags(aid int64) ([]*VideoTag, error) {
-	resp, err := c.RawParse(BiliApiURL,
+	return c.VideoTagsWithContext(context.Background(), aid)
+}
+
+// VideoTagsWithContext 同 VideoTags，ctx 用于取消请求或设置超时
+func (c *CommClient) VideoTagsWithContext(ctx context.Context, aid int64) ([]*VideoTag, error) {
+	resp, err := c.RawParseWithContext(ctx, BiliApiURL,
 		"x/tag/archive/tags",
 		"GET",
 		map[string]string{
@@ -270,7 +368,12 @@ func (c *CommClient) VideoTags(aid int64) ([]*VideoTag, error) {
 //
 // 最多获取40条推荐视频
 func (c *CommClient) VideoGetRecommend(aid int64) ([]*VideoRecommendInfo, error) {
-	resp, err := c.RawParse(BiliApiURL,
+	return c.VideoGetRecommendWithContext(context.Background(), aid)
+}
+
+// VideoGetRecommendWithContext 同 VideoGetRecommend，ctx 用于取消请求或设置超时
+func (c *CommClient) VideoGetRecommendWithContext(ctx context.Context, aid int64) ([]*VideoRecommendInfo, error) {
+	resp, err := c.RawParseWithContext(ctx, BiliApiURL,
 		"x/web-interface/archive/related",
 		"GET",
 		map[string]string{
@@ -291,7 +394,13 @@ func (c *CommClient) VideoGetRecommend(aid int64) ([]*VideoRecommendInfo, error)
 //
 // 所有参数、返回信息和取流方法的说明请直接前往：https://github.com/SocialSisterYi/bilibili-API-collect/blob/master/video/videostream_url.md
 func (c *CommClient) VideoGetPlayURL(aid int64, cid int64, qn int, fnval int) (*VideoPlayURLResult, error) {
-	resp, err := c.RawParse(
+	return c.VideoGetPlayURLWithContext(context.Background(), aid, cid, qn, fnval)
+}
+
+// VideoGetPlayURLWithContext 同 VideoGetPlayURL，ctx 用于取消请求或设置超时
+func (c *CommClient) VideoGetPlayURLWithContext(ctx context.Context, aid int64, cid int64, qn int, fnval int) (*VideoPlayURLResult, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/player/playurl",
 		"GET",
@@ -314,13 +423,66 @@ func (c *CommClient) VideoGetPlayURL(aid int64, cid int64, qn int, fnval int) (*
 	return r, nil
 }
 
+// VideoGetSubtitles 获取视频的CC字幕，并下载解析各语言的字幕内容
+//
+// 没有字幕时返回空列表，AI字幕的 Lan 以 ai- 开头，未登录时没有下载地址的AI字幕会被跳过
+func (c *CommClient) VideoGetSubtitles(aid int64, cid int64) ([]*VideoSubtitleTrack, error) {
+	return c.VideoGetSubtitlesWithContext(context.Background(), aid, cid)
+}
+
+// VideoGetSubtitlesWithContext 同 VideoGetSubtitles，ctx 用于取消请求或设置超时
+func (c *CommClient) VideoGetSubtitlesWithContext(ctx context.Context, aid int64, cid int64) ([]*VideoSubtitleTrack, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
+		BiliApiURL,
+		"x/player/wbi/v2",
+		"GET",
+		map[string]string{
+			"aid": strconv.FormatInt(aid, 10),
+			"cid": strconv.FormatInt(cid, 10),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	tracks := []*VideoSubtitleTrack{}
+	list := gjson.GetBytes(resp.Data, "subtitle.subtitles")
+	if !list.IsArray() {
+		return tracks, nil
+	}
+	var all []*VideoSubtitleTrack
+	if err = json.Unmarshal([]byte(list.Raw), &all); err != nil {
+		return nil, err
+	}
+	for _, t := range all {
+		// 未登录时AI字幕没有地址
+		if t.SubtitleURL == "" {
+			continue
+		}
+		raw, err := c.get(ctx, httpsURL(t.SubtitleURL))
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(raw, &t.Subtitle); err != nil {
+			return nil, fmt.Errorf("subtitle %s: %w", t.Lan, err)
+		}
+		tracks = append(tracks, t)
+	}
+	return tracks, nil
+}
+
 // VideoShot 获取视频快照
 //
 // cid属性非必须 传入0表示1P
 //
 // index为JSON数组截取时间表 true:需要 false:不需要 传入false则Index属性为空
 func (c *CommClient) VideoShot(aid int64, cid int64, index bool) (*VideoShot, error) {
-	resp, err := c.RawParse(BiliApiURL,
+	return c.VideoShotWithContext(context.Background(), aid, cid, index)
+}
+
+// VideoShotWithContext 同 VideoShot，ctx 用于取消请求或设置超时
+func (c *CommClient) VideoShotWithContext(ctx context.Context, aid int64, cid int64, index bool) (*VideoShot, error) {
+	resp, err := c.RawParseWithContext(ctx, BiliApiURL,
 		"x/player/videoshot",
 		"GET",
 		map[string]string{
@@ -346,7 +508,13 @@ func (c *CommClient) VideoShot(aid int64, cid int64, index bool) (*VideoShot, er
 // 返回一个map，key为dmid，value为相关信息
 // 未登录时UserLike属性恒为0
 func (c *CommClient) DanmakuGetLikes(cid int64, dmids []uint64) (map[uint64]*DanmakuGetLikesResult, error) {
-	resp, err := c.RawParse(
+	return c.DanmakuGetLikesWithContext(context.Background(), cid, dmids)
+}
+
+// DanmakuGetLikesWithContext 同 DanmakuGetLikes，ctx 用于取消请求或设置超时
+func (c *CommClient) DanmakuGetLikesWithContext(ctx context.Context, cid int64, dmids []uint64) (map[uint64]*DanmakuGetLikesResult, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/dm/thumbup/stats",
 		"GET",
@@ -373,7 +541,13 @@ func (c *CommClient) DanmakuGetLikes(cid int64, dmids []uint64) (map[uint64]*Dan
 //
 // 获取关系状态数，Whisper和Black恒为0
 func (c *CommClient) GetRelationStat(mid int64) (*RelationStat, error) {
-	resp, err := c.RawParse(
+	return c.GetRelationStatWithContext(context.Background(), mid)
+}
+
+// GetRelationStatWithContext 同 GetRelationStat，ctx 用于取消请求或设置超时
+func (c *CommClient) GetRelationStatWithContext(ctx context.Context, mid int64) (*RelationStat, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/relation/stat",
 		"GET",
@@ -395,7 +569,13 @@ func (c *CommClient) GetRelationStat(mid int64) (*RelationStat, error) {
 //
 // 获取空间置顶稿件
 func (c *CommClient) SpaceGetTopArchive(mid int64) (*SpaceVideo, error) {
-	resp, err := c.RawParse(
+	return c.SpaceGetTopArchiveWithContext(context.Background(), mid)
+}
+
+// SpaceGetTopArchiveWithContext 同 SpaceGetTopArchive，ctx 用于取消请求或设置超时
+func (c *CommClient) SpaceGetTopArchiveWithContext(ctx context.Context, mid int64) (*SpaceVideo, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/top/arc",
 		"GET",
@@ -417,7 +597,13 @@ func (c *CommClient) SpaceGetTopArchive(mid int64) (*SpaceVideo, error) {
 //
 // 获取UP代表作
 func (c *CommClient) SpaceGetMasterpieces(mid int64) ([]*SpaceVideo, error) {
-	resp, err := c.RawParse(
+	return c.SpaceGetMasterpiecesWithContext(context.Background(), mid)
+}
+
+// SpaceGetMasterpiecesWithContext 同 SpaceGetMasterpieces，ctx 用于取消请求或设置超时
+func (c *CommClient) SpaceGetMasterpiecesWithContext(ctx context.Context, mid int64) ([]*SpaceVideo, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/masterpiece",
 		"GET",
@@ -439,7 +625,13 @@ func (c *CommClient) SpaceGetMasterpieces(mid int64) ([]*SpaceVideo, error) {
 //
 // 获取空间用户个人TAG 上限5条，且内容由用户自定义 带有转义
 func (c *CommClient) SpaceGetTags(mid int64) ([]string, error) {
-	resp, err := c.RawParse(
+	return c.SpaceGetTagsWithContext(context.Background(), mid)
+}
+
+// SpaceGetTagsWithContext 同 SpaceGetTags，ctx 用于取消请求或设置超时
+func (c *CommClient) SpaceGetTagsWithContext(ctx context.Context, mid int64) ([]string, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/acc/tags",
 		"GET",
@@ -462,7 +654,13 @@ func (c *CommClient) SpaceGetTags(mid int64) ([]string, error) {
 //
 // 获取空间公告内容
 func (c *CommClient) SpaceGetNotice(mid int64) (string, error) {
-	resp, err := c.RawParse(
+	return c.SpaceGetNoticeWithContext(context.Background(), mid)
+}
+
+// SpaceGetNoticeWithContext 同 SpaceGetNotice，ctx 用于取消请求或设置超时
+func (c *CommClient) SpaceGetNoticeWithContext(ctx context.Context, mid int64) (string, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/notice",
 		"GET",
@@ -486,7 +684,13 @@ func (c *CommClient) SpaceGetNotice(mid int64) (string, error) {
 //
 // 获取用户空间近期玩的游戏
 func (c *CommClient) SpaceGetLastPlayGame(mid int64) ([]*SpaceGame, error) {
-	resp, err := c.RawParse(
+	return c.SpaceGetLastPlayGameWithContext(context.Background(), mid)
+}
+
+// SpaceGetLastPlayGameWithContext 同 SpaceGetLastPlayGame，ctx 用于取消请求或设置超时
+func (c *CommClient) SpaceGetLastPlayGameWithContext(ctx context.Context, mid int64) ([]*SpaceGame, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/lastplaygame",
 		"GET",
@@ -508,7 +712,13 @@ func (c *CommClient) SpaceGetLastPlayGame(mid int64) ([]*SpaceGame, error) {
 //
 // 获取用户最近投币的视频明细 如设置隐私查看自己的使用 BiliClient 访问
 func (c *CommClient) SpaceGetLastVideoCoin(mid int64) ([]*SpaceVideoCoin, error) {
-	resp, err := c.RawParse(
+	return c.SpaceGetLastVideoCoinWithContext(context.Background(), mid)
+}
+
+// SpaceGetLastVideoCoinWithContext 同 SpaceGetLastVideoCoin，ctx 用于取消请求或设置超时
+func (c *CommClient) SpaceGetLastVideoCoinWithContext(ctx context.Context, mid int64) ([]*SpaceVideoCoin, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/coin/video",
 		"GET",
@@ -544,7 +754,13 @@ func (c *CommClient) SpaceGetLastVideoCoin(mid int64) ([]*SpaceVideoCoin, error)
 //
 // ps 每页项数
 func (c *CommClient) SpaceSearchVideo(mid int64, order string, tid int, keyword string, pn int, ps int) (*SpaceVideoSearchResult, error) {
-	resp, err := c.RawParse(
+	return c.SpaceSearchVideoWithContext(context.Background(), mid, order, tid, keyword, pn, ps)
+}
+
+// SpaceSearchVideoWithContext 同 SpaceSearchVideo，ctx 用于取消请求或设置超时
+func (c *CommClient) SpaceSearchVideoWithContext(ctx context.Context, mid int64, order string, tid int, keyword string, pn int, ps int) (*SpaceVideoSearchResult, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/wbi/arc/search",
 		"GET",
@@ -571,7 +787,13 @@ func (c *CommClient) SpaceSearchVideo(mid int64, order string, tid int, keyword
 //
 // 获取用户频道列表
 func (c *CommClient) ChanGet(mid int64) (*ChannelList, error) {
-	resp, err := c.RawParse(
+	return c.ChanGetWithContext(context.Background(), mid)
+}
+
+// ChanGetWithContext 同 ChanGet，ctx 用于取消请求或设置超时
+func (c *CommClient) ChanGetWithContext(ctx context.Context, mid int64) (*ChannelList, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/channel/list",
 		"GET",
@@ -599,7 +821,13 @@ func (c *CommClient) ChanGet(mid int64) (*ChannelList, error) {
 //
 // ps 每页项数
 func (c *CommClient) ChanGetVideo(mid int64, cid int64, pn int, ps int) (*ChanVideo, error) {
-	resp, err := c.RawParse(
+	return c.ChanGetVideoWithContext(context.Background(), mid, cid, pn, ps)
+}
+
+// ChanGetVideoWithContext 同 ChanGetVideo，ctx 用于取消请求或设置超时
+func (c *CommClient) ChanGetVideoWithContext(ctx context.Context, mid int64, cid int64, pn int, ps int) (*ChanVideo, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/channel/video",
 		"GET",
@@ -624,7 +852,13 @@ func (c *CommClient) ChanGetVideo(mid int64, cid int64, pn int, ps int) (*ChanVi
 //
 // 获取用户的公开收藏夹列表
 func (c *CommClient) FavGet(mid int64) (*FavoritesList, error) {
-	resp, err := c.RawParse(
+	return c.FavGetWithContext(context.Background(), mid)
+}
+
+// FavGetWithContext 同 FavGet，ctx 用于取消请求或设置超时
+func (c *CommClient) FavGetWithContext(ctx context.Context, mid int64) (*FavoritesList, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v3/fav/folder/created/list-all",
 		"GET",
@@ -649,7 +883,13 @@ func (c *CommClient) FavGet(mid int64) (*FavoritesList, error) {
 //
 // 获取收藏夹详细信息，部分信息需要登录，请使用 BiliClient 请求
 func (c *CommClient) FavGetDetail(mlid int64) (*FavDetail, error) {
-	resp, err := c.RawParse(
+	return c.FavGetDetailWithContext(context.Background(), mlid)
+}
+
+// FavGetDetailWithContext 同 FavGetDetail，ctx 用于取消请求或设置超时
+func (c *CommClient) FavGetDetailWithContext(ctx context.Context, mlid int64) (*FavDetail, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v3/fav/folder/info",
 		"GET",
@@ -671,7 +911,13 @@ func (c *CommClient) FavGetDetail(mlid int64) (*FavDetail, error) {
 //
 // 获取收藏夹全部内容id 查询权限收藏夹时请使用 BiliClient 请求
 func (c *CommClient) FavGetRes(mlid int64) ([]*FavRes, error) {
-	resp, err := c.RawParse(
+	return c.FavGetResWithContext(context.Background(), mlid)
+}
+
+// FavGetResWithContext 同 FavGetRes，ctx 用于取消请求或设置超时
+func (c *CommClient) FavGetResWithContext(ctx context.Context, mlid int64) ([]*FavRes, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v3/fav/resource/ids",
 		"GET",
@@ -709,7 +955,13 @@ func (c *CommClient) FavGetRes(mlid int64) ([]*FavRes, error) {
 //
 // ps 每页项数 ps不能太大，会报错
 func (c *CommClient) FavGetResDetail(mlid int64, tid int, keyword string, order string, tp int, pn int, ps int) (*FavResDetail, error) {
-	resp, err := c.RawParse(
+	return c.FavGetResDetailWithContext(context.Background(), mlid, tid, keyword, order, tp, pn, ps)
+}
+
+// FavGetResDetailWithContext 同 FavGetResDetail，ctx 用于取消请求或设置超时
+func (c *CommClient) FavGetResDetailWithContext(ctx context.Context, mlid int64, tid int, keyword string, order string, tp int, pn int, ps int) (*FavResDetail, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v3/fav/resource/list",
 		"GET",
@@ -737,7 +989,12 @@ func (c *CommClient) FavGetResDetail(mlid int64, tid int, keyword string, order
 //
 // 获取每日分区投稿数
 func (c *CommClient) GetDailyNum() (map[int]int, error) {
-	resp, err := c.RawParse(BiliApiURL, "x/web-interface/online", "GET", nil)
+	return c.GetDailyNumWithContext(context.Background())
+}
+
+// GetDailyNumWithContext 同 GetDailyNum，ctx 用于取消请求或设置超时
+func (c *CommClient) GetDailyNumWithContext(ctx context.Context) (map[int]int, error) {
+	resp, err := c.RawParseWithContext(ctx, BiliApiURL, "x/web-interface/online", "GET", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -753,7 +1010,13 @@ func (c *CommClient) GetDailyNum() (map[int]int, error) {
 //
 // 获取服务器的Unix时间戳
 func (c *CommClient) GetUnixNow() (int64, error) {
-	resp, err := c.RawParse(
+	return c.GetUnixNowWithContext(context.Background())
+}
+
+// GetUnixNowWithContext 同 GetUnixNow，ctx 用于取消请求或设置超时
+func (c *CommClient) GetUnixNowWithContext(ctx context.Context) (int64, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/report/click/now",
 		"GET",
@@ -775,7 +1038,13 @@ func (c *CommClient) GetUnixNow() (int64, error) {
 //
 // 获取实时弹幕(protobuf接口)
 func (c *CommClient) DanmakuGetByPb(tp int, cid int64, seg int) (*DanmakuResp, error) {
-	resp, err := c.Raw(
+	return c.DanmakuGetByPbWithContext(context.Background(), tp, cid, seg)
+}
+
+// DanmakuGetByPbWithContext 同 DanmakuGetByPb，ctx 用于取消请求或设置超时
+func (c *CommClient) DanmakuGetByPbWithContext(ctx context.Context, tp int, cid int64, seg int) (*DanmakuResp, error) {
+	resp, err := c.RawWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/dm/web/seg.so",
 		"GET",
@@ -814,11 +1083,125 @@ func (c *CommClient) DanmakuGetByPb(tp int, cid int64, seg int) (*DanmakuResp, e
 
 }
 
+// danmakuSegDuration 每个弹幕分包的时长(秒)
+const danmakuSegDuration = 6 * 60
+
+// DanmakuGetAllOption DanmakuGetAll 设置
+type DanmakuGetAllOption struct {
+	// Concurrency 同时请求的分包数 默认4
+	Concurrency int
+}
+
+// DanmakuGetAll
+//
+// 获取视频某一P的全部实时弹幕
+//
+// 根据 VideoGetPageList 中的时长计算分包数，并发获取所有分包后按ID去重，按Progress排序
+func (c *CommClient) DanmakuGetAll(aid int64, cid int64) (*DanmakuResp, error) {
+	return c.DanmakuGetAllWithContext(context.Background(), aid, cid)
+}
+
+// DanmakuGetAllWithContext 同 DanmakuGetAll，ctx 用于取消请求或设置超时
+func (c *CommClient) DanmakuGetAllWithContext(ctx context.Context, aid int64, cid int64) (*DanmakuResp, error) {
+	return c.DanmakuGetAllWithOption(ctx, aid, cid, nil)
+}
+
+// DanmakuGetAllWithOption 同 DanmakuGetAllWithContext，opt 用于设置并发数，opt可为nil
+func (c *CommClient) DanmakuGetAllWithOption(ctx context.Context, aid int64, cid int64, opt *DanmakuGetAllOption) (*DanmakuResp, error) {
+	concurrency := 4
+	if opt != nil && opt.Concurrency > 0 {
+		concurrency = opt.Concurrency
+	}
+	pages, err := c.VideoGetPageListWithContext(ctx, aid)
+	if err != nil {
+		return nil, err
+	}
+	var duration int64 = -1
+	for _, p := range pages {
+		if p.CID == cid {
+			duration = p.Duration
+			break
+		}
+	}
+	if duration < 0 {
+		return nil, fmt.Errorf("cid %d not found in av%d", cid, aid)
+	}
+	segs := int((duration + danmakuSegDuration - 1) / danmakuSegDuration)
+	if segs < 1 {
+		segs = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, concurrency)
+		results = make([]*DanmakuResp, segs)
+		errOnce sync.Once
+		segErr  error
+	)
+	for i := 0; i < segs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			// segment_index 从1开始
+			r, err := c.DanmakuGetByPbWithContext(ctx, 1, cid, i+1)
+			if err != nil {
+				errOnce.Do(func() {
+					segErr = err
+					cancel()
+				})
+				return
+			}
+			results[i] = r
+		}(i)
+	}
+	wg.Wait()
+	if segErr != nil {
+		return nil, segErr
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var (
+		r    = &DanmakuResp{}
+		seen = make(map[uint64]struct{})
+	)
+	for _, seg := range results {
+		for _, d := range seg.Danmaku {
+			if _, ok := seen[d.ID]; ok {
+				continue
+			}
+			seen[d.ID] = struct{}{}
+			r.Danmaku = append(r.Danmaku, d)
+		}
+	}
+	sort.SliceStable(r.Danmaku, func(i, j int) bool {
+		return r.Danmaku[i].Progress < r.Danmaku[j].Progress
+	})
+	return r, nil
+}
+
 // DanmakuGetShot
 //
 // 获取弹幕快照(最新的几条弹幕)
 func (c *CommClient) DanmakuGetShot(aid int64) ([]string, error) {
-	resp, err := c.RawParse(
+	return c.DanmakuGetShotWithContext(context.Background(), aid)
+}
+
+// DanmakuGetShotWithContext 同 DanmakuGetShot，ctx 用于取消请求或设置超时
+func (c *CommClient) DanmakuGetShotWithContext(ctx context.Context, aid int64) ([]string, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/dm/ajax",
 		"GET",
@@ -842,7 +1225,13 @@ func (c *CommClient) DanmakuGetShot(aid int64) ([]string, error) {
 //
 // 全为免费表情包，如需获取个人专属表情包请使用 BiliClient 请求
 func (c *CommClient) EmoteGetFreePack(business string) ([]*EmotePack, error) {
-	resp, err := c.RawParse(
+	return c.EmoteGetFreePackWithContext(context.Background(), business)
+}
+
+// EmoteGetFreePackWithContext 同 EmoteGetFreePack，ctx 用于取消请求或设置超时
+func (c *CommClient) EmoteGetFreePackWithContext(ctx context.Context, business string) ([]*EmotePack, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/emote/user/panel/web",
 		"GET",
@@ -868,7 +1257,13 @@ func (c *CommClient) EmoteGetFreePack(business string) ([]*EmotePack, error) {
 //
 // ids 多个表情包id的数组
 func (c *CommClient) EmoteGetPackDetail(business string, ids []int64) ([]*EmotePack, error) {
-	resp, err := c.RawParse(
+	return c.EmoteGetPackDetailWithContext(context.Background(), business, ids)
+}
+
+// EmoteGetPackDetailWithContext 同 EmoteGetPackDetail，ctx 用于取消请求或设置超时
+func (c *CommClient) EmoteGetPackDetailWithContext(ctx context.Context, business string, ids []int64) ([]*EmotePack, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/emote/package",
 		"GET",
@@ -893,7 +1288,13 @@ func (c *CommClient) EmoteGetPackDetail(business string, ids []int64) ([]*EmoteP
 //
 // 获取音频信息 部分属性需要登录，请使用 BiliClient 请求
 func (c *CommClient) AudioGetInfo(auid int64) (*AudioInfo, error) {
-	resp, err := c.RawParse(
+	return c.AudioGetInfoWithContext(context.Background(), auid)
+}
+
+// AudioGetInfoWithContext 同 AudioGetInfo，ctx 用于取消请求或设置超时
+func (c *CommClient) AudioGetInfoWithContext(ctx context.Context, auid int64) (*AudioInfo, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliMainURL,
 		"audio/music-service-c/web/song/info",
 		"GET",
@@ -915,7 +1316,13 @@ func (c *CommClient) AudioGetInfo(auid int64) (*AudioInfo, error) {
 //
 // 根据页面显示观察，应该是歌曲分类
 func (c *CommClient) AudioGetTags(auid int64) ([]*AudioTag, error) {
-	resp, err := c.RawParse(
+	return c.AudioGetTagsWithContext(context.Background(), auid)
+}
+
+// AudioGetTagsWithContext 同 AudioGetTags，ctx 用于取消请求或设置超时
+func (c *CommClient) AudioGetTagsWithContext(ctx context.Context, auid int64) ([]*AudioTag, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliMainURL,
 		"audio/music-service-c/web/tag/song",
 		"GET",
@@ -937,7 +1344,13 @@ func (c *CommClient) AudioGetTags(auid int64) ([]*AudioTag, error) {
 //
 // 获取音频创作者信息
 func (c *CommClient) AudioGetMembers(auid int64) ([]*AudioMember, error) {
-	resp, err := c.RawParse(
+	return c.AudioGetMembersWithContext(context.Background(), auid)
+}
+
+// AudioGetMembersWithContext 同 AudioGetMembers，ctx 用于取消请求或设置超时
+func (c *CommClient) AudioGetMembersWithContext(ctx context.Context, auid int64) ([]*AudioMember, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliMainURL,
 		"audio/music-service-c/web/member/song",
 		"GET",
@@ -959,7 +1372,13 @@ func (c *CommClient) AudioGetMembers(auid int64) ([]*AudioMember, error) {
 //
 // 同 AudioGetInfo 中的lrc歌词
 func (c *CommClient) AudioGetLyric(auid int64) (string, error) {
-	resp, err := c.RawParse(
+	return c.AudioGetLyricWithContext(context.Background(), auid)
+}
+
+// AudioGetLyricWithContext 同 AudioGetLyric，ctx 用于取消请求或设置超时
+func (c *CommClient) AudioGetLyricWithContext(ctx context.Context, auid int64) (string, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliMainURL,
 		"audio/music-service-c/web/song/lyric",
 		"GET",
@@ -981,7 +1400,13 @@ func (c *CommClient) AudioGetLyric(auid int64) (string, error) {
 //
 // 没有投币数 获取投币数请使用 AudioGetInfo
 func (c *CommClient) AudioGetStat(auid int64) (*AudioInfoStat, error) {
-	resp, err := c.RawParse(
+	return c.AudioGetStatWithContext(context.Background(), auid)
+}
+
+// AudioGetStatWithContext 同 AudioGetStat，ctx 用于取消请求或设置超时
+func (c *CommClient) AudioGetStatWithContext(ctx context.Context, auid int64) (*AudioInfoStat, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliMainURL,
 		"audio/music-service-c/web/stat/song",
 		"GET",
@@ -1017,7 +1442,13 @@ func (c *CommClient) AudioGetStat(auid int64) (*AudioInfoStat, error) {
 //
 // 取流：https://github.com/SocialSisterYi/bilibili-API-collect/blob/master/audio/musicstream_url.md#%E9%9F%B3%E9%A2%91%E6%B5%81%E7%9A%84%E8%8E%B7%E5%8F%96
 func (c *CommClient) AudioGetPlayURL(auid int64, qn int) (*AudioPlayURL, error) {
-	resp, err := c.RawParse(
+	return c.AudioGetPlayURLWithContext(context.Background(), auid, qn)
+}
+
+// AudioGetPlayURLWithContext 同 AudioGetPlayURL，ctx 用于取消请求或设置超时
+func (c *CommClient) AudioGetPlayURLWithContext(ctx context.Context, auid int64, qn int) (*AudioPlayURL, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"audio/music-service-c/url",
 		"GET",
@@ -1043,7 +1474,13 @@ func (c *CommClient) AudioGetPlayURL(auid int64, qn int) (*AudioPlayURL, error)
 //
 // 获取用户空间充电名单
 func (c *CommClient) ChargeSpaceGetList(mid int64) (*ChargeSpaceList, error) {
-	resp, err := c.RawParse(
+	return c.ChargeSpaceGetListWithContext(context.Background(), mid)
+}
+
+// ChargeSpaceGetListWithContext 同 ChargeSpaceGetList，ctx 用于取消请求或设置超时
+func (c *CommClient) ChargeSpaceGetListWithContext(ctx context.Context, mid int64) (*ChargeSpaceList, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliElecURL,
 		"api/query.rank.do",
 		"GET",
@@ -1065,7 +1502,13 @@ func (c *CommClient) ChargeSpaceGetList(mid int64) (*ChargeSpaceList, error) {
 //
 // 获取用户视频充电名单
 func (c *CommClient) ChargeVideoGetList(mid int64, aid int64) (*ChargeVideoList, error) {
-	resp, err := c.RawParse(
+	return c.ChargeVideoGetListWithContext(context.Background(), mid, aid)
+}
+
+// ChargeVideoGetListWithContext 同 ChargeVideoGetList，ctx 用于取消请求或设置超时
+func (c *CommClient) ChargeVideoGetListWithContext(ctx context.Context, mid int64, aid int64) (*ChargeVideoList, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/web-interface/elec/show",
 		"GET",
@@ -1089,7 +1532,12 @@ func (c *CommClient) ChargeVideoGetList(mid int64, aid int64) (*ChargeVideoList,
 //
 // 从mid获取直播间信息
 func (c *CommClient) LiveGetRoomInfoByMID(mid int64) (*LiveRoomInfoByMID, error) {
-	r, err := c.UserGetInfo(mid)
+	return c.LiveGetRoomInfoByMIDWithContext(context.Background(), mid)
+}
+
+// LiveGetRoomInfoByMIDWithContext 同 LiveGetRoomInfoByMID，ctx 用于取消请求或设置超时
+func (c *CommClient) LiveGetRoomInfoByMIDWithContext(ctx context.Context, mid int64) (*LiveRoomInfoByMID, error) {
+	r, err := c.UserGetInfoWithContext(ctx, mid)
 	if err != nil {
 		return nil, err
 	}
@@ -1100,7 +1548,13 @@ func (c *CommClient) LiveGetRoomInfoByMID(mid int64) (*LiveRoomInfoByMID, error)
 //
 // roomID 可为短号也可以是真实房号
 func (c *CommClient) LiveGetRoomInfoByID(roomID int64) (*LiveRoomInfoByID, error) {
-	resp, err := c.RawParse(
+	return c.LiveGetRoomInfoByIDWithContext(context.Background(), roomID)
+}
+
+// LiveGetRoomInfoByIDWithContext 同 LiveGetRoomInfoByID，ctx 用于取消请求或设置超时
+func (c *CommClient) LiveGetRoomInfoByIDWithContext(ctx context.Context, roomID int64) (*LiveRoomInfoByID, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/web-room/v1/index/getRoomPlayInfo",
 		"GET",
@@ -1122,7 +1576,13 @@ func (c *CommClient) LiveGetRoomInfoByID(roomID int64) (*LiveRoomInfoByID, error
 //
 // roomID: 真实直播间ID
 func (c *CommClient) LiveGetWsConf(roomID int64) (*LiveWsConf, error) {
-	resp, err := c.RawParse(
+	return c.LiveGetWsConfWithContext(context.Background(), roomID)
+}
+
+// LiveGetWsConfWithContext 同 LiveGetWsConf，ctx 用于取消请求或设置超时
+func (c *CommClient) LiveGetWsConfWithContext(ctx context.Context, roomID int64) (*LiveWsConf, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"room/v1/Danmu/getConf",
 		"GET",
@@ -1144,7 +1604,13 @@ func (c *CommClient) LiveGetWsConf(roomID int64) (*LiveWsConf, error) {
 //
 // 获取直播分区信息
 func (c *CommClient) LiveGetAreaInfo() ([]*LiveAreaInfo, error) {
-	resp, err := c.RawParse(
+	return c.LiveGetAreaInfoWithContext(context.Background())
+}
+
+// LiveGetAreaInfoWithContext 同 LiveGetAreaInfo，ctx 用于取消请求或设置超时
+func (c *CommClient) LiveGetAreaInfoWithContext(ctx context.Context) ([]*LiveAreaInfo, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"room/v1/Area/getList",
 		"GET",
@@ -1170,7 +1636,13 @@ func (c *CommClient) LiveGetAreaInfo() ([]*LiveAreaInfo, error) {
 //
 // ps: 每页项数
 func (c *CommClient) LiveGetGuardList(roomID int64, mid int64, pn int, ps int) (*LiveGuardList, error) {
-	resp, err := c.RawParse(
+	return c.LiveGetGuardListWithContext(context.Background(), roomID, mid, pn, ps)
+}
+
+// LiveGetGuardListWithContext 同 LiveGetGuardList，ctx 用于取消请求或设置超时
+func (c *CommClient) LiveGetGuardListWithContext(ctx context.Context, roomID int64, mid int64, pn int, ps int) (*LiveGuardList, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/app-room/v1/guardTab/topList",
 		"GET",
@@ -1197,7 +1669,13 @@ func (c *CommClient) LiveGetGuardList(roomID int64, mid int64, pn int, ps int) (
 //
 // mid: 主播mid
 func (c *CommClient) LiveGetMedalRank(roomID int64, mid int64) (*LiveMedalRank, error) {
-	resp, err := c.RawParse(
+	return c.LiveGetMedalRankWithContext(context.Background(), roomID, mid)
+}
+
+// LiveGetMedalRankWithContext 同 LiveGetMedalRank，ctx 用于取消请求或设置超时
+func (c *CommClient) LiveGetMedalRankWithContext(ctx context.Context, roomID int64, mid int64) (*LiveMedalRank, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"rankdb/v1/RoomRank/webMedalRank",
 		"GET",
@@ -1220,14 +1698,25 @@ func (c *CommClient) LiveGetMedalRank(roomID int64, mid int64) (*LiveMedalRank,
 //
 // qn: 原画:10000 蓝光:400 超清:250 高清:150 流畅:80
 func (c *CommClient) LiveGetPlayURL(roomID int64, qn int) (*LivePlayURL, error) {
-	resp, err := c.RawParse(
+	return c.LiveGetPlayURLWithContext(context.Background(), roomID, qn)
+}
+
+// LiveGetPlayURLWithContext 同 LiveGetPlayURL，ctx 用于取消请求或设置超时
+func (c *CommClient) LiveGetPlayURLWithContext(ctx context.Context, roomID int64, qn int) (*LivePlayURL, error) {
+	return c.liveGetPlayURL(ctx, roomID, qn, "web")
+}
+
+// liveGetPlayURL platform为web时返回FLV流，为h5时返回HLS(m3u8)流
+func (c *CommClient) liveGetPlayURL(ctx context.Context, roomID int64, qn int, platform string) (*LivePlayURL, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/web-room/v1/playUrl/playUrl",
 		"GET",
 		map[string]string{
 			"cid":           strconv.FormatInt(roomID, 10),
 			"qn":            strconv.Itoa(qn),
-			"platform":      "web",
+			"platform":      platform,
 			"https_url_req": "1",
 			"ptype":         "16",
 		},
@@ -1250,7 +1739,13 @@ func (c *CommClient) LiveGetPlayURL(roomID int64, qn int) (*LivePlayURL, error)
 //
 // 三个字段可以不用填，但填了有助于减小返回内容的大小，置空(传入0)返回约 2.7w 行，填了三个对应值返回约 1.4w 行
 func (c *CommClient) LiveGetAllGiftInfo(roomID int64, areaID int, areaParentID int) (*LiveAllGiftInfo, error) {
-	resp, err := c.RawParse(
+	return c.LiveGetAllGiftInfoWithContext(context.Background(), roomID, areaID, areaParentID)
+}
+
+// LiveGetAllGiftInfoWithContext 同 LiveGetAllGiftInfo，ctx 用于取消请求或设置超时
+func (c *CommClient) LiveGetAllGiftInfoWithContext(ctx context.Context, roomID int64, areaID int, areaParentID int) (*LiveAllGiftInfo, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/web-room/v1/giftPanel/giftConfig",
 		"GET",
@@ -1274,7 +1769,13 @@ func (c *CommClient) LiveGetAllGiftInfo(roomID int64, areaID int, areaParentID i
 
 // GetEffectConfList ...
 func (c *CommClient) GetEffectConfList(roomID int64, areaID int, areaParentID int) (*GetEffectConfList, error) {
-	resp, err := c.RawParse(
+	return c.GetEffectConfListWithContext(context.Background(), roomID, areaID, areaParentID)
+}
+
+// GetEffectConfListWithContext 同 GetEffectConfList，ctx 用于取消请求或设置超时
+func (c *CommClient) GetEffectConfListWithContext(ctx context.Context, roomID int64, areaID int, areaParentID int) (*GetEffectConfList, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/general-interface/v1/fullScSpecialEffect/GetEffectConfList",
 		"GET",
@@ -1302,7 +1803,13 @@ func (c *CommClient) GetEffectConfList(roomID int64, areaID int, areaParentID in
 //
 // tp: 类型。https://github.com/SocialSisterYi/bilibili-API-collect/tree/master/comment#%E8%AF%84%E8%AE%BA%E5%8C%BA%E7%B1%BB%E5%9E%8B%E4%BB%A3%E7%A0%81
 func (c *CommClient) CommentGetCount(oid int64, tp int) (int, error) {
-	resp, err := c.RawParse(
+	return c.CommentGetCountWithContext(context.Background(), oid, tp)
+}
+
+// CommentGetCountWithContext 同 CommentGetCount，ctx 用于取消请求或设置超时
+func (c *CommClient) CommentGetCountWithContext(ctx context.Context, oid int64, tp int) (int, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/reply/count",
 		"GET",
@@ -1343,7 +1850,13 @@ func (c *CommClient) CommentGetCount(oid int64, tp int) (int, error) {
 //
 // 具体用法请看测试样例
 func (c *CommClient) CommentGetMain(oid int64, tp int, mode int, next int, ps int) (*CommentMain, error) {
-	resp, err := c.RawParse(
+	return c.CommentGetMainWithContext(context.Background(), oid, tp, mode, next, ps)
+}
+
+// CommentGetMainWithContext 同 CommentGetMain，ctx 用于取消请求或设置超时
+func (c *CommClient) CommentGetMainWithContext(ctx context.Context, oid int64, tp int, mode int, next int, ps int) (*CommentMain, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/reply/main",
 		"GET",
@@ -1377,7 +1890,13 @@ func (c *CommClient) CommentGetMain(oid int64, tp int, mode int, next int, ps in
 //
 // ps: 二级评论每页项数 定义域：1-49
 func (c *CommClient) CommentGetReply(oid int64, tp int, root int64, pn int, ps int) (*CommentReply, error) {
-	resp, err := c.RawParse(
+	return c.CommentGetReplyWithContext(context.Background(), oid, tp, root, pn, ps)
+}
+
+// CommentGetReplyWithContext 同 CommentGetReply，ctx 用于取消请求或设置超时
+func (c *CommClient) CommentGetReplyWithContext(ctx context.Context, oid int64, tp int, root int64, pn int, ps int) (*CommentReply, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/v2/reply/reply",
 		"GET",
@@ -1400,7 +1919,13 @@ func (c *CommClient) CommentGetReply(oid int64, tp int, root int64, pn int, ps i
 }
 
 func (c *CommClient) UserGetInfo(mid int64) (*UserInfo, error) {
-	resp, err := c.RawParse(
+	return c.UserGetInfoWithContext(context.Background(), mid)
+}
+
+// UserGetInfoWithContext 同 UserGetInfo，ctx 用于取消请求或设置超时
+func (c *CommClient) UserGetInfoWithContext(ctx context.Context, mid int64) (*UserInfo, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliApiURL,
 		"x/space/acc/info",
 		"GET",
@@ -1427,7 +1952,13 @@ type GetRoomListResp struct {
 
 // GetRoomList https://api.live.bilibili.com/xlive/web-interface/v1/second/getList?platform=web&parent_area_id=6&area_id=308&sort_type=&page=1&vajra_business_key=
 func (c *CommClient) GetRoomList(parentAreaID, areaID, sortType string, page int) (*GetRoomListResp, error) {
-	resp, err := c.RawParse(
+	return c.GetRoomListWithContext(context.Background(), parentAreaID, areaID, sortType, page)
+}
+
+// GetRoomListWithContext 同 GetRoomList，ctx 用于取消请求或设置超时
+func (c *CommClient) GetRoomListWithContext(ctx context.Context, parentAreaID, areaID, sortType string, page int) (*GetRoomListResp, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/web-interface/v1/second/getList",
 		"GET",
@@ -1451,7 +1982,13 @@ func (c *CommClient) GetRoomList(parentAreaID, areaID, sortType string, page int
 
 // GetWebAreaList https://api.live.bilibili.com/xlive/web-interface/v1/index/getWebAreaList?source_id=2
 func (c *CommClient) GetWebAreaList(sourceID int64) ([]*AreaInfo, error) {
-	resp, err := c.RawParse(
+	return c.GetWebAreaListWithContext(context.Background(), sourceID)
+}
+
+// GetWebAreaListWithContext 同 GetWebAreaList，ctx 用于取消请求或设置超时
+func (c *CommClient) GetWebAreaListWithContext(ctx context.Context, sourceID int64) ([]*AreaInfo, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/web-interface/v1/index/getWebAreaList",
 		"GET",
@@ -1479,7 +2016,13 @@ type WebQRCodeGenerateResp struct {
 
 // WebQRCodeGenerate 网页版获取二维码链接
 func (c *CommClient) WebQRCodeGenerate() (*WebQRCodeGenerateResp, error) {
-	resp, err := c.RawParse(
+	return c.WebQRCodeGenerateWithContext(context.Background())
+}
+
+// WebQRCodeGenerateWithContext 同 WebQRCodeGenerate，ctx 用于取消请求或设置超时
+func (c *CommClient) WebQRCodeGenerateWithContext(ctx context.Context) (*WebQRCodeGenerateResp, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliPassportURL,
 		"x/passport-login/web/qrcode/generate",
 		"GET",
@@ -1527,12 +2070,19 @@ func (resp *WebQRCodePoolResp) GetCookieAuth() *CookieAuth {
 		default:
 		}
 	}
+	auth.RefreshToken = resp.RefreshToken
 	return auth
 }
 
 // WebQRCodePool 网页版轮询二维码返回结果
 func (c *CommClient) WebQRCodePool(qrcodeKey string) (*WebQRCodePoolResp, error) {
-	resp, err := c.RawParse(
+	return c.WebQRCodePoolWithContext(context.Background(), qrcodeKey)
+}
+
+// WebQRCodePoolWithContext 同 WebQRCodePool，ctx 用于取消请求或设置超时
+func (c *CommClient) WebQRCodePoolWithContext(ctx context.Context, qrcodeKey string) (*WebQRCodePoolResp, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliPassportURL,
 		"x/passport-login/web/qrcode/poll",
 		"GET",
@@ -1556,16 +2106,20 @@ type QRCodeGetLoginURLResp struct {
 	OauthKey string `json:"oauthKey"`
 }
 
-func (resp *QRCodeGetLoginURLResp) ToQRCode() {
-	err := qrcode.WriteFile(resp.Url, qrcode.Medium, 256, "qr.png")
-	if err != nil {
-		panic(err)
-	}
+// ToQRCode 将二维码以256*256的PNG图片写入w
+func (resp *QRCodeGetLoginURLResp) ToQRCode(w io.Writer) error {
+	return QRCodePNG(w, resp.Url, 256)
 }
 
 // QRCodeGetLoginURL 网页版获取二维码链接
 func (c *CommClient) QRCodeGetLoginURL() (*QRCodeGetLoginURLResp, error) {
-	resp, err := c.RawParse(
+	return c.QRCodeGetLoginURLWithContext(context.Background())
+}
+
+// QRCodeGetLoginURLWithContext 同 QRCodeGetLoginURL，ctx 用于取消请求或设置超时
+func (c *CommClient) QRCodeGetLoginURLWithContext(ctx context.Context) (*QRCodeGetLoginURLResp, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliPassportURL,
 		"qrcode/getLoginUrl",
 		"GET",
@@ -1609,12 +2163,19 @@ func (resp *QRCodeGetLoginInfoResp) GetCookieAuth() *CookieAuth {
 		default:
 		}
 	}
+	auth.RefreshToken = resp.RefreshToken
 	return auth
 }
 
 // QRCodeGetLoginInfo 客户端获取二维码结果
 func (c *CommClient) QRCodeGetLoginInfo(oauthKey string) (*QRCodeGetLoginInfoResp, error) {
-	resp, err := c.RawParse(
+	return c.QRCodeGetLoginInfoWithContext(context.Background(), oauthKey)
+}
+
+// QRCodeGetLoginInfoWithContext 同 QRCodeGetLoginInfo，ctx 用于取消请求或设置超时
+func (c *CommClient) QRCodeGetLoginInfoWithContext(ctx context.Context, oauthKey string) (*QRCodeGetLoginInfoResp, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliPassportURL,
 		"qrcode/getLoginInfo",
 		"POST",
@@ -1662,7 +2223,13 @@ type GetPopularAnchorRankResp struct {
 }
 
 func (c *CommClient) GetPopularAnchorRank() (*GetPopularAnchorRankResp, error) {
-	resp, err := c.RawParse(
+	return c.GetPopularAnchorRankWithContext(context.Background())
+}
+
+// GetPopularAnchorRankWithContext 同 GetPopularAnchorRank，ctx 用于取消请求或设置超时
+func (c *CommClient) GetPopularAnchorRankWithContext(ctx context.Context) (*GetPopularAnchorRankResp, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/general-interface/v1/rank/getPopularAnchorRank",
 		"GET",
@@ -1727,7 +2294,13 @@ type GetAreaRankInfoResp struct {
 }
 
 func (c *CommClient) GetAreaRankInfo(ruid, confID string) (*GetAreaRankInfoResp, error) {
-	resp, err := c.RawParse(
+	return c.GetAreaRankInfoWithContext(context.Background(), ruid, confID)
+}
+
+// GetAreaRankInfoWithContext 同 GetAreaRankInfo，ctx 用于取消请求或设置超时
+func (c *CommClient) GetAreaRankInfoWithContext(ctx context.Context, ruid, confID string) (*GetAreaRankInfoResp, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/general-interface/v1/rank/getAreaRankInfo",
 		"GET",
@@ -2176,7 +2749,13 @@ type GetInfoByRoomResp struct {
 }
 
 func (c *CommClient) GetInfoByRoom(roomID int64) (*GetInfoByRoomResp, error) {
-	resp, err := c.RawParse(
+	return c.GetInfoByRoomWithContext(context.Background(), roomID)
+}
+
+// GetInfoByRoomWithContext 同 GetInfoByRoom，ctx 用于取消请求或设置超时
+func (c *CommClient) GetInfoByRoomWithContext(ctx context.Context, roomID int64) (*GetInfoByRoomResp, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/web-room/v1/index/getInfoByRoom",
 		"GET",
@@ -2233,7 +2812,13 @@ type GetOnlineGoldRankResp struct {
 }
 
 func (c *CommClient) GetOnlineGoldRank(rUID, roomID, page, pageSize int64) (*GetOnlineGoldRankResp, error) {
-	resp, err := c.RawParse(
+	return c.GetOnlineGoldRankWithContext(context.Background(), rUID, roomID, page, pageSize)
+}
+
+// GetOnlineGoldRankWithContext 同 GetOnlineGoldRank，ctx 用于取消请求或设置超时
+func (c *CommClient) GetOnlineGoldRankWithContext(ctx context.Context, rUID, roomID, page, pageSize int64) (*GetOnlineGoldRankResp, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/general-interface/v1/rank/getOnlineGoldRank",
 		"GET",
@@ -2289,17 +2874,42 @@ type GetUserExResp struct {
 }
 
 func (c *CommClient) GetUserEx(uid int64) (*GetUserExResp, error) {
-	resp, err := http.Get("https://api.vc.bilibili.com/user_ex/v1/user/detail?uid=" + fmt.Sprint(uid) + "&user[]=role&user[]=level&room[]=live_status&room[]=room_link&feed[]=fans_count&feed[]=feed_count&feed[]=is_followed&feed[]=is_following&platform=pc")
+	return c.GetUserExWithContext(context.Background(), uid)
+}
+
+// GetUserExWithContext 同 GetUserEx，ctx 用于取消请求或设置超时
+func (c *CommClient) GetUserExWithContext(ctx context.Context, uid int64) (*GetUserExResp, error) {
+	endpoint := "user_ex/v1/user/detail"
+	raw, err := c.raw(
+		ctx,
+		BiliVcURL,
+		endpoint,
+		"GET",
+		map[string]string{
+			"uid":      strconv.FormatInt(uid, 10),
+			"platform": "pc",
+		},
+		func(d *url.Values) {
+			for k, fields := range map[string][]string{
+				"user[]": {"role", "level"},
+				"room[]": {"live_status", "room_link"},
+				"feed[]": {"fans_count", "feed_count", "is_followed", "is_following"},
+			} {
+				for _, f := range fields {
+					d.Add(k, f)
+				}
+			}
+		},
+		nil,
+	)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	bytes, err := io.ReadAll(resp.Body)
-	if err != nil {
+	if _, err = c.parse(endpoint, raw); err != nil {
 		return nil, err
 	}
 	r := &GetUserExResp{}
-	if err = json.Unmarshal(bytes, r); err != nil {
+	if err = json.Unmarshal(raw, r); err != nil {
 		return nil, err
 	}
 	return r, nil
@@ -2355,7 +2965,13 @@ type QueryAppDetailRsp struct {
 }
 
 func (c *CommClient) QueryAppDetail(app_id int64) (*QueryAppDetailRsp, error) {
-	resp, err := c.RawParse(
+	return c.QueryAppDetailWithContext(context.Background(), app_id)
+}
+
+// QueryAppDetailWithContext 同 QueryAppDetail，ctx 用于取消请求或设置超时
+func (c *CommClient) QueryAppDetailWithContext(ctx context.Context, app_id int64) (*QueryAppDetailRsp, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
 		BiliLiveURL,
 		"xlive/virtual-interface/v2/app/queryAppDetail",
 		"GET",
@@ -2372,3 +2988,285 @@ func (c *CommClient) QueryAppDetail(app_id int64) (*QueryAppDetailRsp, error) {
 	}
 	return r, nil
 }
+
+// 分类搜索的类型
+const (
+	SearchTypeVideo    = "video"         // 视频
+	SearchTypeBangumi  = "media_bangumi" // 番剧
+	SearchTypeFT       = "media_ft"      // 影视
+	SearchTypeUser     = "bili_user"     // 用户
+	SearchTypeLiveRoom = "live_room"     // 直播间
+	SearchTypeArticle  = "article"       // 专栏
+)
+
+// SearchAll 综合搜索，返回各类型的第一页结果
+//
+// 需要携带buvid3等Cookie，否则可能返回-412
+//
+// Link: https://github.com/SocialSisterYi/bilibili-API-collect/blob/master/docs/search/search_request.md
+func (c *CommClient) SearchAll(keyword string) (*SearchAll, error) {
+	return c.SearchAllWithContext(context.Background(), keyword)
+}
+
+// SearchAllWithContext 同 SearchAll，ctx 用于取消请求或设置超时
+func (c *CommClient) SearchAllWithContext(ctx context.Context, keyword string) (*SearchAll, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
+		BiliApiURL,
+		"x/web-interface/wbi/search/all/v2",
+		"GET",
+		map[string]string{
+			"keyword": keyword,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	var r = &SearchAll{}
+	if err = json.Unmarshal(resp.Data, r); err != nil {
+		return nil, err
+	}
+	r.Result = &SearchResult{}
+	for _, g := range gjson.GetBytes(resp.Data, "result").Array() {
+		if err = r.Result.add(g.Get("result_type").String(), g.Get("data")); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// SearchByType 分类搜索
+//
+// tp: SearchTypeVideo 等常量
+//
+// order: 排序方式，空为默认排序
+// 视频与专栏: totalrank:综合排序 click:最多点击 pubdate:最新发布 dm:最多弹幕 stow:最多收藏 scores:最多评论 attention:最多喜欢(专栏)
+// 用户: 0:默认 fans:粉丝数 level:等级
+// 直播间: online:人气 live_time:最新开播
+//
+// duration: 视频时长筛选 0:全部 1:10分钟以下 2:10-30分钟 3:30-60分钟 4:60分钟以上
+//
+// tid: 视频分区筛选，0为全部分区；专栏时为专栏分区
+//
+// page: 页码，最多50页
+func (c *CommClient) SearchByType(keyword string, tp string, order string, duration int, tid int, page int) (*SearchTypeResult, error) {
+	return c.SearchByTypeWithContext(context.Background(), keyword, tp, order, duration, tid, page)
+}
+
+// SearchByTypeWithContext 同 SearchByType，ctx 用于取消请求或设置超时
+func (c *CommClient) SearchByTypeWithContext(ctx context.Context, keyword string, tp string, order string, duration int, tid int, page int) (*SearchTypeResult, error) {
+	payload := map[string]string{
+		"keyword":     keyword,
+		"search_type": tp,
+		"page":        strconv.Itoa(page),
+	}
+	if order != "" {
+		payload["order"] = order
+	}
+	if duration != 0 {
+		payload["duration"] = strconv.Itoa(duration)
+	}
+	if tid != 0 {
+		if tp == SearchTypeArticle {
+			payload["category_id"] = strconv.Itoa(tid)
+		} else {
+			payload["tids"] = strconv.Itoa(tid)
+		}
+	}
+	resp, err := c.RawParseWithContext(
+		ctx,
+		BiliApiURL,
+		"x/web-interface/wbi/search/type",
+		"GET",
+		payload,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var r = &SearchTypeResult{}
+	if err = json.Unmarshal(resp.Data, r); err != nil {
+		return nil, err
+	}
+	r.Result = &SearchResult{ResultType: tp}
+	if err = r.Result.add(tp, gjson.GetBytes(resp.Data, "result")); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// add 按类型解析一组结果，未知类型忽略
+func (r *SearchResult) add(tp string, data gjson.Result) error {
+	if !data.IsArray() {
+		return nil
+	}
+	raw := []byte(data.Raw)
+	switch tp {
+	case SearchTypeVideo:
+		return json.Unmarshal(raw, &r.Video)
+	case SearchTypeBangumi, SearchTypeFT:
+		// 综合搜索中番剧与影视分为两组，需要追加
+		var media []*SearchResultMedia
+		if err := json.Unmarshal(raw, &media); err != nil {
+			return err
+		}
+		r.Media = append(r.Media, media...)
+	case SearchTypeUser:
+		return json.Unmarshal(raw, &r.User)
+	case SearchTypeLiveRoom:
+		return json.Unmarshal(raw, &r.LiveRoom)
+	case SearchTypeArticle:
+		return json.Unmarshal(raw, &r.Article)
+	}
+	return nil
+}
+
+// ArticleGetInfo 获取专栏文章信息
+//
+// cvid: 专栏ID，不含cv前缀
+func (c *CommClient) ArticleGetInfo(cvid int64) (*ArticleInfo, error) {
+	return c.ArticleGetInfoWithContext(context.Background(), cvid)
+}
+
+// ArticleGetInfoWithContext 同 ArticleGetInfo，ctx 用于取消请求或设置超时
+func (c *CommClient) ArticleGetInfoWithContext(ctx context.Context, cvid int64) (*ArticleInfo, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
+		BiliApiURL,
+		"x/article/viewinfo",
+		"GET",
+		map[string]string{
+			"id": strconv.FormatInt(cvid, 10),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	var r = &ArticleInfo{}
+	if err = json.Unmarshal(resp.Data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ArticleGetContent 获取专栏正文，Blocks 为解析后的段落、图片、卡片等
+//
+// cvid: 专栏ID，不含cv前缀
+func (c *CommClient) ArticleGetContent(cvid int64) (*ArticleContent, error) {
+	return c.ArticleGetContentWithContext(context.Background(), cvid)
+}
+
+// ArticleGetContentWithContext 同 ArticleGetContent，ctx 用于取消请求或设置超时
+func (c *CommClient) ArticleGetContentWithContext(ctx context.Context, cvid int64) (*ArticleContent, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
+		BiliApiURL,
+		"x/article/view",
+		"GET",
+		map[string]string{
+			"id": strconv.FormatInt(cvid, 10),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	var r = &ArticleContent{}
+	if err = json.Unmarshal(resp.Data, r); err != nil {
+		return nil, err
+	}
+	if r.Blocks, err = ParseArticleHTML(r.HTML); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SpaceGetArticles 获取用户投稿的专栏
+//
+// sort: 排序方式 publish_time:最新发布 view:最多阅读 fav:最多收藏
+//
+// pn: 页码
+//
+// ps: 每页项数 最大30
+func (c *CommClient) SpaceGetArticles(mid int64, sort string, pn int, ps int) (*SpaceArticles, error) {
+	return c.SpaceGetArticlesWithContext(context.Background(), mid, sort, pn, ps)
+}
+
+// SpaceGetArticlesWithContext 同 SpaceGetArticles，ctx 用于取消请求或设置超时
+func (c *CommClient) SpaceGetArticlesWithContext(ctx context.Context, mid int64, sort string, pn int, ps int) (*SpaceArticles, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
+		BiliApiURL,
+		"x/space/wbi/article",
+		"GET",
+		map[string]string{
+			"mid":  strconv.FormatInt(mid, 10),
+			"sort": sort,
+			"pn":   strconv.Itoa(pn),
+			"ps":   strconv.Itoa(ps),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	var r = &SpaceArticles{}
+	if err = json.Unmarshal(resp.Data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ArticleGetReadLists 获取用户的文集
+//
+// sort: 0:最近更新 1:最多阅读
+func (c *CommClient) ArticleGetReadLists(mid int64, sort int) ([]*ArticleReadList, error) {
+	return c.ArticleGetReadListsWithContext(context.Background(), mid, sort)
+}
+
+// ArticleGetReadListsWithContext 同 ArticleGetReadLists，ctx 用于取消请求或设置超时
+func (c *CommClient) ArticleGetReadListsWithContext(ctx context.Context, mid int64, sort int) ([]*ArticleReadList, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
+		BiliApiURL,
+		"x/article/up/lists",
+		"GET",
+		map[string]string{
+			"mid":  strconv.FormatInt(mid, 10),
+			"sort": strconv.Itoa(sort),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	var r struct {
+		Lists []*ArticleReadList `json:"lists"`
+	}
+	if err = json.Unmarshal(resp.Data, &r); err != nil {
+		return nil, err
+	}
+	return r.Lists, nil
+}
+
+// ArticleGetReadList 获取文集信息及其中的全部文章
+func (c *CommClient) ArticleGetReadList(id int64) (*ArticleReadListDetail, error) {
+	return c.ArticleGetReadListWithContext(context.Background(), id)
+}
+
+// ArticleGetReadListWithContext 同 ArticleGetReadList，ctx 用于取消请求或设置超时
+func (c *CommClient) ArticleGetReadListWithContext(ctx context.Context, id int64) (*ArticleReadListDetail, error) {
+	resp, err := c.RawParseWithContext(
+		ctx,
+		BiliApiURL,
+		"x/article/list/web/articles",
+		"GET",
+		map[string]string{
+			"id": strconv.FormatInt(id, 10),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	var r = &ArticleReadListDetail{}
+	if err = json.Unmarshal(resp.Data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
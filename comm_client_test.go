@@ -1,7 +1,11 @@
 package biligo
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 )
@@ -702,8 +706,11 @@ func TestLogin(t *testing.T) {
 		t.Error(err)
 		t.FailNow()
 	}
-	gResp.ToQRCode()
-	t.Logf("url: %s", gResp.Url)
+	qr, err := QRCodeString(gResp.Url, QRCodeRenderHalfBlock, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("url: %s\n%s", gResp.Url, qr)
 	for i := 0; i < 60; i++ {
 		iResp, err := testCommClient.QRCodeGetLoginInfo(gResp.OauthKey)
 		if err != nil {
@@ -793,3 +800,22 @@ func TestBiliClient_QueryAppDetail(t *testing.T) {
 	}
 	t.Log(gResp)
 }
+
+func TestCommClient_RawWithContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(3 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	c := NewCommClient(&CommSetting{})
+	_, err := c.RawParseWithContext(ctx, srv.URL+"/", "x/web-interface/zone", "GET", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error(err)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,295 @@
+package biligo
+
+import (
+	"context"
+)
+
+// CommentCursor 评论区爬取进度
+//
+// 可以序列化后保存，通过 CommentCrawlOption.Cursor 从中断处继续
+type CommentCursor struct {
+	OID  int64 `json:"oid"`  // 评论区对象id
+	Type int   `json:"type"` // 评论区类型
+	Mode int   `json:"mode"` // 排序方式
+	// Next 当前一级评论页的next参数
+	Next int `json:"next"`
+	// Root 当前页中最后一个已输出的一级评论rpid
+	//
+	// 0：当前页还未输出
+	Root int64 `json:"root"`
+	// ReplyPage Root的二级评论下一个要请求的页码
+	//
+	// 0：Root的二级评论已全部输出
+	ReplyPage int `json:"reply_page"`
+	// ReplyOffset ReplyPage中已输出的二级评论数
+	ReplyOffset int `json:"reply_offset"`
+	// PrevRoots 上一页的一级评论rpid
+	//
+	// 翻页时评论区有变动会导致相邻两页出现重复，继续时用于去重
+	PrevRoots []int64 `json:"prev_roots,omitempty"`
+	// Done 是否已爬取完毕
+	Done bool `json:"done"`
+}
+
+// CommentCrawlOption 评论区爬取设置
+type CommentCrawlOption struct {
+	// Mode 排序方式，同 CommentGetMain
+	//
+	// 0 视为2，即按时间排序，爬取过程中有新评论时不会错位
+	Mode int
+	// PageSize 一级评论每页项数 默认20
+	PageSize int
+	// ReplyPageSize 二级评论每页项数 默认20
+	ReplyPageSize int
+	// Cursor 从保存的进度继续，为nil时从头开始
+	Cursor *CommentCursor
+}
+
+// CommentThreadCrawler 评论区爬取器
+//
+// 按顺序输出每条一级评论及其全部二级评论，同一rpid只输出一次
+//
+// 非并发安全
+type CommentThreadCrawler struct {
+	c       *CommClient
+	ctx     context.Context
+	ps      int
+	replyPs int
+	cursor  CommentCursor
+
+	roots      []*Comment // 当前页的一级评论
+	rootIdx    int        // 下一个要输出的一级评论
+	next       int        // 下一页的next参数
+	last       bool       // 当前页是否为最后一页
+	replies    []*Comment // 当前二级评论页中未输出的评论
+	loaded     bool       // cursor.ReplyPage是否已请求
+	replyPages int        // Root的二级评论总页数 0:未知
+	seen       map[int64]struct{}
+
+	cur  *Comment
+	err  error
+	done bool
+}
+
+// CommentCrawl 爬取整个评论区
+//
+// oid: 对应类型的ID
+//
+// tp: 类型。https://github.com/SocialSisterYi/bilibili-API-collect/tree/master/comment#%E8%AF%84%E8%AE%BA%E5%8C%BA%E7%B1%BB%E5%9E%8B%E4%BB%A3%E7%A0%81
+//
+// opt可为nil
+func (c *CommClient) CommentCrawl(oid int64, tp int, opt *CommentCrawlOption) *CommentThreadCrawler {
+	return c.CommentCrawlWithContext(context.Background(), oid, tp, opt)
+}
+
+// CommentCrawlWithContext 同 CommentCrawl，ctx 用于取消爬取
+func (c *CommClient) CommentCrawlWithContext(ctx context.Context, oid int64, tp int, opt *CommentCrawlOption) *CommentThreadCrawler {
+	o := CommentCrawlOption{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.Mode == 0 {
+		o.Mode = 2
+	}
+	if o.PageSize <= 0 {
+		o.PageSize = 20
+	}
+	if o.ReplyPageSize <= 0 {
+		o.ReplyPageSize = 20
+	}
+
+	cr := &CommentThreadCrawler{
+		c:       c,
+		ctx:     ctx,
+		ps:      o.PageSize,
+		replyPs: o.ReplyPageSize,
+		cursor:  CommentCursor{OID: oid, Type: tp, Mode: o.Mode},
+		seen:    make(map[int64]struct{}),
+	}
+	if o.Cursor != nil {
+		cr.cursor = *o.Cursor
+		cr.cursor.OID, cr.cursor.Type = oid, tp
+		cr.cursor.PrevRoots = append([]int64(nil), o.Cursor.PrevRoots...)
+		for _, id := range cr.cursor.PrevRoots {
+			cr.seen[id] = struct{}{}
+		}
+	}
+	return cr
+}
+
+// Next 移动到下一条评论，爬取完毕或出错时返回false，之后应检查 Err
+func (cr *CommentThreadCrawler) Next() bool {
+	if cr.done {
+		return false
+	}
+	for {
+		if err := cr.ctx.Err(); err != nil {
+			return cr.fail(err)
+		}
+
+		// 当前二级评论页
+		if len(cr.replies) > 0 {
+			r := cr.replies[0]
+			cr.replies = cr.replies[1:]
+			cr.cursor.ReplyOffset++
+			if cr.emit(r) {
+				return true
+			}
+			continue
+		}
+
+		// 当前一级评论的下一页二级评论
+		if cr.cursor.Root != 0 && cr.cursor.ReplyPage > 0 {
+			if cr.loaded {
+				cr.loaded = false
+				cr.cursor.ReplyPage++
+				cr.cursor.ReplyOffset = 0
+				if cr.replyPages > 0 && cr.cursor.ReplyPage > cr.replyPages {
+					cr.cursor.ReplyPage = 0
+				}
+				continue
+			}
+			if err := cr.loadReplies(); err != nil {
+				return cr.fail(err)
+			}
+			continue
+		}
+
+		// 当前页的下一条一级评论
+		if cr.roots == nil {
+			if cr.cursor.Done {
+				cr.done = true
+				return false
+			}
+			if err := cr.loadMain(); err != nil {
+				return cr.fail(err)
+			}
+			continue
+		}
+		if cr.rootIdx < len(cr.roots) {
+			r := cr.roots[cr.rootIdx]
+			cr.rootIdx++
+			cr.cursor.Root = r.RPID
+			cr.cursor.ReplyPage, cr.cursor.ReplyOffset = 0, 0
+			cr.loaded, cr.replyPages = false, 0
+			if cr.emit(r) {
+				if r.Rcount > 0 || r.Count > 0 {
+					cr.cursor.ReplyPage = 1
+				}
+				return true
+			}
+			continue
+		}
+
+		// 当前页已输出完毕
+		cr.cursor.PrevRoots = make([]int64, 0, len(cr.roots))
+		for _, r := range cr.roots {
+			cr.cursor.PrevRoots = append(cr.cursor.PrevRoots, r.RPID)
+		}
+		cr.roots = nil
+		cr.cursor.Root = 0
+		if cr.last {
+			cr.cursor.Done = true
+		} else {
+			cr.cursor.Next = cr.next
+		}
+	}
+}
+
+// loadMain 请求cursor.Next对应的一级评论页
+func (cr *CommentThreadCrawler) loadMain() error {
+	page, err := cr.c.CommentGetMainWithContext(cr.ctx, cr.cursor.OID, cr.cursor.Type, cr.cursor.Mode, cr.cursor.Next, cr.ps)
+	if err != nil {
+		return err
+	}
+
+	roots := make([]*Comment, 0, len(page.Replies)+3)
+	// 置顶评论只在第一页返回
+	if page.Cursor.IsBegin {
+		for _, t := range []*Comment{page.Top.Upper, page.Top.Admin, page.Top.Vote} {
+			if t != nil {
+				roots = append(roots, t)
+			}
+		}
+	}
+	for _, r := range page.Replies {
+		if r != nil {
+			roots = append(roots, r)
+		}
+	}
+
+	// 从保存的进度继续时跳过已输出的一级评论
+	cr.rootIdx = 0
+	if cr.cursor.Root != 0 {
+		for i, r := range roots {
+			if r.RPID == cr.cursor.Root {
+				cr.rootIdx = i + 1
+				break
+			}
+		}
+		for _, r := range roots[:cr.rootIdx] {
+			cr.seen[r.RPID] = struct{}{}
+		}
+	}
+	cr.roots = roots
+
+	// 当前页输出完毕后才更新cursor.Next，否则中断后会丢失当前页
+	// next不变时视为结束，防止死循环
+	cr.next = page.Cursor.Next
+	cr.last = page.Cursor.IsEnd || len(page.Replies) == 0 || page.Cursor.Next == cr.cursor.Next
+	return nil
+}
+
+// loadReplies 请求cursor.ReplyPage对应的二级评论页
+func (cr *CommentThreadCrawler) loadReplies() error {
+	r, err := cr.c.CommentGetReplyWithContext(cr.ctx, cr.cursor.OID, cr.cursor.Type, cr.cursor.Root, cr.cursor.ReplyPage, cr.replyPs)
+	if err != nil {
+		return err
+	}
+	if len(r.Replies) == 0 {
+		cr.cursor.ReplyPage, cr.cursor.ReplyOffset = 0, 0
+		return nil
+	}
+	cr.replyPages = pageCount(r.Page.Count, cr.replyPs)
+	cr.loaded = true
+	if cr.cursor.ReplyOffset < len(r.Replies) {
+		cr.replies = r.Replies[cr.cursor.ReplyOffset:]
+	}
+	return nil
+}
+
+func (cr *CommentThreadCrawler) emit(r *Comment) bool {
+	if r == nil {
+		return false
+	}
+	if _, ok := cr.seen[r.RPID]; ok {
+		return false
+	}
+	cr.seen[r.RPID] = struct{}{}
+	cr.cur = r
+	return true
+}
+
+func (cr *CommentThreadCrawler) fail(err error) bool {
+	cr.done, cr.err = true, err
+	return false
+}
+
+// Item 当前评论，Next 返回true后有效
+//
+// 一级评论的Root为0，二级评论的Root为所属一级评论rpid
+func (cr *CommentThreadCrawler) Item() *Comment {
+	return cr.cur
+}
+
+// Err 爬取过程中的错误
+func (cr *CommentThreadCrawler) Err() error {
+	return cr.err
+}
+
+// Cursor 当前进度，从该进度继续时会从当前评论的下一条开始
+func (cr *CommentThreadCrawler) Cursor() *CommentCursor {
+	c := cr.cursor
+	c.PrevRoots = append([]int64(nil), c.PrevRoots...)
+	return &c
+}
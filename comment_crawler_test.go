@@ -0,0 +1,93 @@
+package biligo
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/iyear/biligo/biligotest"
+)
+
+// handleCommentThread 模拟两页一级评论，第二页中有一条与第一页重复
+func handleCommentThread(srv *biligotest.Server) {
+	root := func(rpid int64, rcount int) map[string]interface{} {
+		return map[string]interface{}{"rpid": rpid, "rcount": rcount, "count": rcount}
+	}
+	srv.HandleFunc(biligotest.BiliApiURL, "x/v2/reply/main", func(w http.ResponseWriter, r *http.Request) {
+		var data map[string]interface{}
+		switch r.URL.Query().Get("next") {
+		case "0":
+			data = map[string]interface{}{
+				"cursor":  map[string]interface{}{"is_begin": true, "next": 5},
+				"replies": []interface{}{root(1, 3), root(2, 0)},
+				"top":     map[string]interface{}{"upper": root(9, 0)},
+			}
+		case "5":
+			data = map[string]interface{}{
+				"cursor":  map[string]interface{}{"next": 3, "is_end": true},
+				"replies": []interface{}{root(2, 0), root(3, 1)},
+			}
+		default:
+			biligotest.WriteJSON(w, -400, "请求错误", nil)
+			return
+		}
+		biligotest.WriteJSON(w, 0, "0", data)
+	})
+	srv.HandleFunc(biligotest.BiliApiURL, "x/v2/reply/reply", func(w http.ResponseWriter, r *http.Request) {
+		rt, _ := strconv.ParseInt(r.URL.Query().Get("root"), 10, 64)
+		pn, _ := strconv.Atoi(r.URL.Query().Get("pn"))
+		all := map[int64][]int64{1: {11, 12, 13}, 3: {31}}[rt]
+		var replies []map[string]interface{}
+		for i := (pn - 1) * 2; i < pn*2 && i < len(all); i++ {
+			replies = append(replies, map[string]interface{}{"rpid": all[i], "root": rt})
+		}
+		biligotest.WriteJSON(w, 0, "0", map[string]interface{}{
+			"page":    map[string]interface{}{"count": len(all), "num": pn, "size": 2},
+			"replies": replies,
+		})
+	})
+}
+func TestCommClient_CommentCrawl(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+	handleCommentThread(srv)
+
+	crawl := func(opt *CommentCrawlOption, limit int) ([]int64, *CommentCursor) {
+		cr := c.CommentCrawl(170001, 1, opt)
+		var ids []int64
+		for (limit < 0 || len(ids) < limit) && cr.Next() {
+			ids = append(ids, cr.Item().RPID)
+		}
+		if err := cr.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return ids, cr.Cursor()
+	}
+
+	all, cursor := crawl(&CommentCrawlOption{ReplyPageSize: 2}, -1)
+	want := []int64{9, 1, 11, 12, 13, 2, 3, 31}
+	if !reflect.DeepEqual(all, want) {
+		t.Fatalf("%v", all)
+	}
+	if !cursor.Done {
+		t.Errorf("%+v", cursor)
+	}
+
+	// 在每个位置中断后继续，结果应与完整爬取一致
+	for i := 1; i < len(want); i++ {
+		head, cursor := crawl(&CommentCrawlOption{ReplyPageSize: 2}, i)
+		raw, err := json.Marshal(cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var saved CommentCursor
+		if err = json.Unmarshal(raw, &saved); err != nil {
+			t.Fatal(err)
+		}
+		tail, _ := crawl(&CommentCrawlOption{ReplyPageSize: 2, Cursor: &saved}, -1)
+		if got := append(head, tail...); !reflect.DeepEqual(got, want) {
+			t.Errorf("resume at %d (%+v): %v", i, saved, got)
+		}
+	}
+}
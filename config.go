@@ -7,6 +7,7 @@ const (
 	BiliElecURL     = "https://elec.bilibili.com/"
 	BiliLiveURL     = "https://api.live.bilibili.com/"
 	BiliVcURL       = "https://api.vc.bilibili.com/"
+	BiliMemberURL   = "https://member.bilibili.com/"
 )
 
 var userAgent = []string{
@@ -0,0 +1,247 @@
+package biligo
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// correspondKey 生成correspondPath的公钥
+const correspondKey = `-----BEGIN PUBLIC KEY-----
+MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQDLgd2OAkcGVtoE3ThUREbio0Eg
+Uc/prcajMKXvkCKFCWhJYJcLkcM2DKKcSeFpD/j6Boy538YXnR6VhcuUJOhH2x71
+nzPjfdTcqMz7djHum0qSZA0AyCBDABUqCrfNgCiJ00Ra7GmRj+YCK1NJEuewlb40
+JNrRuoEUXpabUzGB8QIDAQAB
+-----END PUBLIC KEY-----`
+
+// ErrNoRefreshToken CookieAuth 中没有 RefreshToken，无法刷新Cookie
+var ErrNoRefreshToken = errors.New("refresh token is empty")
+
+var refreshCsrfRe = regexp.MustCompile(`<div id="1-name">([^<]+)</div>`)
+
+// CookieRefreshInfo Cookie刷新检查结果
+type CookieRefreshInfo struct {
+	Refresh   bool  `json:"refresh"`   // 是否需要刷新
+	Timestamp int64 `json:"timestamp"` // 当前毫秒时间戳，用于生成correspondPath
+}
+
+// CookieCheckRefresh 检查Cookie是否需要刷新
+//
+// Link: https://github.com/SocialSisterYi/bilibili-API-collect/blob/master/docs/login/cookie_refresh.md
+func (b *BiliClient) CookieCheckRefresh() (*CookieRefreshInfo, error) {
+	return b.CookieCheckRefreshWithContext(context.Background())
+}
+
+// CookieCheckRefreshWithContext 同 CookieCheckRefresh，ctx 用于取消请求或设置超时
+func (b *BiliClient) CookieCheckRefreshWithContext(ctx context.Context) (*CookieRefreshInfo, error) {
+	resp, err := b.RawParseWithContext(
+		ctx,
+		BiliPassportURL,
+		"x/passport-login/web/cookie/info",
+		"GET",
+		map[string]string{
+			"csrf": b.GetCookieAuth().BiliJCT,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	var r = &CookieRefreshInfo{}
+	if err = json.Unmarshal(resp.Data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// CookieRefreshIfNeeded 检查Cookie，需要时刷新，返回是否进行了刷新
+func (b *BiliClient) CookieRefreshIfNeeded() (bool, error) {
+	return b.CookieRefreshIfNeededWithContext(context.Background())
+}
+
+// CookieRefreshIfNeededWithContext 同 CookieRefreshIfNeeded，ctx 用于取消请求或设置超时
+func (b *BiliClient) CookieRefreshIfNeededWithContext(ctx context.Context) (bool, error) {
+	auth := b.GetCookieAuth()
+	info, err := b.CookieCheckRefreshWithContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !info.Refresh {
+		return false, nil
+	}
+	// 确认或保存失败时Cookie已经刷新
+	refreshed, err := b.cookieRefresh(ctx, auth, info.Timestamp)
+	return refreshed != nil, err
+}
+
+// CookieRefresh 刷新Cookie，需要 CookieAuth.RefreshToken
+//
+// 依次获取refresh_csrf、刷新Cookie并确认，刷新成功后原Cookie失效，
+// 新的 CookieAuth 替换当前登录信息，保存到 BiliSetting.Store 并调用 BiliSetting.OnAuthRefresh
+//
+// 确认或保存失败时同时返回新的 CookieAuth 与错误
+//
+// 并发调用时只会刷新一次
+func (b *BiliClient) CookieRefresh() (*CookieAuth, error) {
+	return b.CookieRefreshWithContext(context.Background())
+}
+
+// CookieRefreshWithContext 同 CookieRefresh，ctx 用于取消请求或设置超时
+func (b *BiliClient) CookieRefreshWithContext(ctx context.Context) (*CookieAuth, error) {
+	auth := b.GetCookieAuth()
+	info, err := b.CookieCheckRefreshWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.cookieRefresh(ctx, auth, info.Timestamp)
+}
+
+// cookieRefresh old为发起刷新时的登录信息，等待锁期间已被其他调用刷新时直接返回
+func (b *BiliClient) cookieRefresh(ctx context.Context, old *CookieAuth, ts int64) (*CookieAuth, error) {
+	b.refreshMu.Lock()
+	defer b.refreshMu.Unlock()
+	if cur := b.GetCookieAuth(); cur != old {
+		return cur, nil
+	}
+	if old.RefreshToken == "" {
+		return nil, ErrNoRefreshToken
+	}
+
+	path, err := correspondPath(ts)
+	if err != nil {
+		return nil, err
+	}
+	refreshCsrf, err := b.refreshCsrf(ctx, old, path)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("csrf", old.BiliJCT)
+	form.Set("refresh_csrf", refreshCsrf)
+	form.Set("source", "main_web")
+	form.Set("refresh_token", old.RefreshToken)
+	endpoint := "x/passport-login/web/cookie/refresh"
+	resp, err := b.authPost(ctx, endpoint, old, form)
+	if err != nil {
+		return nil, err
+	}
+	r, err := b.parse(endpoint, resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var data struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err = json.Unmarshal(r.Data, &data); err != nil {
+		return nil, err
+	}
+
+	auth := &CookieAuth{BUVID3: old.BUVID3, RefreshToken: data.RefreshToken}
+	for _, c := range (&http.Response{Header: resp.Header}).Cookies() {
+		switch c.Name {
+		case "DedeUserID":
+			auth.DedeUserID = c.Value
+		case "DedeUserID__ckMd5":
+			auth.DedeUserIDCkMd5 = c.Value
+		case "SESSDATA":
+			auth.SESSDATA = c.Value
+		case "bili_jct":
+			auth.BiliJCT = c.Value
+		}
+	}
+	if auth.SESSDATA == "" || auth.BiliJCT == "" || auth.RefreshToken == "" {
+		return nil, errors.New("cookie refresh: missing new cookies in response")
+	}
+	if auth.DedeUserID == "" {
+		auth.DedeUserID, auth.DedeUserIDCkMd5 = old.DedeUserID, old.DedeUserIDCkMd5
+	}
+
+	// 刷新后旧Cookie已失效，先替换并保存新的登录信息，确认失败时也不会丢失
+	b.auth.Store(auth)
+	var saveErr error
+	if b.store != nil {
+		if saveErr = b.store.Save(b.storeKey, auth); saveErr != nil {
+			saveErr = fmt.Errorf("cookie refreshed but failed to save: %w", saveErr)
+		}
+	}
+	if b.onAuth != nil {
+		b.onAuth(auth)
+	}
+
+	// 使用新Cookie确认刷新，使旧的refresh_token失效
+	form = url.Values{}
+	form.Set("csrf", auth.BiliJCT)
+	form.Set("refresh_token", old.RefreshToken)
+	endpoint = "x/passport-login/web/confirm/refresh"
+	if resp, err = b.authPost(ctx, endpoint, auth, form); err == nil {
+		_, err = b.parse(endpoint, resp.Body)
+	}
+	if err != nil {
+		return auth, fmt.Errorf("cookie refreshed but failed to confirm: %w", err)
+	}
+	return auth, saveErr
+}
+
+// correspondPath 使用RSA-OAEP加密 refresh_{ts}
+func correspondPath(ts int64) (string, error) {
+	block, _ := pem.Decode([]byte(correspondKey))
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+	enc, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub.(*rsa.PublicKey), []byte("refresh_"+strconv.FormatInt(ts, 10)), nil)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(enc), nil
+}
+
+// refreshCsrf 从correspond页面中获取refresh_csrf
+func (b *BiliClient) refreshCsrf(ctx context.Context, auth *CookieAuth, path string) (string, error) {
+	endpoint := "correspond/1/" + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.resolve(BiliMainURL)+endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("User-Agent", b.ua)
+	req.Header.Add("Cookie", auth.Cookie())
+	resp, err := b.roundTrip(BiliMainURL, endpoint, req, nil)
+	if err != nil {
+		return "", err
+	}
+	m := refreshCsrfRe.FindSubmatch(resp.Body)
+	if m == nil {
+		return "", fmt.Errorf("cookie refresh: refresh_csrf not found, status %d", resp.StatusCode)
+	}
+	return strings.TrimSpace(string(m[1])), nil
+}
+
+// authPost 以指定的登录信息发出POST请求，返回带有Set-Cookie的完整响应
+func (b *BiliClient) authPost(ctx context.Context, endpoint string, auth *CookieAuth, form url.Values) (*RawResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.resolve(BiliPassportURL)+endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Origin", "https://www.bilibili.com")
+	req.Header.Add("Referer", "https://www.bilibili.com")
+	req.Header.Add("Content-type", "application/x-www-form-urlencoded")
+	req.Header.Add("User-Agent", b.ua)
+	req.Header.Add("Cookie", auth.Cookie())
+	payload := make(map[string]string, len(form))
+	for k := range form {
+		payload[k] = form.Get(k)
+	}
+	return b.roundTrip(BiliPassportURL, endpoint, req, payload)
+}
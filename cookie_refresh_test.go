@@ -0,0 +1,126 @@
+package biligo
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iyear/biligo/biligotest"
+)
+
+// newFakeCookieRefresh 注册刷新流程的全部接口，setting的Auth与Endpoints会被覆盖
+func newFakeCookieRefresh(t *testing.T, setting *BiliSetting) (*BiliClient, *biligotest.Server) {
+	srv := biligotest.NewServer()
+	t.Cleanup(srv.Close)
+
+	setting.Auth = &CookieAuth{
+		DedeUserID:      "2",
+		DedeUserIDCkMd5: "2ab3b1b4a5e7c4a9",
+		SESSDATA:        "old_sessdata",
+		BiliJCT:         "old_bili_jct",
+		BUVID3:          "fake_buvid3",
+		RefreshToken:    "old_refresh_token",
+	}
+	setting.Endpoints = srv.Endpoints()
+	b, err := NewBiliClient(setting)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.HandleJSON(biligotest.BiliPassportURL, "x/passport-login/web/cookie/info", 0, "0", map[string]interface{}{
+		"refresh":   true,
+		"timestamp": 1684466082437,
+	})
+	srv.HandleFunc(biligotest.BiliMainURL, "correspond/1/", func(w http.ResponseWriter, r *http.Request) {
+		if p := strings.TrimPrefix(r.URL.Path, "/www.bilibili.com/correspond/1/"); len(p) != 256 {
+			t.Errorf("correspondPath: %s", p)
+		}
+		if !strings.Contains(r.Header.Get("Cookie"), "SESSDATA=old_sessdata") {
+			t.Errorf("cookie: %s", r.Header.Get("Cookie"))
+		}
+		_, _ = w.Write([]byte(`<html><body><div id="1-name">fake_refresh_csrf</div></body></html>`))
+	})
+	srv.HandleFunc(biligotest.BiliPassportURL, "x/passport-login/web/cookie/refresh", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.PostForm.Get("refresh_csrf") != "fake_refresh_csrf" || r.PostForm.Get("refresh_token") != "old_refresh_token" || r.PostForm.Get("csrf") != "old_bili_jct" {
+			t.Errorf("%v", r.PostForm)
+		}
+		for k, v := range map[string]string{"SESSDATA": "new_sessdata", "bili_jct": "new_bili_jct", "DedeUserID": "2", "DedeUserID__ckMd5": "2ab3b1b4a5e7c4a9"} {
+			http.SetCookie(w, &http.Cookie{Name: k, Value: v, Path: "/"})
+		}
+		biligotest.WriteJSON(w, 0, "0", map[string]interface{}{"status": 0, "refresh_token": "new_refresh_token"})
+	})
+	srv.HandleFunc(biligotest.BiliPassportURL, "x/passport-login/web/confirm/refresh", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.PostForm.Get("csrf") != "new_bili_jct" || r.PostForm.Get("refresh_token") != "old_refresh_token" || !strings.Contains(r.Header.Get("Cookie"), "SESSDATA=new_sessdata") {
+			t.Errorf("%v %s", r.PostForm, r.Header.Get("Cookie"))
+		}
+		biligotest.WriteJSON(w, 0, "0", nil)
+	})
+	return b, srv
+}
+
+func TestOffline_CookieRefresh(t *testing.T) {
+	var saved []*CookieAuth
+	b, srv := newFakeCookieRefresh(t, &BiliSetting{
+		OnAuthRefresh: func(auth *CookieAuth) { saved = append(saved, auth) },
+	})
+
+	// 并发调用只刷新一次
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := b.CookieRefreshIfNeeded(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	auth := b.GetCookieAuth()
+	if auth.SESSDATA != "new_sessdata" || auth.BiliJCT != "new_bili_jct" || auth.RefreshToken != "new_refresh_token" || auth.BUVID3 != "fake_buvid3" {
+		t.Errorf("%+v", auth)
+	}
+	if len(saved) != 1 || saved[0] != auth {
+		t.Errorf("callback: %v", saved)
+	}
+
+	// 之后的请求使用新Cookie
+	srv.HandleJSON(biligotest.BiliApiURL, "x/article/like", 0, "0", nil)
+	if err := b.ArticleLike(1, true); err != nil {
+		t.Fatal(err)
+	}
+	if r := srv.LastRequest(); r.Form.Get("csrf") != "new_bili_jct" || !strings.Contains(r.Header.Get("Cookie"), "SESSDATA=new_sessdata") {
+		t.Errorf("%v %v", r.Form, r.Header)
+	}
+
+	b.auth.Store(&CookieAuth{SESSDATA: "x"})
+	if _, err := b.CookieRefresh(); err != ErrNoRefreshToken {
+		t.Errorf("expected ErrNoRefreshToken, got %v", err)
+	}
+}
+
+func TestOffline_CookieRefresh_ConfirmFailed(t *testing.T) {
+	var saved []*CookieAuth
+	store := NewMemoryCredentialStore()
+	b, srv := newFakeCookieRefresh(t, &BiliSetting{
+		Store:         store,
+		OnAuthRefresh: func(auth *CookieAuth) { saved = append(saved, auth) },
+	})
+	srv.HandleJSON(biligotest.BiliPassportURL, "x/passport-login/web/confirm/refresh", -101, "账号未登录", nil)
+
+	// 确认失败时新Cookie已生效，不能丢弃
+	auth, err := b.CookieRefresh()
+	if !IsNotLoggedIn(err) || auth == nil || auth.SESSDATA != "new_sessdata" {
+		t.Fatalf("%+v %v", auth, err)
+	}
+	if b.GetCookieAuth() != auth || len(saved) != 1 || saved[0] != auth {
+		t.Errorf("%+v %v", b.GetCookieAuth(), saved)
+	}
+	if a, err := store.Load("2"); err != nil || a.SESSDATA != "new_sessdata" {
+		t.Errorf("%+v %v", a, err)
+	}
+}
@@ -0,0 +1,315 @@
+package biligo
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrCredentialNotFound 存储中没有该账户的登录信息
+var ErrCredentialNotFound = errors.New("credential not found")
+
+// CredentialStore 登录信息存储，account通常为DedeUserID
+//
+// 实现需要并发安全
+type CredentialStore interface {
+	// Load 读取登录信息，不存在时返回 ErrCredentialNotFound
+	Load(account string) (*CookieAuth, error)
+	// Save 保存登录信息，已存在时覆盖
+	Save(account string, auth *CookieAuth) error
+	// Delete 删除登录信息，不存在时不返回错误
+	Delete(account string) error
+}
+
+// MemoryCredentialStore 内存存储，进程退出后丢失，可用于测试或由外部负责持久化
+type MemoryCredentialStore struct {
+	mu sync.RWMutex
+	m  map[string]CookieAuth
+}
+
+// NewMemoryCredentialStore 创建内存存储
+func NewMemoryCredentialStore() *MemoryCredentialStore {
+	return &MemoryCredentialStore{m: make(map[string]CookieAuth)}
+}
+
+func (s *MemoryCredentialStore) Load(account string) (*CookieAuth, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	auth, ok := s.m[account]
+	if !ok {
+		return nil, ErrCredentialNotFound
+	}
+	return &auth, nil
+}
+
+func (s *MemoryCredentialStore) Save(account string, auth *CookieAuth) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[account] = *auth
+	return nil
+}
+
+func (s *MemoryCredentialStore) Delete(account string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.m, account)
+	return nil
+}
+
+// Accounts 全部账户
+func (s *MemoryCredentialStore) Accounts() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r := make([]string, 0, len(s.m))
+	for k := range s.m {
+		r = append(r, k)
+	}
+	return r
+}
+
+// FileCredentialStore 文件存储，全部账户以JSON保存在同一个文件中，文件权限为0600
+//
+// passphrase不为空时使用 AES-256-GCM 加密，密钥由 PBKDF2-SHA256 从passphrase派生
+type FileCredentialStore struct {
+	path       string
+	passphrase string
+	mu         sync.Mutex
+
+	// 缓存派生的密钥，同一个salt只派生一次
+	salt []byte
+	gcm  cipher.AEAD
+}
+
+// NewFileCredentialStore 创建文件存储，文件不存在时在第一次保存时创建
+//
+// passphrase为空时不加密
+func NewFileCredentialStore(path string, passphrase string) *FileCredentialStore {
+	return &FileCredentialStore{path: path, passphrase: passphrase}
+}
+
+// credentialFile 加密后的文件格式
+type credentialFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+const (
+	credentialKDFIter = 100000
+	credentialSaltLen = 16
+)
+
+func (s *FileCredentialStore) Load(account string) (*CookieAuth, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	auth, ok := m[account]
+	if !ok {
+		return nil, ErrCredentialNotFound
+	}
+	return auth, nil
+}
+
+func (s *FileCredentialStore) Save(account string, auth *CookieAuth) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.read()
+	if err != nil {
+		return err
+	}
+	c := *auth
+	m[account] = &c
+	return s.write(m)
+}
+
+func (s *FileCredentialStore) Delete(account string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := m[account]; !ok {
+		return nil
+	}
+	delete(m, account)
+	return s.write(m)
+}
+
+// Accounts 全部账户
+func (s *FileCredentialStore) Accounts() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	r := make([]string, 0, len(m))
+	for k := range m {
+		r = append(r, k)
+	}
+	return r, nil
+}
+
+func (s *FileCredentialStore) read() (map[string]*CookieAuth, error) {
+	m := make(map[string]*CookieAuth)
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.passphrase != "" {
+		var f credentialFile
+		if err = json.Unmarshal(b, &f); err != nil {
+			return nil, err
+		}
+		if f.Version != 1 || len(f.Salt) == 0 {
+			return nil, errors.New("credential file is not encrypted or has unknown version")
+		}
+		gcm, err := s.cipher(f.Salt)
+		if err != nil {
+			return nil, err
+		}
+		if b, err = gcm.Open(nil, f.Nonce, f.Data, nil); err != nil {
+			return nil, errors.New("failed to decrypt credential file: wrong passphrase or corrupted file")
+		}
+	}
+	if err = json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("parse credential file: %w", err)
+	}
+	return m, nil
+}
+
+// write 先写入临时文件再重命名，避免写入中断时损坏原文件
+func (s *FileCredentialStore) write(m map[string]*CookieAuth) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if s.passphrase != "" {
+		f := credentialFile{Version: 1, Salt: s.salt}
+		if f.Salt == nil {
+			f.Salt = make([]byte, credentialSaltLen)
+			if _, err = rand.Read(f.Salt); err != nil {
+				return err
+			}
+		}
+		gcm, err := s.cipher(f.Salt)
+		if err != nil {
+			return err
+		}
+		f.Nonce = make([]byte, gcm.NonceSize())
+		if _, err = rand.Read(f.Nonce); err != nil {
+			return err
+		}
+		f.Data = gcm.Seal(nil, f.Nonce, b, nil)
+		if b, err = json.Marshal(f); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err = tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// cipher 由passphrase与salt派生密钥，每次加密使用随机nonce，因此可以复用salt
+func (s *FileCredentialStore) cipher(salt []byte) (cipher.AEAD, error) {
+	if s.gcm != nil && hmac.Equal(s.salt, salt) {
+		return s.gcm, nil
+	}
+	block, err := aes.NewCipher(pbkdf2([]byte(s.passphrase), salt, credentialKDFIter, 32, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	s.salt, s.gcm = append([]byte(nil), salt...), gcm
+	return gcm, nil
+}
+
+// pbkdf2 RFC 8018 PBKDF2，标准库中没有提供
+func pbkdf2(password, salt []byte, iter, keyLen int, h func() hash.Hash) []byte {
+	prf := hmac.New(h, password)
+	size := prf.Size()
+	n := (keyLen + size - 1) / size
+	var (
+		dk  = make([]byte, 0, n*size)
+		buf = make([]byte, 4)
+		u   = make([]byte, size)
+	)
+	for block := 1; block <= n; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		buf[0], buf[1], buf[2], buf[3] = byte(block>>24), byte(block>>16), byte(block>>8), byte(block)
+		prf.Write(buf)
+		u = prf.Sum(u[:0])
+		t := append([]byte(nil), u...)
+		for i := 1; i < iter; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		dk = append(dk, t...)
+	}
+	return dk[:keyLen]
+}
+
+// NewBiliClientFromStore 从存储中读取account的登录信息并创建Client
+//
+// setting中的 Auth 与 Store 会被忽略，Cookie刷新后自动保存到store，setting可为nil
+func NewBiliClientFromStore(store CredentialStore, account string, setting *BiliSetting) (*BiliClient, error) {
+	return NewBiliClientFromStoreWithContext(context.Background(), store, account, setting)
+}
+
+// NewBiliClientFromStoreWithContext 同 NewBiliClientFromStore，ctx 用于取消获取账户信息的请求
+func NewBiliClientFromStoreWithContext(ctx context.Context, store CredentialStore, account string, setting *BiliSetting) (*BiliClient, error) {
+	auth, err := store.Load(account)
+	if err != nil {
+		return nil, err
+	}
+	s := BiliSetting{}
+	if setting != nil {
+		s = *setting
+	}
+	s.Auth = auth
+	s.Store = store
+	s.StoreKey = account
+	return newBiliClient(ctx, &s, false)
+}
@@ -0,0 +1,127 @@
+package biligo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iyear/biligo/biligotest"
+)
+
+func TestPbkdf2(t *testing.T) {
+	for _, tc := range []struct {
+		iter int
+		want string
+	}{
+		{1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+	} {
+		if r := hex.EncodeToString(pbkdf2([]byte("password"), []byte("salt"), tc.iter, 32, sha256.New)); r != tc.want {
+			t.Errorf("%d: %s", tc.iter, r)
+		}
+	}
+}
+
+func testCredentialStore(t *testing.T, s CredentialStore) {
+	if _, err := s.Load("2"); err != ErrCredentialNotFound {
+		t.Fatalf("expected ErrCredentialNotFound, got %v", err)
+	}
+	auth := &CookieAuth{DedeUserID: "2", SESSDATA: "fake_sessdata", BiliJCT: "fake_bili_jct", RefreshToken: "fake_refresh_token"}
+	if err := s.Save("2", auth); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save("3", &CookieAuth{DedeUserID: "3"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.Load("2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *r != *auth || r == auth {
+		t.Errorf("%+v", r)
+	}
+	if err = s.Delete("2"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Delete("2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Load("2"); err != ErrCredentialNotFound {
+		t.Errorf("expected ErrCredentialNotFound, got %v", err)
+	}
+	if r, err = s.Load("3"); err != nil || r.DedeUserID != "3" {
+		t.Errorf("%+v %v", r, err)
+	}
+}
+
+func TestMemoryCredentialStore(t *testing.T) {
+	testCredentialStore(t, NewMemoryCredentialStore())
+}
+
+func TestFileCredentialStore(t *testing.T) {
+	dir := t.TempDir()
+
+	plain := filepath.Join(dir, "plain.json")
+	testCredentialStore(t, NewFileCredentialStore(plain, ""))
+	b, _ := ioutil.ReadFile(plain)
+	if !strings.Contains(string(b), `"DedeUserID": "3"`) {
+		t.Errorf("%s", b)
+	}
+
+	enc := filepath.Join(dir, "enc.json")
+	s := NewFileCredentialStore(enc, "passphrase")
+	testCredentialStore(t, s)
+	if err := s.Save("2", &CookieAuth{SESSDATA: "secret_sessdata"}); err != nil {
+		t.Fatal(err)
+	}
+	b, _ = ioutil.ReadFile(enc)
+	if strings.Contains(string(b), "secret_sessdata") {
+		t.Error("credential file is not encrypted")
+	}
+	if fi, err := os.Stat(enc); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("%v %v", fi.Mode(), err)
+	}
+	if r, err := NewFileCredentialStore(enc, "passphrase").Load("2"); err != nil || r.SESSDATA != "secret_sessdata" {
+		t.Errorf("%+v %v", r, err)
+	}
+	if _, err := NewFileCredentialStore(enc, "wrong").Load("2"); err == nil {
+		t.Error("expected error for wrong passphrase")
+	}
+	if accounts, err := s.Accounts(); err != nil || len(accounts) != 2 {
+		t.Errorf("%v %v", accounts, err)
+	}
+}
+
+func TestOffline_NewBiliClientFromStore(t *testing.T) {
+	srv := biligotest.NewServer()
+	t.Cleanup(srv.Close)
+	store := NewMemoryCredentialStore()
+
+	// 扫码登录等得到的登录信息在创建Client后保存
+	_, err := NewBiliClient(&BiliSetting{
+		Auth:      &CookieAuth{DedeUserID: "2", SESSDATA: "fake_sessdata", BiliJCT: "fake_bili_jct"},
+		Endpoints: srv.Endpoints(),
+		Store:     store,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, err := store.Load("2"); err != nil || a.SESSDATA != "fake_sessdata" {
+		t.Fatalf("%+v %v", a, err)
+	}
+
+	b, err := NewBiliClientFromStore(store, "2", &BiliSetting{Endpoints: srv.Endpoints()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.GetCookieAuth().BiliJCT != "fake_bili_jct" || b.storeKey != "2" {
+		t.Errorf("%+v", b.GetCookieAuth())
+	}
+	if _, err = NewBiliClientFromStore(store, "3", nil); err != ErrCredentialNotFound {
+		t.Errorf("expected ErrCredentialNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,369 @@
+package biligo
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// EncodeXML 以经典的 <i><d p="..."> XML格式写入弹幕
+//
+// cid 写入chatid，未知时可传0
+//
+// p的各项依次为：出现时间(秒) 类型 字号 颜色 发送时间 弹幕池 发送者mid hash dmid 权重
+func (d *DanmakuResp) EncodeXML(w io.Writer, cid int64) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(bw, `<i><chatserver>chat.bilibili.com</chatserver><chatid>%d</chatid><mission>0</mission><maxlimit>%d</maxlimit><state>0</state><real_name>0</real_name><source>k-v</source>`+"\n", cid, len(d.Danmaku))
+	for _, dm := range d.Danmaku {
+		fmt.Fprintf(bw, `<d p="%.5f,%d,%d,%d,%d,%d,%s,%d,%d">`,
+			float64(dm.Progress)/1000, dm.Mode, dm.FontSize, dm.Color, dm.Ctime, dm.Pool, escapeXMLAttr(dm.MidHash), dm.ID, dm.Weight)
+		if err := xml.EscapeText(bw, []byte(dm.Content)); err != nil {
+			return err
+		}
+		bw.WriteString("</d>\n")
+	}
+	bw.WriteString("</i>\n")
+	return bw.Flush()
+}
+
+func escapeXMLAttr(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// EncodeJSONL 以JSON Lines格式写入弹幕，每行一条
+func (d *DanmakuResp) EncodeJSONL(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	for _, dm := range d.Danmaku {
+		if err := enc.Encode(dm); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// DecodeDanmakuXML 读取 EncodeXML 或B站 x/v1/dm/list.so 接口的XML弹幕
+func DecodeDanmakuXML(r io.Reader) ([]*Danmaku, error) {
+	var doc struct {
+		D []struct {
+			P       string `xml:"p,attr"`
+			Content string `xml:",chardata"`
+		} `xml:"d"`
+	}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	r2 := make([]*Danmaku, 0, len(doc.D))
+	for i, d := range doc.D {
+		p := strings.Split(d.P, ",")
+		if len(p) < 8 {
+			return nil, fmt.Errorf("danmaku %d: invalid p attribute %q", i, d.P)
+		}
+		var (
+			dm  = &Danmaku{Content: d.Content, MidHash: p[6], IDStr: p[7]}
+			sec float64
+			err error
+		)
+		if sec, err = strconv.ParseFloat(p[0], 64); err != nil {
+			return nil, fmt.Errorf("danmaku %d: %v", i, err)
+		}
+		dm.Progress = int64(math.Round(sec * 1000))
+		ints := []*int{&dm.Mode, &dm.FontSize, &dm.Color}
+		for j, v := range ints {
+			if *v, err = strconv.Atoi(p[j+1]); err != nil {
+				return nil, fmt.Errorf("danmaku %d: %v", i, err)
+			}
+		}
+		if dm.Ctime, err = strconv.ParseInt(p[4], 10, 64); err != nil {
+			return nil, fmt.Errorf("danmaku %d: %v", i, err)
+		}
+		if dm.Pool, err = strconv.Atoi(p[5]); err != nil {
+			return nil, fmt.Errorf("danmaku %d: %v", i, err)
+		}
+		if dm.ID, err = strconv.ParseUint(p[7], 10, 64); err != nil {
+			return nil, fmt.Errorf("danmaku %d: %v", i, err)
+		}
+		// 旧格式没有权重
+		if len(p) > 8 {
+			if dm.Weight, err = strconv.Atoi(p[8]); err != nil {
+				return nil, fmt.Errorf("danmaku %d: %v", i, err)
+			}
+		}
+		r2 = append(r2, dm)
+	}
+	return r2, nil
+}
+
+// DanmakuASSOption ASS字幕输出设置
+type DanmakuASSOption struct {
+	// Width Height 画面分辨率 默认1920x1080
+	Width  int
+	Height int
+	// FontName 字体 默认 Microsoft YaHei
+	FontName string
+	// FontScale 字号缩放
+	//
+	// 实际字号为 FontSize * Height / 720 * FontScale，默认1
+	FontScale float64
+	// Opacity 不透明度 (0,1] 默认0.8
+	Opacity float64
+	// ScrollDuration 滚动弹幕在屏幕上停留的时间 默认8s
+	ScrollDuration time.Duration
+	// FixedDuration 顶部、底部弹幕停留的时间 默认4s
+	FixedDuration time.Duration
+	// AllowOverlap 没有空闲位置时是否重叠显示
+	//
+	// 默认false，即丢弃该弹幕
+	AllowOverlap bool
+}
+
+// 弹幕在ASS中的排布方式
+const (
+	assScroll = iota
+	assReverse
+	assTop
+	assBottom
+)
+
+// assLane 占据某一行的弹幕
+type assLane struct {
+	start float64 // 出现时间(秒)
+	end   float64 // 消失时间(秒)
+	width float64 // 宽度(像素)
+	fixed bool    // 是否为顶部、底部弹幕
+}
+
+// assLayout 某一排布方式下的行占用情况，按像素行记录
+type assLayout struct {
+	rows []*assLane
+}
+
+// EncodeASS 以ASS字幕格式写入弹幕
+//
+// 滚动、逆向、顶部、底部弹幕按出现时间分配不重叠的位置，高级弹幕、代码弹幕等不输出
+//
+// opt可为nil
+func (d *DanmakuResp) EncodeASS(w io.Writer, opt *DanmakuASSOption) error {
+	o := DanmakuASSOption{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.Width <= 0 || o.Height <= 0 {
+		o.Width, o.Height = 1920, 1080
+	}
+	if o.FontName == "" {
+		o.FontName = "Microsoft YaHei"
+	}
+	if o.FontScale <= 0 {
+		o.FontScale = 1
+	}
+	if o.Opacity <= 0 || o.Opacity > 1 {
+		o.Opacity = 0.8
+	}
+	if o.ScrollDuration <= 0 {
+		o.ScrollDuration = 8 * time.Second
+	}
+	if o.FixedDuration <= 0 {
+		o.FixedDuration = 4 * time.Second
+	}
+
+	alpha := fmt.Sprintf("%02X", int(math.Round((1-o.Opacity)*255)))
+	baseSize := 25 * float64(o.Height) / 720 * o.FontScale
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "[Script Info]\n")
+	fmt.Fprintf(bw, "ScriptType: v4.00+\n")
+	fmt.Fprintf(bw, "PlayResX: %d\n", o.Width)
+	fmt.Fprintf(bw, "PlayResY: %d\n", o.Height)
+	fmt.Fprintf(bw, "ScaledBorderAndShadow: yes\n")
+	fmt.Fprintf(bw, "WrapStyle: 2\n\n")
+	fmt.Fprintf(bw, "[V4+ Styles]\n")
+	fmt.Fprintf(bw, "Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding\n")
+	fmt.Fprintf(bw, "Style: Danmaku,%s,%.0f,&H%sFFFFFF,&H%sFFFFFF,&H%s000000,&H%s000000,0,0,0,0,100,100,0,0,1,1,0,7,0,0,0,1\n\n",
+		o.FontName, baseSize, alpha, alpha, alpha, alpha)
+	fmt.Fprintf(bw, "[Events]\n")
+	fmt.Fprintf(bw, "Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
+
+	list := append([]*Danmaku(nil), d.Danmaku...)
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Progress < list[j].Progress
+	})
+
+	layouts := make([]*assLayout, 4)
+	for i := range layouts {
+		layouts[i] = &assLayout{rows: make([]*assLane, o.Height)}
+	}
+	W, H := float64(o.Width), float64(o.Height)
+	for _, dm := range list {
+		var kind int
+		switch dm.Mode {
+		case 1, 2, 3:
+			kind = assScroll
+		case 4:
+			kind = assBottom
+		case 5:
+			kind = assTop
+		case 6:
+			kind = assReverse
+		default:
+			continue
+		}
+
+		size := baseSize
+		if dm.FontSize > 0 {
+			size = float64(dm.FontSize) * float64(o.Height) / 720 * o.FontScale
+		}
+		text := assEscape(dm.Content)
+		lane := &assLane{
+			start: float64(dm.Progress) / 1000,
+			width: assTextWidth(dm.Content, size),
+		}
+		height := int(math.Ceil(size * float64(strings.Count(dm.Content, "\n")+1)))
+		if kind == assScroll || kind == assReverse {
+			lane.end = lane.start + o.ScrollDuration.Seconds()
+		} else {
+			lane.end = lane.start + o.FixedDuration.Seconds()
+			lane.fixed = true
+		}
+
+		y, ok := layouts[kind].place(lane, height, W, o.AllowOverlap)
+		if !ok {
+			continue
+		}
+
+		var pos string
+		switch kind {
+		case assScroll:
+			pos = fmt.Sprintf(`\move(%.0f,%d,%.0f,%d)`, W, y, -lane.width, y)
+		case assReverse:
+			pos = fmt.Sprintf(`\move(%.0f,%d,%.0f,%d)`, -lane.width, y, W, y)
+		case assTop:
+			pos = fmt.Sprintf(`\an8\pos(%.0f,%d)`, W/2, y)
+		case assBottom:
+			pos = fmt.Sprintf(`\an2\pos(%.0f,%.0f)`, W/2, H-float64(y))
+		}
+		style := pos
+		if dm.FontSize > 0 && size != baseSize {
+			style += fmt.Sprintf(`\fs%.0f`, size)
+		}
+		if c := dm.Color & 0xFFFFFF; c != 0xFFFFFF {
+			style += fmt.Sprintf(`\c&H%02X%02X%02X&`, c&0xFF, (c>>8)&0xFF, (c>>16)&0xFF)
+			// 深色弹幕使用白色描边
+			if c == 0x000000 {
+				style += `\3c&HFFFFFF&`
+			}
+		}
+		fmt.Fprintf(bw, "Dialogue: 2,%s,%s,Danmaku,,0,0,0,,{%s}%s\n",
+			assTime(lane.start), assTime(lane.end), style, text)
+	}
+	return bw.Flush()
+}
+
+// place 为弹幕寻找不冲突的行，返回距顶部(底部弹幕为距底部)的像素位置
+func (l *assLayout) place(lane *assLane, height int, screenW float64, overlap bool) (int, bool) {
+	if height > len(l.rows) {
+		height = len(l.rows)
+	}
+	// 没有空闲位置时放在冲突最早结束的位置
+	best, bestEnd := 0, math.Inf(1)
+	for y := 0; y+height <= len(l.rows); {
+		last, end := -1, 0.0
+		for i := y; i < y+height; i++ {
+			if r := l.rows[i]; r != nil && !r.compatible(lane, screenW) {
+				last = i
+				if r.end > end {
+					end = r.end
+				}
+			}
+		}
+		if last < 0 {
+			l.occupy(y, height, lane)
+			return y, true
+		}
+		if end < bestEnd {
+			best, bestEnd = y, end
+		}
+		// 包含冲突行的位置都不可用
+		y = last + 1
+	}
+	if !overlap {
+		return 0, false
+	}
+	l.occupy(best, height, lane)
+	return best, true
+}
+
+func (l *assLayout) occupy(y, height int, lane *assLane) {
+	for i := y; i < y+height; i++ {
+		l.rows[i] = lane
+	}
+}
+
+// compatible 判断新弹幕是否可以与该行已有弹幕共存
+func (r *assLane) compatible(n *assLane, screenW float64) bool {
+	if r.end <= n.start {
+		return true
+	}
+	// 固定弹幕只要时间重叠就冲突
+	if r.fixed || n.fixed {
+		return false
+	}
+	// 滚动弹幕：已有弹幕需完全进入屏幕，且新弹幕追不上已有弹幕
+	dur := r.end - r.start
+	rSpeed := (screenW + r.width) / dur
+	nSpeed := (screenW + n.width) / (n.end - n.start)
+	if r.start+r.width/rSpeed > n.start {
+		return false
+	}
+	// 新弹幕到达左边缘的时间不早于已有弹幕完全离开
+	return n.start+screenW/nSpeed >= r.end
+}
+
+// assTextWidth 估算文字宽度，全角字符为一个字号宽，半角为一半
+func assTextWidth(s string, size float64) float64 {
+	var w float64
+	for _, line := range strings.Split(s, "\n") {
+		var lw float64
+		for _, r := range line {
+			if r < 0x80 || utf8.RuneLen(r) < 3 {
+				lw += size / 2
+			} else {
+				lw += size
+			}
+		}
+		if lw > w {
+			w = lw
+		}
+	}
+	return w
+}
+
+// assEscape 转义ASS中的特殊字符
+func assEscape(s string) string {
+	return strings.NewReplacer(
+		`\`, `＼`,
+		"{", "｛",
+		"}", "｝",
+		"\r\n", `\N`,
+		"\n", `\N`,
+	).Replace(s)
+}
+
+// assTime 秒 -> H:MM:SS.cc
+func assTime(sec float64) string {
+	cs := int64(math.Round(sec * 100))
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
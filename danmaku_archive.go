@@ -0,0 +1,228 @@
+package biligo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/iyear/biligo/internal/util"
+)
+
+// DanmakuArchive 历史弹幕归档
+type DanmakuArchive struct {
+	AID     int64 `json:"aid"`
+	CID     int64 `json:"cid"`
+	Pubdate int64 `json:"pubdate"` // 稿件发布时间 时间戳
+	// LastDate 最后处理完毕的历史日期 YYYY-MM-DD，继续时从下一天开始
+	LastDate string `json:"last_date"`
+	// Danmaku 归档中的弹幕 key为dmid
+	Danmaku map[uint64]*DanmakuArchiveEntry `json:"danmaku"`
+}
+
+// DanmakuArchiveEntry 归档中的一条弹幕
+type DanmakuArchiveEntry struct {
+	*Danmaku
+	FirstSeen string `json:"first_seen"` // 首次出现的历史日期 YYYY-MM-DD
+	LastSeen  string `json:"last_seen"`  // 最后出现的历史日期 YYYY-MM-DD
+	// Deleted 是否已被删除
+	//
+	// 历史弹幕池有数量上限，旧弹幕会被挤出。只有发送时间不早于之后某天快照中最早的弹幕却没有出现在该快照中时，才视为被删除
+	Deleted bool `json:"deleted"`
+	// DeletedOn 判定为删除的历史日期 YYYY-MM-DD
+	DeletedOn string `json:"deleted_on,omitempty"`
+}
+
+// Entries 按出现位置排序的全部弹幕
+func (a *DanmakuArchive) Entries() []*DanmakuArchiveEntry {
+	r := make([]*DanmakuArchiveEntry, 0, len(a.Danmaku))
+	for _, e := range a.Danmaku {
+		r = append(r, e)
+	}
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Progress != r[j].Progress {
+			return r[i].Progress < r[j].Progress
+		}
+		return r[i].ID < r[j].ID
+	})
+	return r
+}
+
+// merge 合并某一天的快照，返回新增与新判定删除的弹幕数
+//
+// 快照需要按日期顺序合并
+func (a *DanmakuArchive) merge(date string, snap *DanmakuResp) (added int, deleted int) {
+	if a.Danmaku == nil {
+		a.Danmaku = make(map[uint64]*DanmakuArchiveEntry)
+	}
+	var (
+		ids      = make(map[uint64]struct{}, len(snap.Danmaku))
+		minCtime int64
+	)
+	for i, d := range snap.Danmaku {
+		ids[d.ID] = struct{}{}
+		if i == 0 || d.Ctime < minCtime {
+			minCtime = d.Ctime
+		}
+		if e, ok := a.Danmaku[d.ID]; ok {
+			e.Danmaku, e.LastSeen = d, date
+			e.Deleted, e.DeletedOn = false, ""
+			continue
+		}
+		a.Danmaku[d.ID] = &DanmakuArchiveEntry{Danmaku: d, FirstSeen: date, LastSeen: date}
+		added++
+	}
+	if len(snap.Danmaku) == 0 {
+		return added, 0
+	}
+	for id, e := range a.Danmaku {
+		if _, ok := ids[id]; ok || e.Deleted {
+			continue
+		}
+		if e.Ctime >= minCtime {
+			e.Deleted, e.DeletedOn = true, date
+			deleted++
+		}
+	}
+	return added, deleted
+}
+
+// DanmakuArchiveOption 历史弹幕归档设置
+type DanmakuArchiveOption struct {
+	// Path 进度文件路径
+	//
+	// 非空时启动时读取已有进度，每处理完一天保存一次
+	Path string
+	// OnDate 每处理完一天调用
+	OnDate func(date string, added int, deleted int)
+}
+
+// DanmakuHistoryArchiver 历史弹幕归档器
+//
+// 从稿件发布的月份开始，逐月获取历史弹幕日期，按日期顺序获取每天的快照并合并
+type DanmakuHistoryArchiver struct {
+	b       *BiliClient
+	opt     DanmakuArchiveOption
+	archive *DanmakuArchive
+
+	// now 当前时间，测试时替换
+	now func() time.Time
+}
+
+// NewDanmakuHistoryArchiver 创建历史弹幕归档器
+//
+// opt.Path 对应的文件存在时从中继续，文件中的cid需一致
+//
+// opt可为nil
+func (b *BiliClient) NewDanmakuHistoryArchiver(aid int64, cid int64, opt *DanmakuArchiveOption) (*DanmakuHistoryArchiver, error) {
+	a := &DanmakuHistoryArchiver{
+		b:       b,
+		archive: &DanmakuArchive{AID: aid, CID: cid, Danmaku: make(map[uint64]*DanmakuArchiveEntry)},
+		now:     time.Now,
+	}
+	if opt != nil {
+		a.opt = *opt
+	}
+	if a.opt.Path == "" {
+		return a, nil
+	}
+
+	raw, err := ioutil.ReadFile(a.opt.Path)
+	if os.IsNotExist(err) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var archive DanmakuArchive
+	if err = json.Unmarshal(raw, &archive); err != nil {
+		return nil, err
+	}
+	if archive.CID != cid {
+		return nil, fmt.Errorf("archive cid %d does not match %d", archive.CID, cid)
+	}
+	if archive.LastDate != "" {
+		if _, err = time.Parse("2006-01-02", archive.LastDate); err != nil {
+			return nil, fmt.Errorf("archive last_date %q: %w", archive.LastDate, err)
+		}
+	}
+	if archive.Danmaku == nil {
+		archive.Danmaku = make(map[uint64]*DanmakuArchiveEntry)
+	}
+	a.archive = &archive
+	return a, nil
+}
+
+// Archive 当前归档，Run 结束后为完整结果
+func (a *DanmakuHistoryArchiver) Archive() *DanmakuArchive {
+	return a.archive
+}
+
+// Run 获取从发布至今的全部历史弹幕，已处理的日期会跳过
+//
+// 出错或ctx结束时已处理的进度已经保存，可以再次调用继续
+func (a *DanmakuHistoryArchiver) Run(ctx context.Context) error {
+	ar := a.archive
+	if ar.Pubdate == 0 {
+		info, err := a.b.VideoGetInfoWithContext(ctx, ar.AID)
+		if err != nil {
+			return err
+		}
+		ar.Pubdate = info.Pubdate
+	}
+
+	start := util.GetCST8Time(time.Unix(ar.Pubdate, 0))
+	now := util.GetCST8Time(a.now())
+	month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())
+	for !month.After(now) {
+		// 已处理完的月份
+		if ar.LastDate != "" && month.Format("2006-01") < ar.LastDate[:7] {
+			month = month.AddDate(0, 1, 0)
+			continue
+		}
+
+		dates, err := a.b.DanmakuGetHistoryIndexWithContext(ctx, ar.CID, month.Year(), int(month.Month()))
+		if err != nil {
+			return err
+		}
+		sort.Strings(dates)
+		for _, date := range dates {
+			if date <= ar.LastDate {
+				continue
+			}
+			snap, err := a.b.DanmakuGetHistoryWithContext(ctx, ar.CID, date)
+			if err != nil {
+				return err
+			}
+			added, deleted := ar.merge(date, snap)
+			ar.LastDate = date
+			if err = a.save(); err != nil {
+				return err
+			}
+			if a.opt.OnDate != nil {
+				a.opt.OnDate(date, added, deleted)
+			}
+		}
+		month = month.AddDate(0, 1, 0)
+	}
+	return nil
+}
+
+// save 先写入临时文件再替换，避免中断时损坏进度文件
+func (a *DanmakuHistoryArchiver) save() error {
+	if a.opt.Path == "" {
+		return nil
+	}
+	raw, err := json.Marshal(a.archive)
+	if err != nil {
+		return err
+	}
+	tmp := a.opt.Path + ".tmp"
+	if err = ioutil.WriteFile(tmp, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, a.opt.Path)
+}
@@ -0,0 +1,112 @@
+package biligo
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iyear/biligo/biligotest"
+	"github.com/iyear/biligo/proto/dm"
+)
+
+func TestDanmakuHistoryArchiver(t *testing.T) {
+	b, srv := newFakeBiliClient(t)
+
+	failDec := true
+	srv.HandleFunc(biligotest.BiliApiURL, "x/v2/dm/history/index", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("month") {
+		case "2011-11":
+			biligotest.WriteJSON(w, 0, "0", []string{"2011-11-21", "2011-11-20"})
+		case "2011-12":
+			if failDec {
+				biligotest.WriteJSON(w, -412, "请求被拦截", nil)
+				return
+			}
+			biligotest.WriteJSON(w, 0, "0", []string{"2011-12-01"})
+		default:
+			biligotest.WriteJSON(w, 0, "0", nil)
+		}
+	})
+	snaps := map[string][]*dm.DanmakuElem{
+		"2011-11-20": {{Id: 1, Ctime: 100}, {Id: 2, Ctime: 200}},
+		"2011-11-21": {{Id: 1, Ctime: 100}, {Id: 2, Ctime: 200}, {Id: 3, Ctime: 300}},
+		// 1被新弹幕挤出，3被删除
+		"2011-12-01": {{Id: 2, Ctime: 200}, {Id: 4, Ctime: 400}},
+	}
+	var dates []string
+	srv.HandleFunc(biligotest.BiliApiURL, "x/v2/dm/web/history/seg.so", func(w http.ResponseWriter, r *http.Request) {
+		date := r.URL.Query().Get("date")
+		dates = append(dates, date)
+		raw, _ := proto.Marshal(&dm.DmSegMobileReply{Elems: snaps[date]})
+		_, _ = w.Write(raw)
+	})
+
+	path := filepath.Join(t.TempDir(), "archive.json")
+	newArchiver := func() *DanmakuHistoryArchiver {
+		a, err := b.NewDanmakuHistoryArchiver(170001, 279786, &DanmakuArchiveOption{Path: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.now = func() time.Time { return time.Date(2012, 1, 15, 0, 0, 0, 0, time.UTC) }
+		return a
+	}
+
+	a := newArchiver()
+	if err := a.Run(context.Background()); !IsRateLimited(err) {
+		t.Fatal(err)
+	}
+	if a.Archive().LastDate != "2011-11-21" || len(a.Archive().Danmaku) != 3 {
+		t.Errorf("%+v", a.Archive())
+	}
+
+	// 从进度文件继续
+	failDec = false
+	a = newArchiver()
+	if a.Archive().LastDate != "2011-11-21" {
+		t.Fatalf("%+v", a.Archive())
+	}
+	if err := a.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(dates) != 3 || dates[2] != "2011-12-01" {
+		t.Errorf("%v", dates)
+	}
+
+	ar := a.Archive()
+	if len(ar.Danmaku) != 4 || ar.LastDate != "2011-12-01" {
+		t.Fatalf("%+v", ar)
+	}
+	check := func(id uint64, first, last string, deleted bool) {
+		e := ar.Danmaku[id]
+		if e.FirstSeen != first || e.LastSeen != last || e.Deleted != deleted {
+			t.Errorf("%d: %+v", id, e)
+		}
+	}
+	check(1, "2011-11-20", "2011-11-21", false)
+	check(2, "2011-11-20", "2011-12-01", false)
+	check(3, "2011-11-21", "2011-11-21", true)
+	check(4, "2011-12-01", "2011-12-01", false)
+	if ar.Danmaku[3].DeletedOn != "2011-12-01" {
+		t.Errorf("%+v", ar.Danmaku[3])
+	}
+	if es := ar.Entries(); len(es) != 4 || es[0].ID != 1 {
+		t.Errorf("%v", es)
+	}
+}
+
+func TestDanmakuHistoryArchiver_InvalidProgress(t *testing.T) {
+	b, _ := newFakeBiliClient(t)
+	path := filepath.Join(t.TempDir(), "archive.json")
+	for _, date := range []string{"2011", "2011-13-01", "20111201"} {
+		if err := ioutil.WriteFile(path, []byte(`{"aid":170001,"cid":279786,"last_date":"`+date+`"}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.NewDanmakuHistoryArchiver(170001, 279786, &DanmakuArchiveOption{Path: path}); err == nil {
+			t.Errorf("%s: expected error", date)
+		}
+	}
+}
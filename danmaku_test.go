@@ -0,0 +1,128 @@
+package biligo
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func testDanmakuResp() *DanmakuResp {
+	return &DanmakuResp{Danmaku: []*Danmaku{
+		{ID: 43224517718392837, IDStr: "43224517718392837", Progress: 1500, Mode: 1, FontSize: 25, Color: 16777215, MidHash: "a16fe0dd", Content: "前方高能", Ctime: 1608196024, Weight: 10},
+		{ID: 43224517718392901, IDStr: "43224517718392901", Progress: 1500, Mode: 1, FontSize: 25, Color: 16711680, MidHash: "b2c3d4e5", Content: "<script>&\"'", Ctime: 1608196025, Weight: 3},
+		{ID: 43224517718393012, IDStr: "43224517718393012", Progress: 2000, Mode: 5, FontSize: 36, Color: 0, MidHash: "c3d4e5f6", Content: "顶部{弹幕}", Ctime: 1608196026, Pool: 1, Weight: 1},
+		{ID: 43224517718393013, IDStr: "43224517718393013", Progress: 2100, Mode: 5, FontSize: 25, Color: 16777215, MidHash: "c3d4e5f6", Content: "顶部2", Ctime: 1608196026},
+		{ID: 43224517718393014, IDStr: "43224517718393014", Progress: 3000, Mode: 7, FontSize: 25, Color: 16777215, MidHash: "c3d4e5f6", Content: `[0,0,"1-1",4.5,"高级弹幕"]`, Ctime: 1608196026},
+	}}
+}
+func TestDanmakuResp_EncodeXML(t *testing.T) {
+	d := testDanmakuResp()
+	var buf bytes.Buffer
+	if err := d.EncodeXML(&buf, 279786); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `<chatid>279786</chatid>`) ||
+		!strings.Contains(buf.String(), `<d p="1.50000,1,25,16777215,1608196024,0,a16fe0dd,43224517718392837,10">前方高能</d>`) {
+		t.Error(buf.String())
+	}
+
+	got, err := DecodeDanmakuXML(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, d.Danmaku) {
+		for i := range got {
+			t.Logf("%+v\n%+v", got[i], d.Danmaku[i])
+		}
+		t.Error("round trip mismatch")
+	}
+}
+func TestDecodeDanmakuXML(t *testing.T) {
+	// x/v1/dm/list.so 的旧格式，p只有8项
+	raw := `<?xml version="1.0" encoding="UTF-8"?><i><chatserver>chat.bilibili.com</chatserver><chatid>279786</chatid><mission>0</mission><maxlimit>3000</maxlimit><state>0</state><real_name>0</real_name><source>k-v</source><d p="490.19100,1,25,16777215,1584268892,0,a16fe0dd,29950852386521095">我来了</d></i>`
+	got, err := DecodeDanmakuXML(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Progress != 490191 || got[0].ID != 29950852386521095 || got[0].Content != "我来了" {
+		t.Errorf("%+v", got[0])
+	}
+
+	if _, err = DecodeDanmakuXML(strings.NewReader(`<i><d p="1,2">x</d></i>`)); err == nil {
+		t.Error("expected error")
+	}
+}
+func TestDanmakuResp_EncodeJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testDanmakuResp().EncodeJSONL(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 || !strings.Contains(lines[1], `"content":"<script>&\"'"`) {
+		t.Error(buf.String())
+	}
+}
+func TestDanmakuResp_EncodeASS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testDanmakuResp().EncodeASS(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "PlayResX: 1920") {
+		t.Error(out)
+	}
+
+	var dialogues []string
+	for _, l := range strings.Split(out, "\n") {
+		if strings.HasPrefix(l, "Dialogue:") {
+			dialogues = append(dialogues, l)
+		}
+	}
+	// 高级弹幕不输出
+	if len(dialogues) != 4 {
+		t.Fatalf("%d\n%s", len(dialogues), out)
+	}
+
+	move := regexp.MustCompile(`\\move\(\d+,(\d+),`)
+	y1, y2 := move.FindStringSubmatch(dialogues[0]), move.FindStringSubmatch(dialogues[1])
+	if y1 == nil || y2 == nil || y1[1] == y2[1] {
+		t.Errorf("scroll lanes overlap:\n%s\n%s", dialogues[0], dialogues[1])
+	}
+	if !strings.Contains(dialogues[1], `\c&H0000FF&`) || !strings.Contains(dialogues[1], "<script>") {
+		t.Error(dialogues[1])
+	}
+
+	pos := regexp.MustCompile(`\\an8\\pos\(\d+,(\d+)\)`)
+	p1, p2 := pos.FindStringSubmatch(dialogues[2]), pos.FindStringSubmatch(dialogues[3])
+	if p1 == nil || p2 == nil || p1[1] != "0" || p2[1] == "0" {
+		t.Errorf("top lanes:\n%s\n%s", dialogues[2], dialogues[3])
+	}
+	if !strings.Contains(dialogues[2], "顶部｛弹幕｝") || !strings.Contains(dialogues[2], `\fs54`) {
+		t.Error(dialogues[2])
+	}
+	if !strings.HasPrefix(dialogues[0], "Dialogue: 2,0:00:01.50,0:00:09.50,") {
+		t.Error(dialogues[0])
+	}
+}
+func TestDanmakuResp_EncodeASSOverflow(t *testing.T) {
+	d := &DanmakuResp{}
+	for i := 0; i < 10; i++ {
+		d.Danmaku = append(d.Danmaku, &Danmaku{ID: uint64(i), Progress: 0, Mode: 5, FontSize: 25, Content: "满屏"})
+	}
+	count := func(opt *DanmakuASSOption) int {
+		var buf bytes.Buffer
+		if err := d.EncodeASS(&buf, opt); err != nil {
+			t.Fatal(err)
+		}
+		return strings.Count(buf.String(), "Dialogue:")
+	}
+	// 高度100、FontScale为10时每条约35像素，只能放下2条
+	if n := count(&DanmakuASSOption{Width: 200, Height: 100, FontScale: 10}); n != 2 {
+		t.Errorf("drop: %d", n)
+	}
+	if n := count(&DanmakuASSOption{Width: 200, Height: 100, FontScale: 10, AllowOverlap: true}); n != 10 {
+		t.Errorf("overlap: %d", n)
+	}
+}
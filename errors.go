@@ -0,0 +1,79 @@
+package biligo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// APIError 哔哩哔哩API返回的业务错误，即 Response.Code 不为0
+//
+// 可以使用 errors.Is 与下方的哨兵错误比较，比较时只看 Code
+//
+// 也可以使用 errors.As 取出 *APIError 获取完整信息
+type APIError struct {
+	Code     int    // 返回值 Response.Code
+	Message  string // 错误信息 Response.Message
+	Endpoint string // 请求的接口，如 x/web-interface/view
+	Raw      []byte // 原始响应内容
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("(%d) %s", e.Code, e.Message)
+}
+
+// Is 用于 errors.Is，Code 相同即视为同一错误
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	if !ok {
+		return false
+	}
+	return t.Code == e.Code
+}
+
+// 常见错误码，可用于 errors.Is 判断
+//
+// 完整错误码：https://github.com/SocialSisterYi/bilibili-API-collect/blob/master/other/errcode.md
+var (
+	ErrNotLoggedIn    = &APIError{Code: -101, Message: "账号未登录"}
+	ErrCSRFFailed     = &APIError{Code: -111, Message: "csrf 校验失败"}
+	ErrAccessDenied   = &APIError{Code: -403, Message: "访问权限不足"}
+	ErrNotFound       = &APIError{Code: -404, Message: "啥都木有"}
+	ErrRateLimited    = &APIError{Code: -412, Message: "请求被拦截"}
+	ErrTooFrequent    = &APIError{Code: -509, Message: "请求过于频繁"}
+	ErrVideoNotFound  = &APIError{Code: 62002, Message: "稿件不可见"}
+	ErrReplyForbidden = &APIError{Code: 12002, Message: "评论区已关闭"}
+)
+
+// IsNotLoggedIn 是否为未登录错误(-101)
+func IsNotLoggedIn(err error) bool {
+	return errors.Is(err, ErrNotLoggedIn)
+}
+
+// IsCSRFFailed 是否为csrf校验失败(-111)
+func IsCSRFFailed(err error) bool {
+	return errors.Is(err, ErrCSRFFailed)
+}
+
+// IsRateLimited 是否被风控或请求过于频繁(-412,-509)
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrTooFrequent)
+}
+
+// IsNotFound 是否为资源不存在(-404,62002)
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound) || errors.Is(err, ErrVideoNotFound)
+}
+
+// IsAccessDenied 是否为权限不足(-403)
+func IsAccessDenied(err error) bool {
+	return errors.Is(err, ErrAccessDenied)
+}
+
+// ErrorCode 获取错误中的API错误码，不是 *APIError 时返回0和false
+func ErrorCode(err error) (int, bool) {
+	var e *APIError
+	if !errors.As(err, &e) {
+		return 0, false
+	}
+	return e.Code, true
+}
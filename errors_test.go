@@ -0,0 +1,56 @@
+package biligo
+
+import (
+	"errors"
+	"testing"
+
+	pkgerr "github.com/pkg/errors"
+)
+
+func TestBaseClient_ParseAPIError(t *testing.T) {
+	h := &baseClient{}
+	raw := []byte(`{"code":-101,"message":"账号未登录","ttl":1}`)
+	_, err := h.parse("x/member/web/account", raw)
+	if err == nil {
+		t.FailNow()
+	}
+	var e *APIError
+	if !errors.As(err, &e) {
+		t.Fatalf("%T is not *APIError", err)
+	}
+	if e.Code != -101 || e.Endpoint != "x/member/web/account" || string(e.Raw) != string(raw) {
+		t.Errorf("%+v", e)
+	}
+	if err.Error() != "(-101) 账号未登录" {
+		t.Error(err.Error())
+	}
+	if !IsNotLoggedIn(err) || IsRateLimited(err) || IsNotFound(err) {
+		t.FailNow()
+	}
+}
+func TestAPIError_Is(t *testing.T) {
+	err := pkgerr.Wrap(&APIError{Code: -412, Message: "request was banned"}, "video info")
+	if !IsRateLimited(err) || !errors.Is(err, ErrRateLimited) {
+		t.FailNow()
+	}
+	if code, ok := ErrorCode(err); !ok || code != -412 {
+		t.Error(code)
+	}
+	if _, ok := ErrorCode(errors.New("network")); ok {
+		t.FailNow()
+	}
+	if IsNotLoggedIn(errors.New("(-101) 账号未登录")) {
+		t.FailNow()
+	}
+}
+func TestBaseClient_ParseOK(t *testing.T) {
+	h := &baseClient{}
+	resp, err := h.parse("x/web-interface/zone", []byte(`{"code":0,"message":"0","data":{"addr":"127.0.0.1"}}`))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if string(resp.Data) != `{"addr":"127.0.0.1"}` {
+		t.Error(string(resp.Data))
+	}
+}
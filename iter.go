@@ -0,0 +1,541 @@
+package biligo
+
+import (
+	"context"
+)
+
+// IterOption 分页迭代设置
+type IterOption struct {
+	// PageSize 每页项数
+	//
+	// 0 使用各接口的默认值
+	PageSize int
+	// StartPage 起始页码
+	//
+	// 默认1
+	StartPage int
+	// Prefetch 预取页数，即同时进行的请求数
+	//
+	// 默认1，即读完一页再请求下一页
+	Prefetch int
+}
+
+// pageFunc 请求第pn页，返回该页内容和总页数
+//
+// 总页数未知或无法计算时返回0，此时以内容为空作为结束条件
+type pageFunc func(ctx context.Context, pn int, ps int) (items []interface{}, pages int, err error)
+
+type pageResult struct {
+	pn    int
+	items []interface{}
+	pages int
+	err   error
+}
+
+// pager 分页迭代的通用实现，各接口的迭代器在此基础上提供类型化的 Item
+type pager struct {
+	parent  context.Context
+	ctx     context.Context
+	cancel  context.CancelFunc
+	results chan *pageResult
+
+	buf    []interface{}
+	cur    interface{}
+	page   int
+	err    error
+	done   bool
+	closed bool
+	// stopErr run因ctx结束而退出时的错误，在results关闭前写入
+	//
+	// 正常结束时run也会取消ctx，此时为nil
+	stopErr error
+}
+
+func newPager(ctx context.Context, opt *IterOption, defaultSize int, fetch pageFunc) *pager {
+	o := IterOption{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.PageSize <= 0 {
+		o.PageSize = defaultSize
+	}
+	if o.StartPage <= 0 {
+		o.StartPage = 1
+	}
+	if o.Prefetch <= 0 {
+		o.Prefetch = 1
+	}
+
+	p := &pager{
+		parent:  ctx,
+		results: make(chan *pageResult),
+	}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	go p.run(o, fetch)
+	return p
+}
+
+// run 按顺序发出请求，最多同时进行 Prefetch 个，并按页码顺序交付结果
+func (p *pager) run(o IterOption, fetch pageFunc) {
+	defer close(p.results)
+
+	var (
+		queue []chan *pageResult
+		next  = o.StartPage
+		last  = 0 // 已知的总页数
+	)
+	for {
+		for len(queue) < o.Prefetch && (last == 0 || next <= last) {
+			ch := make(chan *pageResult, 1)
+			go func(pn int) {
+				items, pages, err := fetch(p.ctx, pn, o.PageSize)
+				ch <- &pageResult{pn: pn, items: items, pages: pages, err: err}
+			}(next)
+			queue = append(queue, ch)
+			next++
+		}
+		if len(queue) == 0 {
+			return
+		}
+
+		var r *pageResult
+		select {
+		case r = <-queue[0]:
+		case <-p.ctx.Done():
+			p.stopErr = p.ctx.Err()
+			return
+		}
+		queue = queue[1:]
+		if r.err == nil && r.pages > 0 {
+			last = r.pages
+		}
+
+		select {
+		case p.results <- r:
+		case <-p.ctx.Done():
+			p.stopErr = p.ctx.Err()
+			return
+		}
+
+		if r.err != nil || len(r.items) == 0 || (r.pages > 0 && r.pn >= r.pages) {
+			// 多余的预取请求随ctx取消
+			p.cancel()
+			return
+		}
+	}
+}
+
+// Next 移动到下一项，没有更多内容或出错时返回false，之后应检查 Err
+func (p *pager) Next() bool {
+	if p.done || p.closed {
+		return false
+	}
+	if err := p.parent.Err(); err != nil {
+		p.stop(err)
+		return false
+	}
+	for len(p.buf) == 0 {
+		r, ok := <-p.results
+		if !ok {
+			p.stop(p.stopErr)
+			return false
+		}
+		if r.err != nil {
+			// 请求因ctx结束而失败时返回ctx的错误
+			if err := p.parent.Err(); err != nil {
+				r.err = err
+			}
+			p.stop(r.err)
+			return false
+		}
+		p.page, p.buf = r.pn, r.items
+	}
+	p.cur, p.buf = p.buf[0], p.buf[1:]
+	return true
+}
+
+func (p *pager) stop(err error) {
+	p.done, p.err, p.buf = true, err, nil
+	p.cancel()
+}
+
+// Err 迭代过程中的错误，正常结束或调用 Close 后为nil
+func (p *pager) Err() error {
+	return p.err
+}
+
+// Page 当前项所在的页码，可用于中断后通过 IterOption.StartPage 继续
+func (p *pager) Page() int {
+	return p.page
+}
+
+// Close 停止迭代并取消未完成的请求，未迭代完时应调用以释放资源
+func (p *pager) Close() {
+	p.closed, p.buf = true, nil
+	p.cancel()
+}
+
+// pageCount 根据总数计算总页数
+func pageCount(total int, ps int) int {
+	if ps <= 0 {
+		return 0
+	}
+	return (total + ps - 1) / ps
+}
+
+// FollowingsIterator 关注列表迭代器
+type FollowingsIterator struct{ *pager }
+
+// Item 当前项，Next 返回true后有效
+func (it *FollowingsIterator) Item() *FollowingsItem { return it.cur.(*FollowingsItem) }
+
+// FollowingsIter 遍历用户关注列表，对应 FollowingsGetDetail
+//
+// 默认每页50项，opt可为nil
+func (c *CommClient) FollowingsIter(mid int64, opt *IterOption) *FollowingsIterator {
+	return c.FollowingsIterWithContext(context.Background(), mid, opt)
+}
+
+// FollowingsIterWithContext 同 FollowingsIter，ctx 用于取消迭代
+func (c *CommClient) FollowingsIterWithContext(ctx context.Context, mid int64, opt *IterOption) *FollowingsIterator {
+	return &FollowingsIterator{newPager(ctx, opt, 50, func(ctx context.Context, pn, ps int) ([]interface{}, int, error) {
+		r, err := c.FollowingsGetDetailWithContext(ctx, mid, pn, ps)
+		if err != nil {
+			return nil, 0, err
+		}
+		items := make([]interface{}, len(r.List))
+		for i, v := range r.List {
+			items[i] = v
+		}
+		return items, pageCount(r.Total, ps), nil
+	})}
+}
+
+// FavResIterator 收藏夹内容迭代器
+type FavResIterator struct{ *pager }
+
+// Item 当前项，Next 返回true后有效
+func (it *FavResIterator) Item() *FavResDetailMedia { return it.cur.(*FavResDetailMedia) }
+
+func favResPage(fetch func(ctx context.Context, pn, ps int) (*FavResDetail, error)) pageFunc {
+	return func(ctx context.Context, pn, ps int) ([]interface{}, int, error) {
+		r, err := fetch(ctx, pn, ps)
+		if err != nil {
+			return nil, 0, err
+		}
+		items := make([]interface{}, len(r.Medias))
+		for i, v := range r.Medias {
+			items[i] = v
+		}
+		// 带关键词搜索时 media_count 不是结果总数，以 has_more 为准
+		if !r.HasMore {
+			return items, pn, nil
+		}
+		pages := pn + 1
+		if r.Info != nil {
+			if n := pageCount(r.Info.MediaCount, ps); n > pages {
+				pages = n
+			}
+		}
+		return items, pages, nil
+	}
+}
+
+// FavResIter 遍历收藏夹内容，对应 FavGetResDetail
+//
+// 默认每页20项，opt可为nil
+func (c *CommClient) FavResIter(mlid int64, tid int, keyword string, order string, tp int, opt *IterOption) *FavResIterator {
+	return c.FavResIterWithContext(context.Background(), mlid, tid, keyword, order, tp, opt)
+}
+
+// FavResIterWithContext 同 FavResIter，ctx 用于取消迭代
+func (c *CommClient) FavResIterWithContext(ctx context.Context, mlid int64, tid int, keyword string, order string, tp int, opt *IterOption) *FavResIterator {
+	return &FavResIterator{newPager(ctx, opt, 20, favResPage(func(ctx context.Context, pn, ps int) (*FavResDetail, error) {
+		return c.FavGetResDetailWithContext(ctx, mlid, tid, keyword, order, tp, pn, ps)
+	}))}
+}
+
+// FavResIter 遍历收藏夹内容，对应 FavGetResDetail，可以获取私密收藏夹
+//
+// 默认每页20项，opt可为nil
+func (b *BiliClient) FavResIter(mlid int64, tid int, keyword string, order string, tp int, opt *IterOption) *FavResIterator {
+	return b.FavResIterWithContext(context.Background(), mlid, tid, keyword, order, tp, opt)
+}
+
+// FavResIterWithContext 同 FavResIter，ctx 用于取消迭代
+func (b *BiliClient) FavResIterWithContext(ctx context.Context, mlid int64, tid int, keyword string, order string, tp int, opt *IterOption) *FavResIterator {
+	return &FavResIterator{newPager(ctx, opt, 20, favResPage(func(ctx context.Context, pn, ps int) (*FavResDetail, error) {
+		return b.FavGetResDetailWithContext(ctx, mlid, tid, keyword, order, tp, pn, ps)
+	}))}
+}
+
+// ChanVideoIterator 频道视频迭代器
+type ChanVideoIterator struct{ *pager }
+
+// Item 当前项，Next 返回true后有效
+func (it *ChanVideoIterator) Item() *ChanVideoInfo { return it.cur.(*ChanVideoInfo) }
+
+// ChanVideoIter 遍历频道中的视频，对应 ChanGetVideo
+//
+// 默认每页30项，opt可为nil
+func (c *CommClient) ChanVideoIter(mid int64, cid int64, opt *IterOption) *ChanVideoIterator {
+	return c.ChanVideoIterWithContext(context.Background(), mid, cid, opt)
+}
+
+// ChanVideoIterWithContext 同 ChanVideoIter，ctx 用于取消迭代
+func (c *CommClient) ChanVideoIterWithContext(ctx context.Context, mid int64, cid int64, opt *IterOption) *ChanVideoIterator {
+	return &ChanVideoIterator{newPager(ctx, opt, 30, func(ctx context.Context, pn, ps int) ([]interface{}, int, error) {
+		r, err := c.ChanGetVideoWithContext(ctx, mid, cid, pn, ps)
+		if err != nil {
+			return nil, 0, err
+		}
+		var (
+			items []interface{}
+			pages int
+		)
+		if r.List != nil {
+			for _, v := range r.List.Archives {
+				items = append(items, v)
+			}
+		}
+		if r.Page != nil {
+			pages = pageCount(r.Page.Count, ps)
+		}
+		return items, pages, nil
+	})}
+}
+
+// SpaceVideoIterator 用户投稿视频迭代器
+type SpaceVideoIterator struct{ *pager }
+
+// Item 当前项，Next 返回true后有效
+func (it *SpaceVideoIterator) Item() *SpaceVideoSearchVList { return it.cur.(*SpaceVideoSearchVList) }
+
+// SpaceVideoIter 遍历用户投稿视频，对应 SpaceSearchVideo
+//
+// 默认每页30项，opt可为nil
+func (c *CommClient) SpaceVideoIter(mid int64, order string, tid int, keyword string, opt *IterOption) *SpaceVideoIterator {
+	return c.SpaceVideoIterWithContext(context.Background(), mid, order, tid, keyword, opt)
+}
+
+// SpaceVideoIterWithContext 同 SpaceVideoIter，ctx 用于取消迭代
+func (c *CommClient) SpaceVideoIterWithContext(ctx context.Context, mid int64, order string, tid int, keyword string, opt *IterOption) *SpaceVideoIterator {
+	return &SpaceVideoIterator{newPager(ctx, opt, 30, func(ctx context.Context, pn, ps int) ([]interface{}, int, error) {
+		r, err := c.SpaceSearchVideoWithContext(ctx, mid, order, tid, keyword, pn, ps)
+		if err != nil {
+			return nil, 0, err
+		}
+		var (
+			items []interface{}
+			pages int
+		)
+		if r.List != nil {
+			for _, v := range r.List.Vlist {
+				items = append(items, v)
+			}
+		}
+		if r.Page != nil {
+			pages = pageCount(r.Page.Count, ps)
+		}
+		return items, pages, nil
+	})}
+}
+
+// CommentReplyIterator 楼中楼评论迭代器
+type CommentReplyIterator struct{ *pager }
+
+// Item 当前项，Next 返回true后有效
+func (it *CommentReplyIterator) Item() *Comment { return it.cur.(*Comment) }
+
+// CommentReplyIter 遍历根评论下的回复，对应 CommentGetReply
+//
+// 默认每页20项，opt可为nil
+func (c *CommClient) CommentReplyIter(oid int64, tp int, root int64, opt *IterOption) *CommentReplyIterator {
+	return c.CommentReplyIterWithContext(context.Background(), oid, tp, root, opt)
+}
+
+// CommentReplyIterWithContext 同 CommentReplyIter，ctx 用于取消迭代
+func (c *CommClient) CommentReplyIterWithContext(ctx context.Context, oid int64, tp int, root int64, opt *IterOption) *CommentReplyIterator {
+	return &CommentReplyIterator{newPager(ctx, opt, 20, func(ctx context.Context, pn, ps int) ([]interface{}, int, error) {
+		r, err := c.CommentGetReplyWithContext(ctx, oid, tp, root, pn, ps)
+		if err != nil {
+			return nil, 0, err
+		}
+		items := make([]interface{}, len(r.Replies))
+		for i, v := range r.Replies {
+			items[i] = v
+		}
+		return items, pageCount(r.Page.Count, ps), nil
+	})}
+}
+
+// LiveGuardIterator 大航海成员迭代器
+type LiveGuardIterator struct{ *pager }
+
+// Item 当前项，Next 返回true后有效
+func (it *LiveGuardIterator) Item() *LiveGuardItem { return it.cur.(*LiveGuardItem) }
+
+// LiveGuardIter 遍历直播间大航海成员，对应 LiveGetGuardList
+//
+// 第一页会先返回前三名(Top3)
+//
+// 默认每页29项，opt可为nil
+func (c *CommClient) LiveGuardIter(roomID int64, mid int64, opt *IterOption) *LiveGuardIterator {
+	return c.LiveGuardIterWithContext(context.Background(), roomID, mid, opt)
+}
+
+// LiveGuardIterWithContext 同 LiveGuardIter，ctx 用于取消迭代
+func (c *CommClient) LiveGuardIterWithContext(ctx context.Context, roomID int64, mid int64, opt *IterOption) *LiveGuardIterator {
+	return &LiveGuardIterator{newPager(ctx, opt, 29, func(ctx context.Context, pn, ps int) ([]interface{}, int, error) {
+		r, err := c.LiveGetGuardListWithContext(ctx, roomID, mid, pn, ps)
+		if err != nil {
+			return nil, 0, err
+		}
+		var (
+			items []interface{}
+			pages int
+		)
+		if pn == 1 {
+			for _, v := range r.Top3 {
+				items = append(items, v)
+			}
+		}
+		for _, v := range r.List {
+			items = append(items, v)
+		}
+		if r.Info != nil {
+			pages = r.Info.Page
+		}
+		return items, pages, nil
+	})}
+}
+
+// AudioFavListIterator 音频收藏夹迭代器
+type AudioFavListIterator struct{ *pager }
+
+// Item 当前项，Next 返回true后有效
+func (it *AudioFavListIterator) Item() *AudioFavList { return it.cur.(*AudioFavList) }
+
+// AudioMyFavListsIter 遍历自己的音频收藏夹，对应 AudioGetMyFavLists
+//
+// 默认每页20项，opt可为nil
+func (b *BiliClient) AudioMyFavListsIter(opt *IterOption) *AudioFavListIterator {
+	return b.AudioMyFavListsIterWithContext(context.Background(), opt)
+}
+
+// AudioMyFavListsIterWithContext 同 AudioMyFavListsIter，ctx 用于取消迭代
+func (b *BiliClient) AudioMyFavListsIterWithContext(ctx context.Context, opt *IterOption) *AudioFavListIterator {
+	return &AudioFavListIterator{newPager(ctx, opt, 20, func(ctx context.Context, pn, ps int) ([]interface{}, int, error) {
+		r, err := b.AudioGetMyFavListsWithContext(ctx, pn, ps)
+		if err != nil {
+			return nil, 0, err
+		}
+		items := make([]interface{}, len(r.Data))
+		for i, v := range r.Data {
+			items[i] = v
+		}
+		return items, r.PageCount, nil
+	})}
+}
+
+// SearchIterator 分类搜索结果迭代器
+type SearchIterator struct{ *pager }
+
+// Item 当前项，Next 返回true后有效
+//
+// 类型由搜索类型决定，为 *SearchResultVideo *SearchResultMedia *SearchResultUser *SearchResultLiveRoom *SearchResultArticle 之一
+func (it *SearchIterator) Item() interface{} { return it.cur }
+
+// Video 当前项为视频时返回，否则为nil
+func (it *SearchIterator) Video() *SearchResultVideo {
+	v, _ := it.cur.(*SearchResultVideo)
+	return v
+}
+
+// Media 当前项为番剧或影视时返回，否则为nil
+func (it *SearchIterator) Media() *SearchResultMedia {
+	v, _ := it.cur.(*SearchResultMedia)
+	return v
+}
+
+// User 当前项为用户时返回，否则为nil
+func (it *SearchIterator) User() *SearchResultUser {
+	v, _ := it.cur.(*SearchResultUser)
+	return v
+}
+
+// LiveRoom 当前项为直播间时返回，否则为nil
+func (it *SearchIterator) LiveRoom() *SearchResultLiveRoom {
+	v, _ := it.cur.(*SearchResultLiveRoom)
+	return v
+}
+
+// Article 当前项为专栏时返回，否则为nil
+func (it *SearchIterator) Article() *SearchResultArticle {
+	v, _ := it.cur.(*SearchResultArticle)
+	return v
+}
+
+// SearchIter 遍历分类搜索结果，对应 SearchByType
+//
+// 每页项数由服务器决定，PageSize 无效，最多50页
+//
+// opt可为nil
+func (c *CommClient) SearchIter(keyword string, tp string, order string, duration int, tid int, opt *IterOption) *SearchIterator {
+	return c.SearchIterWithContext(context.Background(), keyword, tp, order, duration, tid, opt)
+}
+
+// SearchIterWithContext 同 SearchIter，ctx 用于取消迭代
+func (c *CommClient) SearchIterWithContext(ctx context.Context, keyword string, tp string, order string, duration int, tid int, opt *IterOption) *SearchIterator {
+	return &SearchIterator{newPager(ctx, opt, 20, func(ctx context.Context, pn, ps int) ([]interface{}, int, error) {
+		r, err := c.SearchByTypeWithContext(ctx, keyword, tp, order, duration, tid, pn)
+		if err != nil {
+			return nil, 0, err
+		}
+		var items []interface{}
+		for _, v := range r.Result.Video {
+			items = append(items, v)
+		}
+		for _, v := range r.Result.Media {
+			items = append(items, v)
+		}
+		for _, v := range r.Result.User {
+			items = append(items, v)
+		}
+		for _, v := range r.Result.LiveRoom {
+			items = append(items, v)
+		}
+		for _, v := range r.Result.Article {
+			items = append(items, v)
+		}
+		return items, r.NumPages, nil
+	})}
+}
+
+// SpaceArticleIterator 用户专栏迭代器
+type SpaceArticleIterator struct{ *pager }
+
+// Item 当前项，Next 返回true后有效
+func (it *SpaceArticleIterator) Item() *ArticleMeta { return it.cur.(*ArticleMeta) }
+
+// SpaceArticleIter 遍历用户投稿的专栏，对应 SpaceGetArticles
+//
+// 默认每页30项，opt可为nil
+func (c *CommClient) SpaceArticleIter(mid int64, sort string, opt *IterOption) *SpaceArticleIterator {
+	return c.SpaceArticleIterWithContext(context.Background(), mid, sort, opt)
+}
+
+// SpaceArticleIterWithContext 同 SpaceArticleIter，ctx 用于取消迭代
+func (c *CommClient) SpaceArticleIterWithContext(ctx context.Context, mid int64, sort string, opt *IterOption) *SpaceArticleIterator {
+	return &SpaceArticleIterator{newPager(ctx, opt, 30, func(ctx context.Context, pn, ps int) ([]interface{}, int, error) {
+		r, err := c.SpaceGetArticlesWithContext(ctx, mid, sort, pn, ps)
+		if err != nil {
+			return nil, 0, err
+		}
+		var items []interface{}
+		for _, v := range r.Articles {
+			items = append(items, v)
+		}
+		return items, pageCount(r.Count, ps), nil
+	})}
+}
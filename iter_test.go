@@ -0,0 +1,155 @@
+package biligo
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/iyear/biligo/biligotest"
+)
+
+// handleFollowings 模拟共total项的关注列表
+func handleFollowings(srv *biligotest.Server, total int, failPage int) {
+	srv.HandleFunc(biligotest.BiliApiURL, "x/relation/followings", func(w http.ResponseWriter, r *http.Request) {
+		pn, _ := strconv.Atoi(r.URL.Query().Get("pn"))
+		ps, _ := strconv.Atoi(r.URL.Query().Get("ps"))
+		if pn == failPage {
+			biligotest.WriteJSON(w, -412, "请求被拦截", nil)
+			return
+		}
+		list := make([]map[string]interface{}, 0)
+		for i := (pn - 1) * ps; i < pn*ps && i < total; i++ {
+			list = append(list, map[string]interface{}{"mid": i + 1})
+		}
+		biligotest.WriteJSON(w, 0, "0", map[string]interface{}{"total": total, "list": list})
+	})
+}
+func TestFollowingsIter(t *testing.T) {
+	for _, prefetch := range []int{0, 1, 4} {
+		c, srv := newFakeCommClient(t)
+		handleFollowings(srv, 5, 0)
+
+		it := c.FollowingsIter(1, &IterOption{PageSize: 2, Prefetch: prefetch})
+		var mids []int64
+		for it.Next() {
+			mids = append(mids, it.Item().MID)
+		}
+		if err := it.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if len(mids) != 5 || mids[0] != 1 || mids[4] != 5 {
+			t.Errorf("prefetch %d: %v", prefetch, mids)
+		}
+		if it.Page() != 3 {
+			t.Errorf("page: %d", it.Page())
+		}
+		// 顺序请求时不应请求多余的页
+		if prefetch <= 1 && len(srv.Requests()) != 3 {
+			t.Errorf("requests: %d", len(srv.Requests()))
+		}
+	}
+}
+func TestFollowingsIter_StartPage(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+	handleFollowings(srv, 5, 0)
+
+	it := c.FollowingsIter(1, &IterOption{PageSize: 2, StartPage: 2})
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if n != 3 || it.Err() != nil {
+		t.Errorf("%d %v", n, it.Err())
+	}
+}
+func TestFollowingsIter_Error(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+	handleFollowings(srv, 10, 2)
+
+	it := c.FollowingsIter(1, &IterOption{PageSize: 2, Prefetch: 3})
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if n != 2 || !IsRateLimited(it.Err()) {
+		t.Errorf("%d %v", n, it.Err())
+	}
+	if it.Next() {
+		t.Error("next after error")
+	}
+}
+func TestFollowingsIter_Cancel(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+	handleFollowings(srv, 100, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	it := c.FollowingsIterWithContext(ctx, 1, &IterOption{PageSize: 2})
+	n := 0
+	for it.Next() {
+		if n++; n == 3 {
+			cancel()
+		}
+	}
+	if it.Err() != context.Canceled {
+		t.Errorf("%v", it.Err())
+	}
+	if n >= 100 {
+		t.Errorf("not stopped: %d", n)
+	}
+
+	// Close后不再返回内容，也没有错误
+	it = c.FollowingsIter(1, &IterOption{PageSize: 2, Prefetch: 2})
+	if !it.Next() {
+		t.Fatal(it.Err())
+	}
+	it.Close()
+	if it.Next() || it.Err() != nil {
+		t.Errorf("%v", it.Err())
+	}
+}
+func TestFavResIter_HasMore(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+	srv.HandleFunc(biligotest.BiliApiURL, "x/v3/fav/resource/list", func(w http.ResponseWriter, r *http.Request) {
+		pn, _ := strconv.Atoi(r.URL.Query().Get("pn"))
+		biligotest.WriteJSON(w, 0, "0", map[string]interface{}{
+			// media_count 为整个收藏夹的数量，搜索时不可信
+			"info":     map[string]interface{}{"media_count": 100},
+			"medias":   []map[string]interface{}{{"id": pn}},
+			"has_more": pn < 2,
+		})
+	})
+
+	it := c.FavResIter(1, 0, "keyword", "mtime", 0, &IterOption{PageSize: 1})
+	var ids []int64
+	for it.Next() {
+		ids = append(ids, it.Item().ID)
+	}
+	if it.Err() != nil || len(ids) != 2 || ids[1] != 2 {
+		t.Errorf("%v %v", ids, it.Err())
+	}
+}
+func TestLiveGuardIter(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+	srv.HandleFunc(biligotest.BiliLiveURL, "xlive/app-room/v1/guardTab/topList", func(w http.ResponseWriter, r *http.Request) {
+		pn, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		data := map[string]interface{}{
+			"info": map[string]interface{}{"num": 5, "page": 2, "now": pn},
+			"list": []map[string]interface{}{{"uid": pn*10 + 1}, {"uid": pn*10 + 2}},
+		}
+		if pn == 1 {
+			data["top3"] = []map[string]interface{}{{"uid": 1}}
+		}
+		biligotest.WriteJSON(w, 0, "0", data)
+	})
+
+	it := c.LiveGuardIter(1, 2, nil)
+	var uids []int64
+	for it.Next() {
+		uids = append(uids, it.Item().UID)
+	}
+	if it.Err() != nil || len(uids) != 5 || uids[0] != 1 || uids[4] != 22 {
+		t.Errorf("%v %v", uids, it.Err())
+	}
+}
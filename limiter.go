@@ -0,0 +1,121 @@
+package biligo
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter 限流器，Wait 阻塞直到允许发出请求或ctx结束
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// TokenBucket 令牌桶限流器
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒生成的令牌数
+	burst  float64 // 桶容量
+	tokens float64
+	last   time.Time
+
+	// now 当前时间，测试时替换
+	now func() time.Time
+}
+
+// NewTokenBucket
+//
+// rate 每秒允许的请求数，小于等于0时不限流
+//
+// burst 允许的突发请求数，小于1时视为1
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// reserve 预定一个令牌，返回需要等待的时间
+func (b *TokenBucket) reserve() time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel 归还预定的令牌
+func (b *TokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+}
+
+// Wait 等待获取令牌
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	d := b.reserve()
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
+
+// RateLimit 请求限流设置
+//
+// 每次请求(包括重试)前先等待 Global，再等待对应 Hosts 的限流器
+type RateLimit struct {
+	// Global 全局限流 nil不限流
+	Global Limiter
+	// Hosts 按域名限流，key为 BiliApiURL BiliLiveURL BiliVcURL BiliPassportURL 等
+	Hosts map[string]Limiter
+	// OnWait 请求因限流等待后调用，base为请求的域名
+	OnWait func(base string, waited time.Duration)
+}
+
+func (r *RateLimit) wait(ctx context.Context, base string) error {
+	start := time.Now()
+	if r.Global != nil {
+		if err := r.Global.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if l, ok := r.Hosts[base]; ok && l != nil {
+		if err := l.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if r.OnWait != nil {
+		if waited := time.Since(start); waited >= time.Millisecond {
+			r.OnWait(base, waited)
+		}
+	}
+	return nil
+}
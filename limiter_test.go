@@ -0,0 +1,53 @@
+package biligo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_Wait(t *testing.T) {
+	b := NewTokenBucket(20, 2)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := b.Wait(context.Background()); err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+	}
+	// 突发2个，之后每50ms一个
+	if d := time.Since(start); d < 80*time.Millisecond || d > time.Second {
+		t.Errorf("waited %s", d)
+	}
+}
+func TestTokenBucket_Unlimited(t *testing.T) {
+	b := NewTokenBucket(0, 1)
+	for i := 0; i < 100; i++ {
+		if d := b.reserve(); d != 0 {
+			t.Fatalf("%d: %s", i, d)
+		}
+	}
+}
+func TestTokenBucket_WaitCancel(t *testing.T) {
+	b := NewTokenBucket(0.1, 1)
+	_ = b.Wait(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); err != context.DeadlineExceeded {
+		t.Error(err)
+	}
+}
+func TestRateLimit_Hosts(t *testing.T) {
+	var waited []string
+	r := &RateLimit{
+		Hosts:  map[string]Limiter{BiliLiveURL: NewTokenBucket(20, 1)},
+		OnWait: func(base string, d time.Duration) { waited = append(waited, base) },
+	}
+	for i := 0; i < 2; i++ {
+		_ = r.wait(context.Background(), BiliApiURL)
+		_ = r.wait(context.Background(), BiliLiveURL)
+	}
+	if len(waited) != 1 || waited[0] != BiliLiveURL {
+		t.Error(waited)
+	}
+}
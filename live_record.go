@@ -0,0 +1,663 @@
+package biligo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// 直播录制的流格式
+const (
+	LiveRecordFLV = "flv" // http-flv
+	LiveRecordHLS = "hls" // m3u8，分片为ts或fmp4
+)
+
+// ErrLiveOffline 开始录制时直播间未开播
+var ErrLiveOffline = errors.New("live room is offline")
+
+// errLiveStall 超过 StallTimeout 没有收到数据
+var errLiveStall = errors.New("live stream stalled")
+
+// LiveRecordOption 直播录制设置
+type LiveRecordOption struct {
+	// Qn 清晰度 原画:10000 蓝光:400 超清:250 高清:150 流畅:80
+	//
+	// 默认为10000，直播间不支持时服务器会返回其他清晰度
+	Qn int
+	// Format 流格式 LiveRecordFLV 或 LiveRecordHLS，默认为 LiveRecordFLV
+	Format string
+	// Writer 录制内容写入的目标，不为nil时忽略文件相关设置
+	//
+	// FLV断流重连后只写入一次文件头，后续连接的tag时间戳接续之前写入的tag后追加。
+	// HLS直接追加分片，重连前后的时间戳可能不连续
+	Writer io.Writer
+	// Dir 录制文件保存的目录，默认为当前目录
+	Dir string
+	// FileName 生成文件名，part从0开始递增，ext为 flv ts mp4 之一
+	//
+	// 默认为 {roomID}_{开始时间}_{part}.{ext}
+	FileName func(part int, ext string) string
+	// RotateSize 单个文件的最大字节数，0为不限制
+	RotateSize int64
+	// RotateDuration 单个文件的最大时长，0为不限制
+	//
+	// FLV只在视频关键帧处切分，新文件会重新写入文件头、元数据与音视频序列头；HLS在分片处切分
+	RotateDuration time.Duration
+	// Client 下载直播流使用的http client，不应设置Timeout
+	//
+	// 默认使用 CommClient 的http client与 ProxyProvider
+	Client *http.Client
+	// RetryDelay 断流后重新获取直播流的间隔 默认3s
+	RetryDelay time.Duration
+	// StallTimeout 超过该时间没有收到数据时视为断流 默认30s
+	StallTimeout time.Duration
+	// OnFileDone 文件模式下一个文件写入完成时调用
+	OnFileDone func(path string, size int64)
+	// OnError 断流、地址失效等错误时调用，之后会刷新直播流地址重试
+	OnError func(err error)
+}
+
+// LiveRecorder 直播录制
+//
+// 每次连接前使用 LiveGetRoomInfoByID 检查直播状态，下播后停止录制。
+// 断流或地址过期时重新调用 LiveGetPlayURL 获取地址，依次尝试返回的各个CDN
+type LiveRecorder struct {
+	c      *CommClient
+	roomID int64
+	opt    LiveRecordOption
+	sink   *liveSink
+
+	// next 下一次优先尝试的CDN
+	next int
+	// lastSeq 已写入的最后一个HLS分片序号
+	lastSeq int64
+}
+
+// NewLiveRecorder 创建直播录制，调用 Run 开始录制
+//
+// roomID: 真实直播间ID
+//
+// opt可为nil
+func (c *CommClient) NewLiveRecorder(roomID int64, opt *LiveRecordOption) *LiveRecorder {
+	r := &LiveRecorder{
+		c:       c,
+		roomID:  roomID,
+		lastSeq: -1,
+	}
+	if opt != nil {
+		r.opt = *opt
+	}
+	if r.opt.Qn <= 0 {
+		r.opt.Qn = 10000
+	}
+	if r.opt.Format == "" {
+		r.opt.Format = LiveRecordFLV
+	}
+	if r.opt.Dir == "" {
+		r.opt.Dir = "."
+	}
+	if r.opt.FileName == nil {
+		start := time.Now().Format("20060102_150405")
+		r.opt.FileName = func(part int, ext string) string {
+			return fmt.Sprintf("%d_%s_%03d.%s", roomID, start, part, ext)
+		}
+	}
+	if r.opt.RetryDelay <= 0 {
+		r.opt.RetryDelay = 3 * time.Second
+	}
+	if r.opt.StallTimeout <= 0 {
+		r.opt.StallTimeout = 30 * time.Second
+	}
+	r.sink = &liveSink{opt: &r.opt}
+	return r
+}
+
+// Run 开始录制，阻塞直到直播间下播或ctx结束
+//
+// 下播时返回nil，开始录制时未开播返回 ErrLiveOffline
+func (r *LiveRecorder) Run(ctx context.Context) error {
+	if r.opt.Format != LiveRecordFLV && r.opt.Format != LiveRecordHLS {
+		return fmt.Errorf("unknown live record format: %s", r.opt.Format)
+	}
+	defer r.sink.close()
+
+	first := true
+	for {
+		info, err := r.c.LiveGetRoomInfoByIDWithContext(ctx, r.roomID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			r.report(err)
+			if err = sleep(ctx, r.opt.RetryDelay); err != nil {
+				return err
+			}
+			continue
+		}
+		if info.LiveStatus != 1 {
+			if first {
+				return ErrLiveOffline
+			}
+			return nil
+		}
+		first = false
+
+		err = r.record(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		r.report(err)
+		if err = sleep(ctx, r.opt.RetryDelay); err != nil {
+			return err
+		}
+	}
+}
+
+// record 获取一次直播流地址并录制，直到断流
+//
+// 某个CDN没有收到任何数据就失败时切换到下一个，收到数据后断流则返回，由 Run 刷新地址
+func (r *LiveRecorder) record(ctx context.Context) error {
+	platform := "web"
+	if r.opt.Format == LiveRecordHLS {
+		platform = "h5"
+	}
+	play, err := r.c.liveGetPlayURL(ctx, r.roomID, r.opt.Qn, platform)
+	if err != nil {
+		return err
+	}
+	if len(play.DURL) == 0 {
+		return errors.New("empty live durl")
+	}
+
+	for range play.DURL {
+		u := play.DURL[r.next%len(play.DURL)].URL
+		var started bool
+		if r.opt.Format == LiveRecordHLS {
+			started, err = r.recordHLS(ctx, u)
+		} else {
+			started, err = r.recordFLV(ctx, u)
+		}
+		if err == nil || started || ctx.Err() != nil {
+			return err
+		}
+		r.report(err)
+		r.next++
+	}
+	return err
+}
+
+// get 请求直播流，状态码不为200时返回错误
+func (r *LiveRecorder) get(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", r.c.ua)
+	req.Header.Set("Referer", "https://live.bilibili.com/")
+	var resp *http.Response
+	if r.opt.Client != nil {
+		resp, err = r.opt.Client.Do(req)
+	} else {
+		resp, _, err = r.c.doStream(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("live stream %s: %s", u, resp.Status)
+	}
+	return resp, nil
+}
+
+// stallReader 每次读取到数据时重置计时器，计时器到期时取消请求
+type stallReader struct {
+	r io.Reader
+	t *time.Timer
+	d time.Duration
+}
+
+func (s *stallReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if n > 0 {
+		s.t.Reset(s.d)
+	}
+	return n, err
+}
+
+// flvHeaderLen FLV文件头9字节与第一个PreviousTagSize 4字节
+const flvHeaderLen = 13
+
+// recordFLV 录制一次http-flv连接，返回是否收到过音视频tag
+func (r *LiveRecorder) recordFLV(ctx context.Context, u string) (started bool, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var stalled int32
+	t := time.AfterFunc(r.opt.StallTimeout, func() {
+		atomic.StoreInt32(&stalled, 1)
+		cancel()
+	})
+	defer t.Stop()
+
+	resp, err := r.get(ctx, u)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	br := bufio.NewReader(&stallReader{r: resp.Body, t: t, d: r.opt.StallTimeout})
+	defer func() {
+		if err != nil && atomic.LoadInt32(&stalled) == 1 {
+			err = errLiveStall
+		}
+	}()
+
+	head := make([]byte, flvHeaderLen)
+	if _, err = io.ReadFull(br, head); err != nil {
+		return false, err
+	}
+	if string(head[:3]) != "FLV" {
+		return false, errors.New("invalid flv header")
+	}
+	if err = r.sink.startFLV(head); err != nil {
+		return false, err
+	}
+
+	for {
+		tag, err := readFLVTag(br)
+		if err == io.EOF {
+			return started, nil
+		}
+		if err != nil {
+			return started, err
+		}
+		started = true
+		if err = r.sink.writeFLVTag(tag); err != nil {
+			return started, err
+		}
+	}
+}
+
+// readFLVTag 读取一个完整的tag，包括11字节tag头、数据与4字节PreviousTagSize
+func readFLVTag(r io.Reader) ([]byte, error) {
+	var h [11]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		return nil, err
+	}
+	size := int(h[1])<<16 | int(h[2])<<8 | int(h[3])
+	tag := make([]byte, 11+size+4)
+	copy(tag, h[:])
+	if _, err := io.ReadFull(r, tag[11:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return tag, nil
+}
+
+// hlsPlaylist m3u8中需要的部分
+type hlsPlaylist struct {
+	targetDuration time.Duration
+	mapURI         string
+	segments       []*hlsSegment
+	end            bool
+	// variant 主播放列表中的第一个码流
+	variant string
+}
+
+type hlsSegment struct {
+	seq int64
+	uri string
+}
+
+// parseM3U8 解析m3u8，uri均相对base解析为绝对地址
+func parseM3U8(data []byte, base *url.URL) (*hlsPlaylist, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "#EXTM3U" {
+		return nil, errors.New("invalid m3u8")
+	}
+	resolve := func(s string) (string, error) {
+		u, err := base.Parse(s)
+		if err != nil {
+			return "", err
+		}
+		return u.String(), nil
+	}
+
+	p := &hlsPlaylist{}
+	var (
+		seq       int64
+		streamInf bool
+	)
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXT-X-TARGETDURATION:"):
+			v, _ := strconv.ParseFloat(strings.TrimPrefix(line, "#EXT-X-TARGETDURATION:"), 64)
+			p.targetDuration = time.Duration(v * float64(time.Second))
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			seq, _ = strconv.ParseInt(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"), 10, 64)
+		case strings.HasPrefix(line, "#EXT-X-MAP:"):
+			attr := strings.TrimPrefix(line, "#EXT-X-MAP:")
+			i := strings.Index(attr, `URI="`)
+			if i < 0 {
+				return nil, errors.New("invalid EXT-X-MAP: " + line)
+			}
+			attr = attr[i+5:]
+			if j := strings.IndexByte(attr, '"'); j >= 0 {
+				attr = attr[:j]
+			}
+			uri, err := resolve(attr)
+			if err != nil {
+				return nil, err
+			}
+			p.mapURI = uri
+		case line == "#EXT-X-ENDLIST":
+			p.end = true
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF"):
+			streamInf = true
+		case strings.HasPrefix(line, "#"):
+		default:
+			uri, err := resolve(line)
+			if err != nil {
+				return nil, err
+			}
+			if streamInf {
+				if p.variant == "" {
+					p.variant = uri
+				}
+				continue
+			}
+			p.segments = append(p.segments, &hlsSegment{seq: seq, uri: uri})
+			seq++
+		}
+	}
+	return p, nil
+}
+
+// fetch 下载整个文件，用于m3u8与分片
+func (r *LiveRecorder) fetch(ctx context.Context, u string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.opt.StallTimeout)
+	defer cancel()
+	resp, err := r.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+// recordHLS 轮询m3u8并按序写入新的分片，返回是否写入过分片
+func (r *LiveRecorder) recordHLS(ctx context.Context, u string) (started bool, err error) {
+	var (
+		init    []byte
+		initURI string
+		updated = time.Now()
+	)
+	for {
+		base, err := url.Parse(u)
+		if err != nil {
+			return started, err
+		}
+		data, err := r.fetch(ctx, u)
+		if err != nil {
+			return started, err
+		}
+		p, err := parseM3U8(data, base)
+		if err != nil {
+			return started, err
+		}
+		if p.variant != "" {
+			u = p.variant
+			continue
+		}
+
+		// 新地址的分片序号重新开始
+		if n := len(p.segments); n > 0 && p.segments[n-1].seq < r.lastSeq {
+			r.lastSeq = -1
+		}
+		for _, seg := range p.segments {
+			if seg.seq <= r.lastSeq {
+				continue
+			}
+			if p.mapURI != "" && p.mapURI != initURI {
+				if init, err = r.fetch(ctx, p.mapURI); err != nil {
+					return started, err
+				}
+				initURI = p.mapURI
+			}
+			b, err := r.fetch(ctx, seg.uri)
+			if err != nil {
+				return started, err
+			}
+			if err = r.sink.writeSegment(init, b); err != nil {
+				return started, err
+			}
+			r.lastSeq = seg.seq
+			started = true
+			updated = time.Now()
+		}
+		if p.end {
+			return started, nil
+		}
+		if time.Since(updated) > r.opt.StallTimeout {
+			return started, errLiveStall
+		}
+
+		wait := p.targetDuration
+		if wait <= 0 {
+			wait = time.Second
+		}
+		if err = sleep(ctx, wait); err != nil {
+			return started, err
+		}
+	}
+}
+
+func (r *LiveRecorder) report(err error) {
+	if err != nil && r.opt.OnError != nil {
+		r.opt.OnError(err)
+	}
+}
+
+// liveSink 录制内容的写入目标，处理文件切分
+type liveSink struct {
+	opt *LiveRecordOption
+
+	f     *os.File
+	path  string
+	part  int
+	size  int64
+	start time.Time
+	// media 当前文件是否已写入音视频数据，只有元数据时不切分
+	media bool
+
+	// flvHead 当前连接的FLV文件头
+	flvHead []byte
+	// flvMeta 当前连接的元数据与音视频序列头，按tag类型保存，新文件开头需要重新写入
+	flvMeta map[byte][]byte
+	// headWritten Writer模式下是否已写入FLV文件头
+	headWritten bool
+	// Writer模式下重连后的tag时间戳偏移，使输出的时间戳单调递增
+	flvRebase  bool
+	flvOffset  int64
+	flvLastTS  int64
+	flvWritten bool
+	// init 最后写入的fmp4初始化分片
+	init []byte
+}
+
+func (s *liveSink) write(b []byte) error {
+	var w io.Writer = s.f
+	if s.opt.Writer != nil {
+		w = s.opt.Writer
+	}
+	n, err := w.Write(b)
+	s.size += int64(n)
+	return err
+}
+
+// open 创建新文件并写入文件开头需要的内容
+func (s *liveSink) open(ext string, head ...[]byte) error {
+	if err := s.close(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.opt.Dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(s.opt.Dir, s.opt.FileName(s.part, ext))
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	s.f, s.path, s.size, s.start, s.media = f, path, 0, time.Now(), false
+	s.part++
+	for _, b := range head {
+		if err = s.write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *liveSink) close() error {
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	if err == nil && s.opt.OnFileDone != nil {
+		s.opt.OnFileDone(s.path, s.size)
+	}
+	s.f = nil
+	return err
+}
+
+// rotateDue 当前文件是否达到切分条件
+func (s *liveSink) rotateDue() bool {
+	return s.media && ((s.opt.RotateSize > 0 && s.size >= s.opt.RotateSize) ||
+		(s.opt.RotateDuration > 0 && time.Since(s.start) >= s.opt.RotateDuration))
+}
+
+// startFLV 开始新的FLV连接，文件模式下写入新文件
+func (s *liveSink) startFLV(head []byte) error {
+	s.flvHead = head
+	s.flvMeta = make(map[byte][]byte)
+	if s.opt.Writer != nil {
+		if s.headWritten {
+			s.flvRebase = true
+			return nil
+		}
+		s.headWritten = true
+		return s.write(head)
+	}
+	return s.close()
+}
+
+// FLV tag类型
+const (
+	flvTagAudio  = 8
+	flvTagVideo  = 9
+	flvTagScript = 18
+)
+
+func (s *liveSink) writeFLVTag(tag []byte) error {
+	typ := tag[0] & 0x1f
+	data := tag[11 : len(tag)-4]
+
+	var config, boundary bool
+	switch typ {
+	case flvTagScript:
+		config = true
+	case flvTagVideo:
+		// AVCPacketType 0 为序列头，codec id 7:AVC 12:HEVC
+		if len(data) >= 2 {
+			codec := data[0] & 0x0f
+			config = (codec == 7 || codec == 12) && data[1] == 0
+			boundary = !config && data[0]>>4 == 1
+		}
+	case flvTagAudio:
+		// AAC 序列头
+		config = len(data) >= 2 && data[0]>>4 == 10 && data[1] == 0
+		_, hasVideo := s.flvMeta[flvTagVideo]
+		boundary = !config && !hasVideo
+	}
+
+	if s.opt.Writer == nil && (s.f == nil || (boundary && s.rotateDue())) {
+		head := [][]byte{s.flvHead}
+		for _, t := range []byte{flvTagScript, flvTagVideo, flvTagAudio} {
+			if m, ok := s.flvMeta[t]; ok {
+				head = append(head, m)
+			}
+		}
+		if err := s.open("flv", head...); err != nil {
+			return err
+		}
+	}
+	if config {
+		s.flvMeta[typ] = tag
+	} else {
+		s.media = true
+	}
+	if s.opt.Writer != nil {
+		s.rebaseFLVTag(tag)
+	}
+	return s.write(tag)
+}
+
+// rebaseFLVTag 重连后新连接的时间戳从0开始，接续到最后写入的tag之后
+func (s *liveSink) rebaseFLVTag(tag []byte) {
+	ts := int64(tag[7])<<24 | int64(tag[4])<<16 | int64(tag[5])<<8 | int64(tag[6])
+	if s.flvRebase {
+		s.flvRebase = false
+		if s.flvWritten {
+			s.flvOffset = s.flvLastTS + 1 - ts
+		}
+	}
+	ts += s.flvOffset
+	if ts < 0 {
+		ts = 0
+	}
+	tag[4], tag[5], tag[6], tag[7] = byte(ts>>16), byte(ts>>8), byte(ts), byte(ts>>24)
+	if ts > s.flvLastTS || !s.flvWritten {
+		s.flvLastTS = ts
+	}
+	s.flvWritten = true
+}
+
+// writeSegment 写入一个HLS分片，init不为空时为fmp4
+func (s *liveSink) writeSegment(init, seg []byte) error {
+	if s.opt.Writer != nil {
+		if init != nil && string(init) != string(s.init) {
+			if err := s.write(init); err != nil {
+				return err
+			}
+			s.init = init
+		}
+		return s.write(seg)
+	}
+	// 初始化分片变化后需要写入新文件
+	if s.f == nil || s.rotateDue() || string(init) != string(s.init) {
+		s.init = init
+		ext := "ts"
+		if init != nil {
+			ext = "mp4"
+		}
+		if err := s.open(ext, init); err != nil {
+			return err
+		}
+	}
+	s.media = true
+	return s.write(seg)
+}
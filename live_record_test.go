@@ -0,0 +1,197 @@
+package biligo
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iyear/biligo/biligotest"
+)
+
+func flvTag(typ byte, ts int, data ...byte) []byte {
+	size := len(data)
+	tag := []byte{typ, byte(size >> 16), byte(size >> 8), byte(size), byte(ts >> 16), byte(ts >> 8), byte(ts), 0, 0, 0, 0}
+	tag = append(tag, data...)
+	n := len(tag)
+	return append(tag, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+var (
+	testFLVHead   = []byte{'F', 'L', 'V', 1, 5, 0, 0, 0, 9, 0, 0, 0, 0}
+	testFLVScript = flvTag(flvTagScript, 0, 2, 0, 10, 'o', 'n', 'M', 'e', 't', 'a', 'D', 'a', 't', 'a')
+	testFLVVSeq   = flvTag(flvTagVideo, 0, 0x17, 0, 0, 0, 0, 1)
+	testFLVASeq   = flvTag(flvTagAudio, 0, 0xaf, 0, 0x12, 0x10)
+	testFLVKey    = flvTag(flvTagVideo, 40, 0x17, 1, 0, 0, 0, 0xaa)
+	testFLVInter  = flvTag(flvTagVideo, 80, 0x27, 1, 0, 0, 0, 0xbb)
+	testFLVAudio  = flvTag(flvTagAudio, 60, 0xaf, 1, 0xcc)
+)
+
+func join(b ...[]byte) []byte {
+	return bytes.Join(b, nil)
+}
+
+// fakeLiveRecord 前live次查询直播状态时为直播中，playUrl返回一个404的地址与streamURL
+func fakeLiveRecord(t *testing.T, live int, stream string) (*CommClient, *httptest.Server) {
+	bad := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(bad.Close)
+
+	srv := biligotest.NewServer()
+	t.Cleanup(srv.Close)
+	var mu sync.Mutex
+	srv.HandleFunc(biligotest.BiliLiveURL, "xlive/web-room/v1/index/getRoomPlayInfo", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		status := 0
+		if live > 0 {
+			status = 1
+			live--
+		}
+		biligotest.WriteJSON(w, 0, "0", map[string]interface{}{"room_id": 22637261, "live_status": status})
+	})
+	srv.HandleJSON(biligotest.BiliLiveURL, "xlive/web-room/v1/playUrl/playUrl", 0, "0", map[string]interface{}{
+		"current_qn": 10000,
+		"durl": []map[string]interface{}{
+			{"url": bad.URL + "/live.flv", "order": 1},
+			{"url": stream, "order": 2},
+		},
+	})
+	return NewCommClient(&CommSetting{Endpoints: srv.Endpoints()}), bad
+}
+
+func TestLiveRecorder_FLV(t *testing.T) {
+	stream := join(testFLVHead, testFLVScript, testFLVVSeq, testFLVASeq, testFLVKey, testFLVAudio, testFLVInter)
+	var conns int
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conns++
+		// 第一次连接在tag中间断开
+		if conns == 1 {
+			_, _ = w.Write(stream[:len(stream)-3])
+			return
+		}
+		_, _ = w.Write(stream)
+	}))
+	defer cdn.Close()
+
+	c, _ := fakeLiveRecord(t, 2, cdn.URL+"/live.flv")
+	// 默认使用CommClient的http client
+	var streams int32
+	c.SetClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Host == cdn.Listener.Addr().String() {
+			atomic.AddInt32(&streams, 1)
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})})
+	var (
+		out  bytes.Buffer
+		errs []error
+	)
+	r := c.NewLiveRecorder(22637261, &LiveRecordOption{
+		Writer:     &out,
+		RetryDelay: time.Millisecond,
+		OnError:    func(err error) { errs = append(errs, err) },
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if conns != 2 || streams != 2 {
+		t.Errorf("conns: %d, streams: %d", conns, streams)
+	}
+	// 404的CDN与第一次的截断各一个错误
+	if len(errs) != 2 {
+		t.Errorf("%v", errs)
+	}
+	// 第二次连接的时间戳接续第一次最后写入的音频tag(60)
+	tags := stream[flvHeaderLen:]
+	want := join(testFLVHead, tags[:len(tags)-len(testFLVInter)],
+		flvTag(flvTagScript, 61, testFLVScript[11:len(testFLVScript)-4]...),
+		flvTag(flvTagVideo, 61, testFLVVSeq[11:len(testFLVVSeq)-4]...),
+		flvTag(flvTagAudio, 61, testFLVASeq[11:len(testFLVASeq)-4]...),
+		flvTag(flvTagVideo, 101, testFLVKey[11:len(testFLVKey)-4]...),
+		flvTag(flvTagAudio, 121, testFLVAudio[11:len(testFLVAudio)-4]...),
+		flvTag(flvTagVideo, 141, testFLVInter[11:len(testFLVInter)-4]...),
+	)
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("output mismatch: %d bytes, want %d", out.Len(), len(want))
+	}
+
+	if err := r.Run(context.Background()); err != ErrLiveOffline {
+		t.Errorf("expected ErrLiveOffline, got %v", err)
+	}
+}
+
+func TestLiveRecorder_Rotate(t *testing.T) {
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(join(testFLVHead, testFLVScript, testFLVVSeq, testFLVASeq, testFLVKey, testFLVInter, testFLVKey, testFLVAudio))
+	}))
+	defer cdn.Close()
+
+	c, _ := fakeLiveRecord(t, 1, cdn.URL+"/live.flv")
+	dir := t.TempDir()
+	var files []string
+	r := c.NewLiveRecorder(22637261, &LiveRecordOption{
+		Dir:        dir,
+		RotateSize: 1,
+		RetryDelay: time.Millisecond,
+		OnFileDone: func(path string, size int64) { files = append(files, path) },
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("%v", files)
+	}
+	meta := join(testFLVHead, testFLVScript, testFLVVSeq, testFLVASeq)
+	for i, want := range [][]byte{
+		join(meta, testFLVKey, testFLVInter),
+		join(meta, testFLVKey, testFLVAudio),
+	} {
+		got, err := ioutil.ReadFile(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("file %d mismatch", i)
+		}
+		if filepath.Ext(files[i]) != ".flv" {
+			t.Errorf("%s", files[i])
+		}
+	}
+}
+
+func TestLiveRecorder_HLS(t *testing.T) {
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/live/master.m3u8":
+			_, _ = w.Write([]byte("#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1000\nindex.m3u8\n"))
+		case "/live/index.m3u8":
+			_, _ = w.Write([]byte("#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:1\n#EXT-X-MEDIA-SEQUENCE:100\n" +
+				"#EXT-X-MAP:URI=\"h.m4s\"\n#EXTINF:1.0,\n100.m4s\n#EXTINF:1.0,\n/live/101.m4s\n#EXT-X-ENDLIST\n"))
+		case "/live/h.m4s", "/live/100.m4s", "/live/101.m4s":
+			_, _ = w.Write([]byte(r.URL.Path))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer cdn.Close()
+
+	c, _ := fakeLiveRecord(t, 1, cdn.URL+"/live/master.m3u8")
+	var out bytes.Buffer
+	r := c.NewLiveRecorder(22637261, &LiveRecordOption{
+		Format:     LiveRecordHLS,
+		Writer:     &out,
+		RetryDelay: time.Millisecond,
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "/live/h.m4s/live/100.m4s/live/101.m4s" {
+		t.Errorf("%s", out.String())
+	}
+}
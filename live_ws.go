@@ -0,0 +1,378 @@
+package biligo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/tidwall/gjson"
+)
+
+// LiveWsOption 直播WebSocket连接设置
+type LiveWsOption struct {
+	// UID 认证使用的用户mid，游客为0
+	UID int64
+	// Buvid 认证使用的buvid3，可为空
+	Buvid string
+	// Dialer 自定义WebSocket Dialer
+	//
+	// 默认为 websocket.DefaultDialer
+	Dialer *websocket.Dialer
+	// Header 握手时携带的额外Header，如Cookie
+	Header http.Header
+	// HeartbeatInterval 心跳间隔 默认30s
+	//
+	// 超过两个心跳间隔没有收到任何消息时视为连接断开
+	HeartbeatInterval time.Duration
+	// ReconnectDelay 断开后重连的间隔 默认3s
+	ReconnectDelay time.Duration
+	// Plain 使用 ws:// 与 ws_port 连接
+	//
+	// 默认使用 wss:// 与 wss_port
+	Plain bool
+	// OnError 连接断开或消息解析失败时调用，连接断开后会自动重连
+	OnError func(err error)
+}
+
+// LiveWsClient 直播间弹幕WebSocket客户端
+//
+// 使用 LiveGetWsConf 获取服务器与token，断开后按 HostServerList 依次切换服务器重连
+//
+// 消息处理函数在读取消息的goroutine中依次调用，不应长时间阻塞
+type LiveWsClient struct {
+	c      *CommClient
+	roomID int64
+	opt    LiveWsOption
+
+	mu         sync.RWMutex
+	handlers   map[string][]func(e *LiveEvent)
+	popularity []func(p uint32)
+	connected  []func(host string)
+}
+
+// NewLiveWsClient 创建直播间弹幕客户端，调用 Run 开始连接
+//
+// roomID: 真实直播间ID
+//
+// opt可为nil
+func (c *CommClient) NewLiveWsClient(roomID int64, opt *LiveWsOption) *LiveWsClient {
+	l := &LiveWsClient{
+		c:        c,
+		roomID:   roomID,
+		handlers: make(map[string][]func(e *LiveEvent)),
+	}
+	if opt != nil {
+		l.opt = *opt
+	}
+	if l.opt.Dialer == nil {
+		l.opt.Dialer = websocket.DefaultDialer
+	}
+	if l.opt.HeartbeatInterval <= 0 {
+		l.opt.HeartbeatInterval = 30 * time.Second
+	}
+	if l.opt.ReconnectDelay <= 0 {
+		l.opt.ReconnectDelay = 3 * time.Second
+	}
+	return l
+}
+
+// On 注册cmd对应的消息处理函数，cmd为空时接收全部消息
+func (l *LiveWsClient) On(cmd string, h func(e *LiveEvent)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.handlers[cmd] = append(l.handlers[cmd], h)
+}
+
+// onTyped 注册需要解析的消息，解析失败时调用 OnError
+func (l *LiveWsClient) onTyped(cmd string, parse func(e *LiveEvent) error) {
+	l.On(cmd, func(e *LiveEvent) {
+		if err := parse(e); err != nil {
+			l.report(fmt.Errorf("parse %s: %w", cmd, err))
+		}
+	})
+}
+
+// OnDanmaku 弹幕 DANMU_MSG
+func (l *LiveWsClient) OnDanmaku(h func(d *LiveDanmakuMsg)) {
+	l.onTyped(LiveCmdDanmaku, func(e *LiveEvent) error {
+		d, err := e.Danmaku()
+		if err == nil {
+			h(d)
+		}
+		return err
+	})
+}
+
+// OnGift 礼物 SEND_GIFT
+func (l *LiveWsClient) OnGift(h func(g *LiveGift)) {
+	l.onTyped(LiveCmdGift, func(e *LiveEvent) error {
+		g, err := e.Gift()
+		if err == nil {
+			h(g)
+		}
+		return err
+	})
+}
+
+// OnSuperChat 醒目留言 SUPER_CHAT_MESSAGE
+func (l *LiveWsClient) OnSuperChat(h func(s *LiveSuperChat)) {
+	l.onTyped(LiveCmdSuperChat, func(e *LiveEvent) error {
+		s, err := e.SuperChat()
+		if err == nil {
+			h(s)
+		}
+		return err
+	})
+}
+
+// OnGuardBuy 上舰 GUARD_BUY
+func (l *LiveWsClient) OnGuardBuy(h func(g *LiveGuardBuy)) {
+	l.onTyped(LiveCmdGuardBuy, func(e *LiveEvent) error {
+		g, err := e.GuardBuy()
+		if err == nil {
+			h(g)
+		}
+		return err
+	})
+}
+
+// OnInteractWord 进入直播间、关注、分享 INTERACT_WORD
+func (l *LiveWsClient) OnInteractWord(h func(w *LiveInteractWord)) {
+	l.onTyped(LiveCmdInteractWord, func(e *LiveEvent) error {
+		w, err := e.InteractWord()
+		if err == nil {
+			h(w)
+		}
+		return err
+	})
+}
+
+// OnOnlineRankCount 高能榜人数 ONLINE_RANK_COUNT
+func (l *LiveWsClient) OnOnlineRankCount(h func(c *LiveOnlineRankCount)) {
+	l.onTyped(LiveCmdOnlineRankCount, func(e *LiveEvent) error {
+		c, err := e.OnlineRankCount()
+		if err == nil {
+			h(c)
+		}
+		return err
+	})
+}
+
+// OnPopularity 心跳回应中的人气值
+func (l *LiveWsClient) OnPopularity(h func(p uint32)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.popularity = append(l.popularity, h)
+}
+
+// OnConnected 认证成功后调用，host为当前连接的服务器
+func (l *LiveWsClient) OnConnected(h func(host string)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.connected = append(l.connected, h)
+}
+
+// Run 连接直播间并持续接收消息，阻塞直到ctx结束
+//
+// 连接断开后依次切换 HostServerList 中的服务器重连，每轮都重新获取token
+func (l *LiveWsClient) Run(ctx context.Context) error {
+	next := 0
+	for {
+		conf, err := l.c.LiveGetWsConfWithContext(ctx, l.roomID)
+		if err == nil && len(conf.HostServerList) == 0 {
+			err = errors.New("empty host_server_list")
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			l.report(err)
+			if err = sleep(ctx, l.opt.ReconnectDelay); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for range conf.HostServerList {
+			h := conf.HostServerList[next%len(conf.HostServerList)]
+			next++
+
+			err = l.serve(ctx, l.hostURL(h.Host, h.WssPort, h.WsPort), conf.Token)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			l.report(err)
+			if err = sleep(ctx, l.opt.ReconnectDelay); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (l *LiveWsClient) hostURL(host string, wssPort, wsPort int) string {
+	if l.opt.Plain {
+		return "ws://" + net.JoinHostPort(host, strconv.Itoa(wsPort)) + "/sub"
+	}
+	return "wss://" + net.JoinHostPort(host, strconv.Itoa(wssPort)) + "/sub"
+}
+
+// serve 建立一次连接，返回连接断开的原因
+func (l *LiveWsClient) serve(ctx context.Context, u string, token string) error {
+	conn, _, err := l.opt.Dialer.DialContext(ctx, u, l.opt.Header)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	// ctx结束时关闭连接，使读取返回
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	auth, err := json.Marshal(map[string]interface{}{
+		"uid":      l.opt.UID,
+		"roomid":   l.roomID,
+		"protover": 3,
+		"platform": "web",
+		"type":     2,
+		"key":      token,
+		"buvid":    l.opt.Buvid,
+	})
+	if err != nil {
+		return err
+	}
+	if err = conn.WriteMessage(websocket.BinaryMessage, EncodeLivePacket(&LivePacket{
+		ProtoVer: LiveProtoInt,
+		Op:       LiveOpAuth,
+		Seq:      1,
+		Body:     auth,
+	})); err != nil {
+		return err
+	}
+
+	timeout := 2 * l.opt.HeartbeatInterval
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	if err = l.waitAuth(conn); err != nil {
+		return err
+	}
+
+	l.mu.RLock()
+	connected := l.connected
+	l.mu.RUnlock()
+	for _, h := range connected {
+		h(u)
+	}
+
+	// 认证后只有心跳goroutine写入
+	go l.heartbeat(conn, done)
+
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(timeout))
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		packets, err := DecodeLivePackets(frame)
+		if err != nil {
+			l.report(err)
+			continue
+		}
+		for _, p := range packets {
+			l.dispatch(p)
+		}
+	}
+}
+
+// waitAuth 等待认证回应 {"code":0}
+func (l *LiveWsClient) waitAuth(conn *websocket.Conn) error {
+	for {
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		packets, err := DecodeLivePackets(frame)
+		if err != nil {
+			return err
+		}
+		for _, p := range packets {
+			if p.Op != LiveOpAuthReply {
+				continue
+			}
+			if code := gjson.GetBytes(p.Body, "code").Int(); code != 0 {
+				return fmt.Errorf("live auth failed: %s", string(p.Body))
+			}
+			return nil
+		}
+	}
+}
+
+func (l *LiveWsClient) heartbeat(conn *websocket.Conn, done <-chan struct{}) {
+	packet := EncodeLivePacket(&LivePacket{
+		ProtoVer: LiveProtoInt,
+		Op:       LiveOpHeartbeat,
+		Seq:      1,
+		Body:     []byte("[object Object]"),
+	})
+	t := time.NewTicker(l.opt.HeartbeatInterval)
+	defer t.Stop()
+	for {
+		if err := conn.WriteMessage(websocket.BinaryMessage, packet); err != nil {
+			// 写入失败时关闭连接，由读取处重连
+			conn.Close()
+			return
+		}
+		select {
+		case <-t.C:
+		case <-done:
+			return
+		}
+	}
+}
+
+func (l *LiveWsClient) dispatch(p *LivePacket) {
+	switch p.Op {
+	case LiveOpHeartbeatReply:
+		v, err := p.Popularity()
+		if err != nil {
+			l.report(err)
+			return
+		}
+		l.mu.RLock()
+		hs := l.popularity
+		l.mu.RUnlock()
+		for _, h := range hs {
+			h(v)
+		}
+	case LiveOpMessage:
+		e, err := ParseLiveEvent(p.Body)
+		if err != nil {
+			l.report(err)
+			return
+		}
+		l.mu.RLock()
+		hs := append(l.handlers[""][:len(l.handlers[""]):len(l.handlers[""])], l.handlers[e.Cmd]...)
+		l.mu.RUnlock()
+		for _, h := range hs {
+			h(e)
+		}
+	}
+}
+
+func (l *LiveWsClient) report(err error) {
+	if err != nil && l.opt.OnError != nil {
+		l.opt.OnError(err)
+	}
+}
@@ -0,0 +1,115 @@
+package biligo
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/andybalholm/brotli"
+)
+
+// 直播WebSocket数据包的操作码
+const (
+	LiveOpHeartbeat      uint32 = 2 // 心跳
+	LiveOpHeartbeatReply uint32 = 3 // 心跳回应 正文为4字节人气值
+	LiveOpMessage        uint32 = 5 // 通知消息 正文为JSON
+	LiveOpAuth           uint32 = 7 // 认证
+	LiveOpAuthReply      uint32 = 8 // 认证回应
+)
+
+// 直播WebSocket数据包的协议版本
+const (
+	LiveProtoJSON   uint16 = 0 // 正文为JSON
+	LiveProtoInt    uint16 = 1 // 心跳与认证
+	LiveProtoZlib   uint16 = 2 // 正文为zlib压缩的多个数据包
+	LiveProtoBrotli uint16 = 3 // 正文为brotli压缩的多个数据包
+)
+
+// liveHeaderLen 数据包头部长度
+const liveHeaderLen = 16
+
+// LivePacket 直播WebSocket数据包
+//
+// 头部16字节，均为大端序：包长度(4) 头部长度(2) 协议版本(2) 操作码(4) 序列号(4)
+type LivePacket struct {
+	ProtoVer uint16
+	Op       uint32
+	Seq      uint32
+	Body     []byte
+}
+
+// EncodeLivePacket 编码数据包，不进行压缩
+func EncodeLivePacket(p *LivePacket) []byte {
+	buf := make([]byte, liveHeaderLen+len(p.Body))
+	binary.BigEndian.PutUint32(buf[0:], uint32(len(buf)))
+	binary.BigEndian.PutUint16(buf[4:], liveHeaderLen)
+	binary.BigEndian.PutUint16(buf[6:], p.ProtoVer)
+	binary.BigEndian.PutUint32(buf[8:], p.Op)
+	binary.BigEndian.PutUint32(buf[12:], p.Seq)
+	copy(buf[liveHeaderLen:], p.Body)
+	return buf
+}
+
+// DecodeLivePackets 解码一个WebSocket帧中的全部数据包
+//
+// 压缩的数据包会被解压并展开，返回的数据包的协议版本均不为 LiveProtoZlib LiveProtoBrotli
+func DecodeLivePackets(frame []byte) ([]*LivePacket, error) {
+	var r []*LivePacket
+	for len(frame) > 0 {
+		if len(frame) < liveHeaderLen {
+			return nil, fmt.Errorf("live packet too short: %d bytes", len(frame))
+		}
+		size := binary.BigEndian.Uint32(frame[0:])
+		headerLen := binary.BigEndian.Uint16(frame[4:])
+		if size < uint32(headerLen) || uint32(len(frame)) < size || headerLen < liveHeaderLen {
+			return nil, fmt.Errorf("invalid live packet header: size %d header %d frame %d", size, headerLen, len(frame))
+		}
+		p := &LivePacket{
+			ProtoVer: binary.BigEndian.Uint16(frame[6:]),
+			Op:       binary.BigEndian.Uint32(frame[8:]),
+			Seq:      binary.BigEndian.Uint32(frame[12:]),
+			Body:     frame[headerLen:size],
+		}
+		frame = frame[size:]
+
+		var (
+			raw []byte
+			err error
+		)
+		switch p.ProtoVer {
+		case LiveProtoZlib:
+			var zr io.ReadCloser
+			if zr, err = zlib.NewReader(bytes.NewReader(p.Body)); err != nil {
+				return nil, err
+			}
+			raw, err = ioutil.ReadAll(zr)
+			zr.Close()
+		case LiveProtoBrotli:
+			raw, err = ioutil.ReadAll(brotli.NewReader(bytes.NewReader(p.Body)))
+		default:
+			r = append(r, p)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		inner, err := DecodeLivePackets(raw)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, inner...)
+	}
+	return r, nil
+}
+
+// Popularity 心跳回应中的人气值
+func (p *LivePacket) Popularity() (uint32, error) {
+	if p.Op != LiveOpHeartbeatReply || len(p.Body) < 4 {
+		return 0, errors.New("not a heartbeat reply")
+	}
+	return binary.BigEndian.Uint32(p.Body), nil
+}
@@ -0,0 +1,219 @@
+package biligo
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// 直播通知消息的cmd
+const (
+	LiveCmdDanmaku         = "DANMU_MSG"
+	LiveCmdGift            = "SEND_GIFT"
+	LiveCmdSuperChat       = "SUPER_CHAT_MESSAGE"
+	LiveCmdGuardBuy        = "GUARD_BUY"
+	LiveCmdInteractWord    = "INTERACT_WORD"
+	LiveCmdOnlineRankCount = "ONLINE_RANK_COUNT"
+)
+
+// LiveEvent 直播通知消息
+type LiveEvent struct {
+	// Cmd 消息类型，已去除 DANMU_MSG:4:0:2:2:2:0 这类后缀
+	Cmd string
+	// Raw 完整的JSON消息
+	Raw []byte
+}
+
+// ParseLiveEvent 解析 LiveOpMessage 数据包的正文
+func ParseLiveEvent(body []byte) (*LiveEvent, error) {
+	if !gjson.ValidBytes(body) {
+		return nil, errors.New("invalid live event json")
+	}
+	cmd := gjson.GetBytes(body, "cmd").String()
+	if i := strings.IndexByte(cmd, ':'); i >= 0 {
+		cmd = cmd[:i]
+	}
+	return &LiveEvent{Cmd: cmd, Raw: body}, nil
+}
+
+func (e *LiveEvent) data(cmd string, v interface{}) error {
+	if e.Cmd != cmd {
+		return errors.New("live event is " + e.Cmd + " not " + cmd)
+	}
+	return json.Unmarshal([]byte(gjson.GetBytes(e.Raw, "data").Raw), v)
+}
+
+// LiveDanmakuMsg 弹幕 DANMU_MSG
+type LiveDanmakuMsg struct {
+	Mode      int    // 弹幕类型
+	FontSize  int    // 字号
+	Color     int    // 颜色 十进制RGB888值
+	Timestamp int64  // 发送时间 毫秒时间戳
+	Content   string // 弹幕内容
+	UID       int64  // 发送者mid
+	Uname     string // 发送者昵称
+	IsAdmin   bool   // 是否为房管
+	UserLevel int    // 用户直播等级
+	// 粉丝勋章 没有佩戴时为nil
+	Medal *LiveDanmakuMedal
+	// 大航海等级 0:无 1:总督 2:提督 3:舰长
+	GuardLevel int
+}
+
+// LiveDanmakuMedal 弹幕中的粉丝勋章
+type LiveDanmakuMedal struct {
+	Level     int    // 勋章等级
+	Name      string // 勋章名
+	UpName    string // 勋章对应主播昵称
+	RoomID    int64  // 勋章对应直播间
+	UpMID     int64  // 勋章对应主播mid
+	Color     int    // 勋章颜色
+	IsLighted bool   // 是否点亮
+}
+
+// Danmaku 解析 DANMU_MSG，info为数组，按位置取值
+func (e *LiveEvent) Danmaku() (*LiveDanmakuMsg, error) {
+	if e.Cmd != LiveCmdDanmaku {
+		return nil, errors.New("live event is " + e.Cmd + " not " + LiveCmdDanmaku)
+	}
+	info := gjson.GetBytes(e.Raw, "info")
+	if !info.IsArray() {
+		return nil, errors.New("invalid DANMU_MSG info")
+	}
+	d := &LiveDanmakuMsg{
+		Mode:       int(info.Get("0.1").Int()),
+		FontSize:   int(info.Get("0.2").Int()),
+		Color:      int(info.Get("0.3").Int()),
+		Timestamp:  info.Get("0.4").Int(),
+		Content:    info.Get("1").String(),
+		UID:        info.Get("2.0").Int(),
+		Uname:      info.Get("2.1").String(),
+		IsAdmin:    info.Get("2.2").Int() == 1,
+		UserLevel:  int(info.Get("4.0").Int()),
+		GuardLevel: int(info.Get("7").Int()),
+	}
+	if m := info.Get("3"); m.IsArray() && len(m.Array()) > 0 {
+		d.Medal = &LiveDanmakuMedal{
+			Level:     int(m.Get("0").Int()),
+			Name:      m.Get("1").String(),
+			UpName:    m.Get("2").String(),
+			RoomID:    m.Get("3").Int(),
+			Color:     int(m.Get("4").Int()),
+			UpMID:     m.Get("12").Int(),
+			IsLighted: m.Get("11").Int() == 1,
+		}
+	}
+	return d, nil
+}
+
+// LiveGift 礼物 SEND_GIFT
+type LiveGift struct {
+	UID       int64  `json:"uid"`       // 赠送者mid
+	Uname     string `json:"uname"`     // 赠送者昵称
+	Face      string `json:"face"`      // 赠送者头像
+	GiftID    int64  `json:"giftId"`    // 礼物id
+	GiftName  string `json:"giftName"`  // 礼物名
+	Num       int    `json:"num"`       // 数量
+	Price     int64  `json:"price"`     // 单价 金瓜子为1000=1元
+	CoinType  string `json:"coin_type"` // 瓜子类型 gold:金瓜子 silver:银瓜子
+	TotalCoin int64  `json:"total_coin"`
+	Action    string `json:"action"`    // 投喂
+	Timestamp int64  `json:"timestamp"` // 时间戳
+}
+
+// Gift 解析 SEND_GIFT
+func (e *LiveEvent) Gift() (*LiveGift, error) {
+	var g = &LiveGift{}
+	if err := e.data(LiveCmdGift, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// LiveSuperChat 醒目留言 SUPER_CHAT_MESSAGE
+type LiveSuperChat struct {
+	ID        int64  `json:"id"`
+	UID       int64  `json:"uid"`
+	Price     int    `json:"price"`      // 价格 单位元
+	Message   string `json:"message"`    // 留言内容
+	StartTime int64  `json:"start_time"` // 开始时间 时间戳
+	EndTime   int64  `json:"end_time"`   // 结束时间 时间戳
+	Time      int    `json:"time"`       // 持续时间 秒
+	UserInfo  struct {
+		Uname      string `json:"uname"`
+		Face       string `json:"face"`
+		GuardLevel int    `json:"guard_level"`
+	} `json:"user_info"`
+}
+
+// SuperChat 解析 SUPER_CHAT_MESSAGE
+func (e *LiveEvent) SuperChat() (*LiveSuperChat, error) {
+	var s = &LiveSuperChat{}
+	if err := e.data(LiveCmdSuperChat, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LiveGuardBuy 上舰 GUARD_BUY
+type LiveGuardBuy struct {
+	UID        int64  `json:"uid"`
+	Username   string `json:"username"`
+	GuardLevel int    `json:"guard_level"` // 1:总督 2:提督 3:舰长
+	Num        int    `json:"num"`
+	Price      int64  `json:"price"` // 金瓜子
+	GiftID     int64  `json:"gift_id"`
+	GiftName   string `json:"gift_name"`
+	StartTime  int64  `json:"start_time"`
+	EndTime    int64  `json:"end_time"`
+}
+
+// GuardBuy 解析 GUARD_BUY
+func (e *LiveEvent) GuardBuy() (*LiveGuardBuy, error) {
+	var g = &LiveGuardBuy{}
+	if err := e.data(LiveCmdGuardBuy, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// LiveInteractWord 互动 INTERACT_WORD
+type LiveInteractWord struct {
+	UID    int64  `json:"uid"`
+	Uname  string `json:"uname"`
+	RoomID int64  `json:"roomid"`
+	// 1:进入直播间 2:关注 3:分享
+	MsgType   int   `json:"msg_type"`
+	Timestamp int64 `json:"timestamp"`
+	FansMedal *struct {
+		MedalLevel int    `json:"medal_level"`
+		MedalName  string `json:"medal_name"`
+		TargetID   int64  `json:"target_id"` // 勋章对应主播mid
+	} `json:"fans_medal"`
+}
+
+// InteractWord 解析 INTERACT_WORD
+func (e *LiveEvent) InteractWord() (*LiveInteractWord, error) {
+	var w = &LiveInteractWord{}
+	if err := e.data(LiveCmdInteractWord, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// LiveOnlineRankCount 高能榜人数 ONLINE_RANK_COUNT
+type LiveOnlineRankCount struct {
+	Count       int `json:"count"`        // 高能榜人数
+	OnlineCount int `json:"online_count"` // 在线人数 部分直播间没有
+}
+
+// OnlineRankCount 解析 ONLINE_RANK_COUNT
+func (e *LiveEvent) OnlineRankCount() (*LiveOnlineRankCount, error) {
+	var c = &LiveOnlineRankCount{}
+	if err := e.data(LiveCmdOnlineRankCount, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
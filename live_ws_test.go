@@ -0,0 +1,212 @@
+package biligo
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gorilla/websocket"
+	"github.com/iyear/biligo/biligotest"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	testLiveDanmu = `{"cmd":"DANMU_MSG:4:0:2:2:2:0","info":[[0,1,25,16777215,1634567890123,1634567890,0,"a1b2c3d4",0,0,0,"",0],"主播好",[12345,"测试用户",1,0,0,10000,1,""],[21,"勋章","主播",22637261,1725515,"",0,0,0,0,0,1,2],[13,0,6406234,">50000"],["",""],0,3,null]}`
+	testLiveGift  = `{"cmd":"SEND_GIFT","data":{"uid":12345,"uname":"测试用户","giftId":31036,"giftName":"小花花","num":5,"price":100,"coin_type":"gold","total_coin":500,"action":"投喂","timestamp":1634567890}}`
+	testLiveRank  = `{"cmd":"ONLINE_RANK_COUNT","data":{"count":1024}}`
+)
+
+func liveCompress(ver uint16, packets ...*LivePacket) []byte {
+	var raw, buf bytes.Buffer
+	for _, p := range packets {
+		raw.Write(EncodeLivePacket(p))
+	}
+	switch ver {
+	case LiveProtoZlib:
+		w := zlib.NewWriter(&buf)
+		_, _ = w.Write(raw.Bytes())
+		_ = w.Close()
+	case LiveProtoBrotli:
+		w := brotli.NewWriter(&buf)
+		_, _ = w.Write(raw.Bytes())
+		_ = w.Close()
+	}
+	return EncodeLivePacket(&LivePacket{ProtoVer: ver, Op: LiveOpMessage, Body: buf.Bytes()})
+}
+func TestDecodeLivePackets(t *testing.T) {
+	// 抓包得到的认证回应与心跳回应，在同一帧中
+	frame, _ := hex.DecodeString("0000001a001000010000000800000001" + hex.EncodeToString([]byte(`{"code":0}`)) +
+		"000000140010000100000003000000000000" + "0001")
+	packets, err := DecodeLivePackets(frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packets) != 2 || packets[0].Op != LiveOpAuthReply || string(packets[0].Body) != `{"code":0}` {
+		t.Fatalf("%+v", packets)
+	}
+	if p, err := packets[1].Popularity(); err != nil || p != 1 {
+		t.Errorf("%d %v", p, err)
+	}
+
+	msg := func(s string) *LivePacket { return &LivePacket{Op: LiveOpMessage, Body: []byte(s)} }
+	for _, ver := range []uint16{LiveProtoZlib, LiveProtoBrotli} {
+		packets, err = DecodeLivePackets(liveCompress(ver, msg(testLiveDanmu), msg(testLiveGift)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(packets) != 2 || string(packets[1].Body) != testLiveGift {
+			t.Errorf("ver %d: %+v", ver, packets)
+		}
+	}
+
+	if _, err = DecodeLivePackets(frame[:20]); err == nil {
+		t.Error("expected error for truncated frame")
+	}
+
+	// 心跳包的头部
+	raw := EncodeLivePacket(&LivePacket{ProtoVer: LiveProtoInt, Op: LiveOpHeartbeat, Seq: 1, Body: []byte("[object Object]")})
+	if hex.EncodeToString(raw[:16]) != "0000001f001000010000000200000001" {
+		t.Errorf("%x", raw[:16])
+	}
+}
+func TestLiveEvent(t *testing.T) {
+	e, err := ParseLiveEvent([]byte(testLiveDanmu))
+	if err != nil || e.Cmd != LiveCmdDanmaku {
+		t.Fatal(e, err)
+	}
+	d, err := e.Danmaku()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Content != "主播好" || d.UID != 12345 || d.Uname != "测试用户" || !d.IsAdmin || d.UserLevel != 13 || d.GuardLevel != 3 {
+		t.Errorf("%+v", d)
+	}
+	if d.Medal == nil || d.Medal.Level != 21 || d.Medal.RoomID != 22637261 || !d.Medal.IsLighted || d.Medal.UpMID != 2 {
+		t.Errorf("%+v", d.Medal)
+	}
+	if _, err = e.Gift(); err == nil {
+		t.Error("expected error for wrong cmd")
+	}
+
+	e, _ = ParseLiveEvent([]byte(testLiveGift))
+	if g, err := e.Gift(); err != nil || g.GiftName != "小花花" || g.Num != 5 || g.TotalCoin != 500 {
+		t.Errorf("%+v %v", g, err)
+	}
+}
+
+// fakeLiveServer 第一个服务器无法连接，第二个服务器可以正常认证
+func fakeLiveServer(t *testing.T, token string) (*biligotest.Server, *httptest.Server) {
+	var upgrader websocket.Upgrader
+	ws := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		packets, err := DecodeLivePackets(frame)
+		if err != nil || packets[0].Op != LiveOpAuth || gjson.GetBytes(packets[0].Body, "key").String() != token {
+			t.Errorf("auth: %v %+v", err, packets)
+			return
+		}
+		_ = conn.WriteMessage(websocket.BinaryMessage, EncodeLivePacket(&LivePacket{ProtoVer: LiveProtoInt, Op: LiveOpAuthReply, Body: []byte(`{"code":0}`)}))
+
+		msg := func(s string) *LivePacket { return &LivePacket{Op: LiveOpMessage, Body: []byte(s)} }
+		_ = conn.WriteMessage(websocket.BinaryMessage, liveCompress(LiveProtoBrotli, msg(testLiveDanmu), msg(testLiveGift)))
+		_ = conn.WriteMessage(websocket.BinaryMessage, liveCompress(LiveProtoZlib, msg(testLiveRank)))
+
+		for {
+			_, frame, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if packets, err = DecodeLivePackets(frame); err == nil && packets[0].Op == LiveOpHeartbeat {
+				_ = conn.WriteMessage(websocket.BinaryMessage, EncodeLivePacket(&LivePacket{ProtoVer: LiveProtoInt, Op: LiveOpHeartbeatReply, Body: []byte{0, 0, 4, 0}}))
+			}
+		}
+	}))
+	t.Cleanup(ws.Close)
+
+	// 获取一个未监听的端口
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	deadPort := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	_, port, _ := net.SplitHostPort(ws.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+
+	srv := biligotest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.HandleJSON(biligotest.BiliLiveURL, "room/v1/Danmu/getConf", 0, "ok", map[string]interface{}{
+		"token": token,
+		"host_server_list": []map[string]interface{}{
+			{"host": "127.0.0.1", "port": deadPort, "ws_port": deadPort, "wss_port": deadPort},
+			{"host": "127.0.0.1", "port": p, "ws_port": p, "wss_port": p},
+		},
+	})
+	return srv, ws
+}
+func TestLiveWsClient(t *testing.T) {
+	srv, _ := fakeLiveServer(t, "fake_token")
+	c := NewCommClient(&CommSetting{Endpoints: srv.Endpoints()})
+
+	var (
+		mu          sync.Mutex
+		errs        []error
+		danmaku     *LiveDanmakuMsg
+		gift        *LiveGift
+		rank        *LiveOnlineRankCount
+		all         []string
+		popular     uint32
+		hosts       []string
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	)
+	defer cancel()
+
+	l := c.NewLiveWsClient(22637261, &LiveWsOption{
+		Plain:             true,
+		HeartbeatInterval: 50 * time.Millisecond,
+		ReconnectDelay:    10 * time.Millisecond,
+		OnError: func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		},
+	})
+	l.OnConnected(func(host string) { hosts = append(hosts, host) })
+	l.On("", func(e *LiveEvent) { all = append(all, e.Cmd) })
+	l.OnDanmaku(func(d *LiveDanmakuMsg) { danmaku = d })
+	l.OnGift(func(g *LiveGift) { gift = g })
+	l.OnOnlineRankCount(func(c *LiveOnlineRankCount) { rank = c })
+	l.OnPopularity(func(p uint32) {
+		popular = p
+		cancel()
+	})
+
+	if err := l.Run(ctx); err != context.Canceled {
+		t.Fatal(err)
+	}
+	// 第一个服务器连接失败后切换到第二个
+	if len(errs) == 0 || len(hosts) != 1 {
+		t.Errorf("errs: %v hosts: %v", errs, hosts)
+	}
+	if danmaku == nil || danmaku.Content != "主播好" || gift == nil || gift.Num != 5 || rank == nil || rank.Count != 1024 {
+		t.Errorf("%+v %+v %+v", danmaku, gift, rank)
+	}
+	if len(all) != 3 || popular != 1024 {
+		t.Errorf("%v %d", all, popular)
+	}
+}
@@ -0,0 +1,61 @@
+package biligo
+
+import (
+	"log"
+	"net/http"
+)
+
+// RawRequest 中间件中的请求
+type RawRequest struct {
+	// Base 请求的域名，如 BiliApiURL
+	Base string
+	// Endpoint 请求的接口，如 x/web-interface/view
+	Endpoint string
+	// Payload 调用时传入的参数，只读，修改请直接操作 HTTP
+	Payload map[string]string
+	// HTTP 已构造完成的请求，可以修改Header等
+	HTTP *http.Request
+}
+
+// RawResponse 中间件中的响应
+type RawResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// RoundTrip 发出请求并返回响应
+type RoundTrip func(req *RawRequest) (*RawResponse, error)
+
+// Middleware 中间件，可以在next前后处理请求和响应，也可以不调用next直接返回(如缓存)
+//
+// 中间件在限流、重试与wbi重新签名的外层，每次API调用只经过一次，获取wbi密钥的请求不经过中间件
+type Middleware func(next RoundTrip) RoundTrip
+
+// DebugMiddleware 输出请求信息和响应内容，DebugMode为true时自动添加
+func DebugMiddleware(logger *log.Logger) Middleware {
+	return func(next RoundTrip) RoundTrip {
+		return func(req *RawRequest) (*RawResponse, error) {
+			resp, err := next(req)
+			logger.Printf("%s %s  %+v", req.HTTP.Method, req.HTTP.URL, req.Payload)
+			if err != nil {
+				logger.Printf("%v", err)
+				return nil, err
+			}
+			logger.Printf("%s", string(resp.Body))
+			return resp, nil
+		}
+	}
+}
+
+// HeaderMiddleware 为每个请求设置额外的Header
+func HeaderMiddleware(header http.Header) Middleware {
+	return func(next RoundTrip) RoundTrip {
+		return func(req *RawRequest) (*RawResponse, error) {
+			for k, v := range header {
+				req.HTTP.Header[http.CanonicalHeaderKey(k)] = v
+			}
+			return next(req)
+		}
+	}
+}
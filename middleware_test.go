@@ -0,0 +1,70 @@
+package biligo
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCommClient_Use(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":0,"message":"0","data":"` + r.Header.Get("X-Trace") + `"}`))
+	}))
+	defer srv.Close()
+
+	var (
+		order []string
+		buf   bytes.Buffer
+	)
+	trace := func(name string) Middleware {
+		return func(next RoundTrip) RoundTrip {
+			return func(req *RawRequest) (*RawResponse, error) {
+				order = append(order, name)
+				return next(req)
+			}
+		}
+	}
+	c := NewCommClient(&CommSetting{
+		DebugMode:   true,
+		Logger:      log.New(&buf, "", 0),
+		Middlewares: []Middleware{trace("setting"), HeaderMiddleware(http.Header{"x-trace": {"abc"}})},
+	})
+	c.Use(trace("use"))
+
+	resp, err := c.RawParse(srv.URL+"/", "x/test", "GET", map[string]string{"aid": "1"})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if string(resp.Data) != `"abc"` {
+		t.Error(string(resp.Data))
+	}
+	if strings.Join(order, ",") != "use,setting" {
+		t.Error(order)
+	}
+	if !strings.Contains(buf.String(), "GET "+srv.URL+"/x/test?aid=1") {
+		t.Error(buf.String())
+	}
+}
+func TestCommClient_UseCache(t *testing.T) {
+	c := NewCommClient(&CommSetting{})
+	c.Use(func(next RoundTrip) RoundTrip {
+		return func(req *RawRequest) (*RawResponse, error) {
+			if req.Endpoint == "x/web-interface/zone" {
+				return &RawResponse{StatusCode: 200, Body: []byte(`{"code":0,"data":{"addr":"127.0.0.1"}}`)}, nil
+			}
+			return next(req)
+		}
+	})
+	info, err := c.GetGeoInfo()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if info.Addr != "127.0.0.1" {
+		t.Error(info.Addr)
+	}
+}
@@ -0,0 +1,186 @@
+package biligo
+
+import (
+	"encoding/xml"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type mpd struct {
+	XMLName                   xml.Name   `xml:"MPD"`
+	XMLNS                     string     `xml:"xmlns,attr"`
+	Profiles                  string     `xml:"profiles,attr"`
+	Type                      string     `xml:"type,attr"`
+	MediaPresentationDuration string     `xml:"mediaPresentationDuration,attr,omitempty"`
+	MinBufferTime             string     `xml:"minBufferTime,attr"`
+	Period                    *mpdPeriod `xml:"Period"`
+}
+
+type mpdPeriod struct {
+	ID             string              `xml:"id,attr"`
+	Start          string              `xml:"start,attr"`
+	AdaptationSets []*mpdAdaptationSet `xml:"AdaptationSet"`
+}
+
+type mpdAdaptationSet struct {
+	ID                  int                  `xml:"id,attr"`
+	ContentType         string               `xml:"contentType,attr"`
+	MimeType            string               `xml:"mimeType,attr,omitempty"`
+	SegmentAlignment    bool                 `xml:"segmentAlignment,attr"`
+	SubsegmentAlignment bool                 `xml:"subsegmentAlignment,attr"`
+	Representations     []*mpdRepresentation `xml:"Representation"`
+}
+
+type mpdRepresentation struct {
+	ID           string          `xml:"id,attr"`
+	Bandwidth    int64           `xml:"bandwidth,attr"`
+	Codecs       string          `xml:"codecs,attr,omitempty"`
+	MimeType     string          `xml:"mimeType,attr,omitempty"`
+	Width        int             `xml:"width,attr,omitempty"`
+	Height       int             `xml:"height,attr,omitempty"`
+	FrameRate    string          `xml:"frameRate,attr,omitempty"`
+	Sar          string          `xml:"sar,attr,omitempty"`
+	StartWithSAP int             `xml:"startWithSAP,attr,omitempty"`
+	BaseURL      []string        `xml:"BaseURL"`
+	SegmentBase  *mpdSegmentBase `xml:"SegmentBase,omitempty"`
+}
+
+type mpdSegmentBase struct {
+	IndexRange     string `xml:"indexRange,attr"`
+	Initialization struct {
+		Range string `xml:"range,attr"`
+	} `xml:"Initialization"`
+}
+
+// MPD 生成 DASH MPD 清单 (ISO/IEC 23009-1 isoff-on-demand profile)，可直接交给 dash.js ffmpeg 等播放器
+//
+// 视频按编码分为不同的 AdaptationSet，音频为一个 AdaptationSet，备用地址作为额外的 BaseURL
+//
+// 地址有效时间为120min，过期后需要重新获取并生成
+func (d *VideoPlayURLDash) MPD() ([]byte, error) {
+	minBuffer := d.MinBufferTime
+	if minBuffer <= 0 {
+		minBuffer = 1.5
+	}
+	m := &mpd{
+		XMLNS:         "urn:mpeg:dash:schema:mpd:2011",
+		Profiles:      "urn:mpeg:dash:profile:isoff-on-demand:2011",
+		Type:          "static",
+		MinBufferTime: mpdDuration(minBuffer),
+		Period:        &mpdPeriod{ID: "0", Start: "PT0S"},
+	}
+	if d.Duration > 0 {
+		m.MediaPresentationDuration = mpdDuration(float64(d.Duration))
+	}
+
+	ids := make(map[string]int)
+	// 按编码分组，保持编码首次出现的顺序
+	var (
+		codecs []int
+		groups = make(map[int][]*VideoPlayURLDashMedia)
+	)
+	for _, v := range d.Video {
+		c := v.Codec()
+		if _, ok := groups[c]; !ok {
+			codecs = append(codecs, c)
+		}
+		groups[c] = append(groups[c], v)
+	}
+	for _, c := range codecs {
+		m.Period.AdaptationSets = append(m.Period.AdaptationSets, mpdSet(len(m.Period.AdaptationSets), "video", groups[c], ids))
+	}
+	if len(d.Audio) > 0 {
+		m.Period.AdaptationSets = append(m.Period.AdaptationSets, mpdSet(len(m.Period.AdaptationSets), "audio", d.Audio, ids))
+	}
+
+	b, err := xml.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+// mpdSet ids用于保证 Representation id 在整个清单中唯一
+func mpdSet(id int, contentType string, media []*VideoPlayURLDashMedia, ids map[string]int) *mpdAdaptationSet {
+	sorted := make([]*VideoPlayURLDashMedia, len(media))
+	copy(sorted, media)
+	// 按带宽从低到高排列
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Bandwidth < sorted[j].Bandwidth })
+
+	set := &mpdAdaptationSet{
+		ID:                  id,
+		ContentType:         contentType,
+		SegmentAlignment:    true,
+		SubsegmentAlignment: true,
+	}
+	mime := ""
+	for i, v := range sorted {
+		if i == 0 {
+			mime = v.MimeType
+		} else if v.MimeType != mime {
+			mime = ""
+		}
+	}
+	set.MimeType = mime
+
+	for _, v := range sorted {
+		rid := strconv.Itoa(v.ID)
+		if n := ids[rid]; n > 0 {
+			ids[rid]++
+			rid += "-" + strconv.Itoa(n)
+		} else {
+			ids[rid] = 1
+		}
+		r := &mpdRepresentation{
+			ID:           rid,
+			Bandwidth:    v.Bandwidth,
+			Codecs:       v.Codecs,
+			Width:        v.Width,
+			Height:       v.Height,
+			FrameRate:    mpdFrameRate(v.FrameRate),
+			Sar:          v.Sar,
+			StartWithSAP: v.StartWithSap,
+		}
+		if mime == "" {
+			r.MimeType = v.MimeType
+		}
+		for _, u := range v.URLs() {
+			if u != "" {
+				r.BaseURL = append(r.BaseURL, u)
+			}
+		}
+		if v.SegmentBase != nil {
+			r.SegmentBase = &mpdSegmentBase{IndexRange: v.SegmentBase.IndexRange}
+			r.SegmentBase.Initialization.Range = v.SegmentBase.Initialization
+		}
+		set.Representations = append(set.Representations, r)
+	}
+	return set
+}
+
+// mpdDuration 秒数转为 xs:duration
+func mpdDuration(sec float64) string {
+	return "PT" + strconv.FormatFloat(sec, 'f', -1, 64) + "S"
+}
+
+// mpdFrameRate MPD的frameRate只能为整数或分数，将 29.412 转为 29412/1000
+func mpdFrameRate(s string) string {
+	i := strings.IndexByte(s, '.')
+	if i < 0 {
+		return s
+	}
+	frac := strings.TrimRight(s[i+1:], "0")
+	if frac == "" {
+		return s[:i]
+	}
+	num, err := strconv.ParseInt(s[:i]+frac, 10, 64)
+	if err != nil {
+		return ""
+	}
+	den := int64(1)
+	for range frac {
+		den *= 10
+	}
+	return strconv.FormatInt(num, 10) + "/" + strconv.FormatInt(den, 10)
+}
@@ -0,0 +1,56 @@
+package biligo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+func TestVideoPlayURLDash_MPD(t *testing.T) {
+	seg := &VideoPlayURLDashMediaSeg{Initialization: "0-927", IndexRange: "928-1303"}
+	d := &VideoPlayURLDash{
+		Duration:      120,
+		MinBufferTime: 1.5,
+		Video: []*VideoPlayURLDashMedia{
+			{ID: 80, BaseURL: "https://cn.bilivideo.com/80.m4s?e=1&deadline=2", BackupURL: []string{"https://bak.bilivideo.com/80.m4s"}, Bandwidth: 800000, MimeType: "video/mp4", Codecs: "avc1.640032", Width: 1920, Height: 1080, FrameRate: "29.412", Sar: "1:1", StartWithSap: 1, SegmentBase: seg, Codecid: 7},
+			{ID: 64, BaseURL: "https://cn.bilivideo.com/64.m4s", Bandwidth: 400000, MimeType: "video/mp4", Codecs: "avc1.640028", Width: 1280, Height: 720, FrameRate: "30.000", SegmentBase: seg, Codecid: 7},
+			{ID: 80, BaseURL: "https://cn.bilivideo.com/80h.m4s", Bandwidth: 500000, MimeType: "video/mp4", Codecs: "hev1.1.6.L150.90", Width: 1920, Height: 1080, FrameRate: "25", SegmentBase: seg, Codecid: 12},
+		},
+		Audio: []*VideoPlayURLDashMedia{
+			{ID: 30280, BaseURL: "https://cn.bilivideo.com/a.m4s", Bandwidth: 320000, MimeType: "audio/mp4", Codecs: "mp4a.40.2", SegmentBase: seg},
+		},
+	}
+	raw, err := d.MPD()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(raw, []byte(xml.Header)) {
+		t.Errorf("missing xml header")
+	}
+
+	var m mpd
+	if err = xml.Unmarshal(raw, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.XMLNS != "urn:mpeg:dash:schema:mpd:2011" || m.Type != "static" || m.MediaPresentationDuration != "PT120S" || m.MinBufferTime != "PT1.5S" {
+		t.Errorf("%+v", m)
+	}
+	sets := m.Period.AdaptationSets
+	if len(sets) != 3 || sets[0].ContentType != "video" || sets[2].ContentType != "audio" || sets[2].MimeType != "audio/mp4" {
+		t.Fatalf("%+v", sets)
+	}
+	avc := sets[0].Representations
+	if len(avc) != 2 || avc[0].ID != "64" || avc[0].FrameRate != "30" || avc[1].FrameRate != "29412/1000" {
+		t.Errorf("%+v %+v", avc[0], avc[1])
+	}
+	if len(avc[1].BaseURL) != 2 || avc[1].BaseURL[0] != "https://cn.bilivideo.com/80.m4s?e=1&deadline=2" {
+		t.Errorf("%v", avc[1].BaseURL)
+	}
+	if sb := avc[1].SegmentBase; sb == nil || sb.IndexRange != "928-1303" || sb.Initialization.Range != "0-927" {
+		t.Errorf("%+v", sb)
+	}
+	// 不同编码的同一清晰度id不重复
+	if hevc := sets[1].Representations[0]; hevc.ID != "80-1" || hevc.Codecs != "hev1.1.6.L150.90" {
+		t.Errorf("%+v", hevc)
+	}
+}
@@ -0,0 +1,280 @@
+package biligo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/iyear/biligo/biligotest"
+	"github.com/iyear/biligo/proto/dm"
+)
+
+func newFakeCommClient(t *testing.T) (*CommClient, *biligotest.Server) {
+	srv := biligotest.NewServer()
+	t.Cleanup(srv.Close)
+	return NewCommClient(&CommSetting{Endpoints: srv.Endpoints()}), srv
+}
+func newFakeBiliClient(t *testing.T) (*BiliClient, *biligotest.Server) {
+	srv := biligotest.NewServer()
+	t.Cleanup(srv.Close)
+	b, err := NewBiliClient(&BiliSetting{
+		Auth: &CookieAuth{
+			DedeUserID:      "2",
+			DedeUserIDCkMd5: "2ab3b1b4a5e7c4a9",
+			SESSDATA:        "fake_sessdata",
+			BiliJCT:         "fake_bili_jct",
+		},
+		Endpoints: srv.Endpoints(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, srv
+}
+func TestOffline_CommClient(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+
+	info, err := c.VideoGetInfo(170001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.BVID != "BV17x411w7KC" || len(info.Pages) != 2 {
+		t.Errorf("%+v", info)
+	}
+	if r := srv.LastRequest(); r.Base != BiliApiURL || r.Endpoint != "x/web-interface/view" || r.Query.Get("aid") != "170001" {
+		t.Errorf("%+v", r)
+	}
+
+	pages, err := c.VideoGetPageList(170001)
+	if err != nil || len(pages) != 2 || pages[1].Duration != 1232 {
+		t.Errorf("%v %v", pages, err)
+	}
+
+	d, err := c.DanmakuGetByPb(1, 279786, 1)
+	if err != nil || len(d.Danmaku) != 3 || d.Danmaku[0].Content != "前方高能" {
+		t.Errorf("%v %v", d, err)
+	}
+
+	conf, err := c.LiveGetWsConf(22637261)
+	if err != nil || len(conf.HostServerList) != 3 {
+		t.Errorf("%v %v", conf, err)
+	}
+}
+func TestOffline_CommClientWbi(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+
+	r, err := c.SpaceSearchVideo(122541, "", 0, "", 1, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Page.Count != 1 || r.List.Vlist[0].AID != 170001 {
+		t.Errorf("%+v", r)
+	}
+	// 第一个请求为获取wbi密钥
+	reqs := srv.Requests()
+	if len(reqs) != 2 || reqs[0].Endpoint != "x/web-interface/nav" || reqs[1].Query.Get("w_rid") == "" {
+		t.Errorf("%+v", reqs)
+	}
+}
+func TestOffline_APIError(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+	srv.HandleJSON(biligotest.BiliApiURL, "x/web-interface/view", -404, "啥都木有", nil)
+
+	if _, err := c.VideoGetInfo(1); !IsNotFound(err) {
+		t.Error(err)
+	}
+	if _, err := c.VideoGetDescription(1); !IsNotFound(err) {
+		t.Error(err)
+	}
+}
+func TestOffline_GetUserEx(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+	srv.HandleJSON(biligotest.BiliVcURL, "user_ex/v1/user/detail", 0, "0", map[string]interface{}{
+		"uid":  "2",
+		"feed": map[string]int{"fans_count": 100},
+	})
+	r, err := c.GetUserEx(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Data.Uid != "2" || r.Data.Feed.FansCount != 100 {
+		t.Errorf("%+v", r.Data)
+	}
+	q := srv.LastRequest().Query
+	if q.Get("uid") != "2" || len(q["feed[]"]) != 4 || len(q["user[]"]) != 2 {
+		t.Errorf("%v", q)
+	}
+
+	srv.HandleJSON(biligotest.BiliVcURL, "user_ex/v1/user/detail", -404, "啥都木有", nil)
+	if _, err = c.GetUserEx(2); !IsNotFound(err) {
+		t.Errorf("expected *APIError, got %v", err)
+	}
+}
+func TestOffline_BiliClient(t *testing.T) {
+	b, srv := newFakeBiliClient(t)
+	if b.Me.MID != 2 {
+		t.Errorf("%+v", b.Me)
+	}
+	srv.HandleFunc(biligotest.BiliApiURL, "x/web-interface/archive/like", func(w http.ResponseWriter, r *http.Request) {
+		biligotest.WriteJSON(w, 0, "0", nil)
+	})
+	if err := b.VideoAddLike(170001, true); err != nil {
+		t.Fatal(err)
+	}
+	r := srv.LastRequest()
+	if r.Method != "POST" || r.Form.Get("csrf") != "fake_bili_jct" || r.Form.Get("like") != "1" {
+		t.Errorf("%+v", r)
+	}
+	if r.Header.Get("Cookie") == "" {
+		t.Error("cookie not sent")
+	}
+}
+func TestOffline_DanmakuGetAll(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+
+	var (
+		mu             sync.Mutex
+		segs           []int
+		inflight, peak int
+	)
+	srv.HandleFunc(biligotest.BiliApiURL, "x/v2/dm/web/seg.so", func(w http.ResponseWriter, r *http.Request) {
+		seg, _ := strconv.Atoi(r.URL.Query().Get("segment_index"))
+		mu.Lock()
+		segs = append(segs, seg)
+		if inflight++; inflight > peak {
+			peak = inflight
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inflight--
+			mu.Unlock()
+		}()
+		// 每个分包倒序返回，且包含上一个分包的最后一条
+		progress := int32(seg * 360000)
+		reply := &dm.DmSegMobileReply{Elems: []*dm.DanmakuElem{
+			{Id: int64(seg*10 + 1), Progress: progress - 1000},
+			{Id: int64(seg * 10), Progress: progress - 2000},
+			{Id: int64((seg-1)*10 + 1), Progress: progress - 360000 - 1000},
+		}}
+		raw, err := proto.Marshal(reply)
+		if err != nil {
+			t.Error(err)
+		}
+		_, _ = w.Write(raw)
+	})
+
+	// 1180秒 共4个分包
+	d, err := c.DanmakuGetAll(170001, 279786)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segs) != 4 {
+		t.Errorf("segs: %v", segs)
+	}
+	if len(d.Danmaku) != 9 {
+		t.Errorf("len: %d", len(d.Danmaku))
+	}
+	for i := 1; i < len(d.Danmaku); i++ {
+		if d.Danmaku[i-1].Progress > d.Danmaku[i].Progress || d.Danmaku[i-1].ID == d.Danmaku[i].ID {
+			t.Errorf("%d: %+v %+v", i, d.Danmaku[i-1], d.Danmaku[i])
+		}
+	}
+
+	if _, err = c.DanmakuGetAll(170001, 1); err == nil {
+		t.Error("expected error for unknown cid")
+	}
+
+	// 限制为1时依次请求
+	segs, peak = nil, 0
+	if _, err = c.DanmakuGetAllWithOption(context.Background(), 170001, 279786, &DanmakuGetAllOption{Concurrency: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if len(segs) != 4 || peak != 1 {
+		t.Errorf("segs: %v peak: %d", segs, peak)
+	}
+}
+func TestOffline_SearchAll(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+	srv.HandleJSON(biligotest.BiliApiURL, "x/web-interface/wbi/search/all/v2", 0, "0", map[string]interface{}{
+		"seid":     "123",
+		"page":     1,
+		"pagesize": 20,
+		"numPages": 50,
+		"pageinfo": map[string]interface{}{"video": map[string]interface{}{"numResults": 1000, "pages": 50}},
+		"result": []map[string]interface{}{
+			{"result_type": "tips", "data": []interface{}{}},
+			{"result_type": "media_bangumi", "data": []map[string]interface{}{{"type": "media_bangumi", "media_id": 1, "title": `<em class="keyword">某科学</em>的超电磁炮`}}},
+			{"result_type": "media_ft", "data": []map[string]interface{}{{"type": "media_ft", "media_id": 2}}},
+			{"result_type": "bili_user", "data": []map[string]interface{}{{"type": "bili_user", "mid": 2, "uname": "碧诗", "res": []map[string]interface{}{{"aid": 1}}}}},
+			{"result_type": "video", "data": []map[string]interface{}{{"type": "video", "aid": 170001, "title": `<em class="keyword">保加利亚</em>妖王`}}},
+		},
+	})
+	r, err := c.SearchAll("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.SEID != "123" || r.NumPages != 50 || r.PageInfo.Video.NumResults != 1000 {
+		t.Errorf("%+v", r)
+	}
+	res := r.Result
+	if len(res.Video) != 1 || len(res.Media) != 2 || res.Media[1].Type != SearchTypeFT || len(res.User) != 1 || len(res.User[0].Res) != 1 {
+		t.Fatalf("%+v", res)
+	}
+	res.StripHighlight()
+	if res.Video[0].Title != "保加利亚妖王" || res.Media[0].Title != "某科学的超电磁炮" {
+		t.Errorf("%s %s", res.Video[0].Title, res.Media[0].Title)
+	}
+	if q := srv.LastRequest().Query; q.Get("keyword") != "test" || q.Get("w_rid") == "" {
+		t.Errorf("%v", q)
+	}
+}
+func TestOffline_SearchIter(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+	srv.HandleFunc(biligotest.BiliApiURL, "x/web-interface/wbi/search/type", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("search_type") != SearchTypeLiveRoom || q.Get("order") != "online" || q.Get("tids") != "" {
+			t.Errorf("%v", q)
+		}
+		pn, _ := strconv.Atoi(q.Get("page"))
+		biligotest.WriteJSON(w, 0, "0", map[string]interface{}{
+			"page":     pn,
+			"numPages": 2,
+			"result":   []map[string]interface{}{{"type": "live_room", "roomid": pn*10 + 1}, {"type": "live_room", "roomid": pn*10 + 2}},
+		})
+	})
+
+	it := c.SearchIter("test", SearchTypeLiveRoom, "online", 0, 0, nil)
+	var rooms []int64
+	for it.Next() {
+		if it.Video() != nil {
+			t.Error("unexpected video")
+		}
+		rooms = append(rooms, it.LiveRoom().RoomID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rooms) != 4 || rooms[0] != 11 || rooms[3] != 22 {
+		t.Errorf("%v", rooms)
+	}
+}
+func TestOffline_DynaPublishDraft(t *testing.T) {
+	b, srv := newFakeBiliClient(t)
+	srv.HandleJSON(biligotest.BiliVcURL, "dynamic_draft/v1/dynamic_draft/publish_now", 0, "0", map[string]interface{}{
+		"dynamic_id": 0,
+		"create_ec":  2201116,
+	})
+	_, err := b.DynaPublishDraft(380073)
+	if code, ok := ErrorCode(err); !ok || code != 2201116 {
+		t.Fatalf("%v", err)
+	}
+	var e *APIError
+	if !errors.As(err, &e) || e.Endpoint != "dynamic_draft/v1/dynamic_draft/publish_now" {
+		t.Errorf("%#v", err)
+	}
+}
@@ -0,0 +1,253 @@
+package biligo
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iyear/biligo/biligotest"
+)
+
+// newHTTPProxy 本地HTTP代理，status不为0时直接返回该状态码
+func newHTTPProxy(t *testing.T, status int) (*url.URL, *int32) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+		out := r.Clone(r.Context())
+		out.RequestURI = ""
+		resp, err := http.DefaultTransport.RoundTrip(out)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		for k, v := range resp.Header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	}))
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	return u, &hits
+}
+
+// newSocks5Proxy 本地SOCKS5代理，只支持无认证的CONNECT
+func newSocks5Proxy(t *testing.T) (*url.URL, *int32) {
+	var hits int32
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&hits, 1)
+			go serveSocks5(conn)
+		}
+	}()
+	return &url.URL{Scheme: "socks5", Host: ln.Addr().String()}, &hits
+}
+
+func serveSocks5(conn net.Conn) {
+	defer conn.Close()
+	buf := make([]byte, 262)
+	// 版本与认证方法
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil {
+		return
+	}
+	_, _ = conn.Write([]byte{5, 0})
+	// VER CMD RSV ATYP
+	if _, err := io.ReadFull(conn, buf[:4]); err != nil || buf[1] != 1 {
+		return
+	}
+	var host string
+	switch buf[3] {
+	case 1:
+		if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+			return
+		}
+		host = net.IP(buf[:4]).String()
+	case 3:
+		if _, err := io.ReadFull(conn, buf[:1]); err != nil {
+			return
+		}
+		n := int(buf[0])
+		if _, err := io.ReadFull(conn, buf[:n]); err != nil {
+			return
+		}
+		host = string(buf[:n])
+	default:
+		return
+	}
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return
+	}
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(buf[:2])))))
+	if err != nil {
+		_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	go func() { _, _ = io.Copy(target, conn) }()
+	_, _ = io.Copy(conn, target)
+}
+
+// deadProxy 没有监听的地址，连接会失败
+func deadProxy(t *testing.T) *url.URL {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+	return &url.URL{Scheme: "http", Host: addr}
+}
+
+func TestOffline_ProxyPool(t *testing.T) {
+	httpProxy, httpHits := newHTTPProxy(t, 0)
+	socksProxy, socksHits := newSocks5Proxy(t)
+	riskProxy, riskHits := newHTTPProxy(t, http.StatusPreconditionFailed)
+	dead := deadProxy(t)
+
+	var (
+		mu  sync.Mutex
+		bad []string
+	)
+	pool, err := NewProxyPool([]string{dead.String(), httpProxy.String(), riskProxy.String(), socksProxy.String()}, &ProxyPoolOption{
+		Cooldown: time.Minute,
+		OnBad: func(proxy *url.URL, err error) {
+			mu.Lock()
+			bad = append(bad, proxy.Host)
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, srv := newFakeCommClient(t)
+	c.proxy = pool
+	c.retry = &RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond}
+	srv.HandleJSON(biligotest.BiliApiURL, "x/proxy", 0, "0", map[string]int{"n": 1})
+
+	// dead连接失败，httpProxy成功
+	if _, err = c.RawParse(BiliApiURL, "x/proxy", "GET", nil); err != nil {
+		t.Fatal(err)
+	}
+	// riskProxy返回412，socksProxy成功
+	if _, err = c.RawParse(BiliApiURL, "x/proxy", "GET", nil); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(httpHits) != 1 || atomic.LoadInt32(riskHits) != 1 || atomic.LoadInt32(socksHits) != 1 {
+		t.Errorf("http %d risk %d socks %d", *httpHits, *riskHits, *socksHits)
+	}
+	if len(bad) != 2 || bad[0] != dead.Host || bad[1] != riskProxy.Host {
+		t.Errorf("%v", bad)
+	}
+	if a := pool.Available(); len(a) != 2 {
+		t.Errorf("%v", a)
+	}
+
+	// 冷却中的代理不再使用
+	for i := 0; i < 4; i++ {
+		if _, err = c.RawParse(BiliApiURL, "x/proxy", "GET", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if atomic.LoadInt32(httpHits) != 3 || atomic.LoadInt32(riskHits) != 1 {
+		t.Errorf("http %d risk %d", *httpHits, *riskHits)
+	}
+
+	if _, err = NewProxyPool([]string{"ftp://127.0.0.1"}, nil); err == nil {
+		t.Error("expected error for unsupported scheme")
+	}
+	if p, err := NewProxyPool([]string{"socks5h://127.0.0.1:1080"}, nil); err != nil || p.Available()[0].Scheme != "socks5" {
+		t.Errorf("socks5h: %v", err)
+	}
+}
+
+func TestOffline_ProxyPool_Sticky(t *testing.T) {
+	p1, hits1 := newHTTPProxy(t, 0)
+	p2, hits2 := newHTTPProxy(t, 0)
+	now := time.Unix(1700000000, 0)
+	pool, err := NewProxyPool([]string{p1.String(), p2.String()}, &ProxyPoolOption{Sticky: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.now = func() time.Time { return now }
+
+	srv := biligotest.NewServer()
+	t.Cleanup(srv.Close)
+	newClient := func(id string) *BiliClient {
+		b, err := NewBiliClient(&BiliSetting{
+			Auth:      &CookieAuth{DedeUserID: id, SESSDATA: "fake_sessdata", BiliJCT: "fake_bili_jct"},
+			Endpoints: srv.Endpoints(),
+			Proxy:     pool,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	// 创建时 GetMe 分别使用p1、p2
+	a, b := newClient("1"), newClient("2")
+	for i := 0; i < 3; i++ {
+		if _, err = a.GetMe(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = b.GetMe(); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(hits1) != 4 || atomic.LoadInt32(hits2) != 2 {
+		t.Fatalf("p1 %d p2 %d", *hits1, *hits2)
+	}
+
+	// p1不可用后账号1改用p2，冷却结束后保持在p2
+	pool.MarkBad(p1, ErrRateLimited)
+	if _, err = a.GetMe(); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(10 * time.Minute)
+	if _, err = a.GetMe(); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(hits1) != 4 || atomic.LoadInt32(hits2) != 4 {
+		t.Errorf("p1 %d p2 %d", *hits1, *hits2)
+	}
+
+	// 自定义RoundTripper时无法设置代理
+	a.SetClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return http.DefaultTransport.RoundTrip(r)
+	})})
+	if _, err = a.GetMe(); err == nil {
+		t.Error("expected error for custom RoundTripper")
+	}
+}
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
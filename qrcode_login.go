@@ -0,0 +1,273 @@
+package biligo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// ErrQRCodeExpired 二维码已过期且没有剩余的刷新次数
+var ErrQRCodeExpired = errors.New("qrcode expired")
+
+// QRCodeRender 二维码在终端中的字符样式
+type QRCodeRender int
+
+const (
+	QRCodeRenderHalfBlock QRCodeRender = iota // UTF-8半角方块，上下两个模块合为一个字符，尺寸较小
+	QRCodeRenderASCII                         // 每个模块占两个ASCII字符，兼容不支持UTF-8的终端
+)
+
+// QRCodeString 将content渲染为可在终端中显示的二维码文本
+//
+// 默认黑色模块为实心字符，适用于浅色背景的终端，深色背景的终端需要设置inverse
+func QRCodeString(content string, render QRCodeRender, inverse bool) (string, error) {
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return "", err
+	}
+	bitmap := q.Bitmap()
+	dark := func(y, x int) bool {
+		if y >= len(bitmap) {
+			return inverse
+		}
+		return bitmap[y][x] != inverse
+	}
+
+	var sb strings.Builder
+	switch render {
+	case QRCodeRenderASCII:
+		for y := range bitmap {
+			for x := range bitmap[y] {
+				if dark(y, x) {
+					sb.WriteString("##")
+				} else {
+					sb.WriteString("  ")
+				}
+			}
+			sb.WriteByte('\n')
+		}
+	case QRCodeRenderHalfBlock:
+		for y := 0; y < len(bitmap); y += 2 {
+			for x := range bitmap[y] {
+				switch top, bottom := dark(y, x), dark(y+1, x); {
+				case top && bottom:
+					sb.WriteString("█")
+				case top:
+					sb.WriteString("▀")
+				case bottom:
+					sb.WriteString("▄")
+				default:
+					sb.WriteByte(' ')
+				}
+			}
+			sb.WriteByte('\n')
+		}
+	default:
+		return "", fmt.Errorf("unknown qrcode render: %d", render)
+	}
+	return sb.String(), nil
+}
+
+// QRCodePNG 将content渲染为size*size的PNG图片写入w，size<=0时为256
+func QRCodePNG(w io.Writer, content string, size int) error {
+	if size <= 0 {
+		size = 256
+	}
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return err
+	}
+	return q.Write(size, w)
+}
+
+// QRCodeLoginState 扫码登录状态
+type QRCodeLoginState int
+
+const (
+	QRCodeLoginUnscanned QRCodeLoginState = iota // 已生成二维码，等待扫码
+	QRCodeLoginScanned                           // 已扫码，等待在手机上确认
+	QRCodeLoginExpired                           // 二维码已过期
+	QRCodeLoginConfirmed                         // 已确认登录
+)
+
+func (s QRCodeLoginState) String() string {
+	switch s {
+	case QRCodeLoginUnscanned:
+		return "unscanned"
+	case QRCodeLoginScanned:
+		return "scanned"
+	case QRCodeLoginExpired:
+		return "expired"
+	case QRCodeLoginConfirmed:
+		return "confirmed"
+	}
+	return fmt.Sprintf("QRCodeLoginState(%d)", int(s))
+}
+
+// 网页版二维码轮询返回的状态码
+const (
+	qrcodePollConfirmed = 0
+	qrcodePollExpired   = 86038
+	qrcodePollScanned   = 86090
+	qrcodePollUnscanned = 86101
+)
+
+// QRCodeLoginEvent 扫码登录状态变化
+type QRCodeLoginEvent struct {
+	State   QRCodeLoginState
+	URL     string // 当前二维码的内容
+	Message string // 服务端返回的提示信息
+}
+
+// QRCodeLoginOption 扫码登录设置
+type QRCodeLoginOption struct {
+	// 二维码以文本形式输出到Terminal，如 os.Stdout
+	//
+	// Terminal、PNG、OnQRCode 均未设置时输出到 os.Stdout
+	Terminal io.Writer
+	// 终端字符样式，默认 QRCodeRenderHalfBlock
+	Render QRCodeRender
+	// 反色，深色背景的终端需要设置
+	Inverse bool
+
+	// 二维码以PNG图片写入PNG，二维码刷新后会再次写入
+	PNG io.Writer
+	// PNG图片尺寸，默认256
+	PNGSize int
+
+	// 自定义渲染，参数为二维码内容，返回错误时终止登录
+	OnQRCode func(url string) error
+
+	// 状态变化时调用，每个二维码依次经过 QRCodeLoginUnscanned、QRCodeLoginScanned，
+	// 以 QRCodeLoginConfirmed 或 QRCodeLoginExpired 结束
+	OnEvent func(e *QRCodeLoginEvent)
+
+	// 轮询间隔，默认2s
+	Interval time.Duration
+	// 二维码过期后重新生成的次数，默认0，过期后返回 ErrQRCodeExpired
+	MaxRefresh int
+
+	// 创建 BiliClient 的设置，Auth 会被忽略，可为nil
+	//
+	// 为nil时沿用 CommClient 的http client、UA、Logger、限流、重试与代理设置。
+	// 设置 BiliSetting.Store 时登录信息在登录成功后自动保存
+	Setting *BiliSetting
+}
+
+// LoginByQRCode 网页版扫码登录
+//
+// 生成二维码并按opt渲染，轮询扫码状态直到确认登录，返回使用该登录信息创建的 BiliClient ，opt可为nil
+//
+// ctx 用于取消登录
+func (c *CommClient) LoginByQRCode(ctx context.Context, opt *QRCodeLoginOption) (*BiliClient, error) {
+	if opt == nil {
+		opt = &QRCodeLoginOption{}
+	}
+	interval := opt.Interval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	emit := func(state QRCodeLoginState, url, msg string) {
+		if opt.OnEvent != nil {
+			opt.OnEvent(&QRCodeLoginEvent{State: state, URL: url, Message: msg})
+		}
+	}
+
+	for refresh := 0; ; refresh++ {
+		gen, err := c.WebQRCodeGenerateWithContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err = c.renderQRCode(opt, gen.Url); err != nil {
+			return nil, err
+		}
+		emit(QRCodeLoginUnscanned, gen.Url, "")
+
+		state := QRCodeLoginUnscanned
+		for state != QRCodeLoginExpired {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(interval):
+			}
+			r, err := c.WebQRCodePoolWithContext(ctx, gen.QrcodeKey)
+			if err != nil {
+				return nil, err
+			}
+			switch r.Code {
+			case qrcodePollUnscanned:
+				continue
+			case qrcodePollScanned:
+				if state != QRCodeLoginScanned {
+					state = QRCodeLoginScanned
+					emit(state, gen.Url, r.Message)
+				}
+			case qrcodePollExpired:
+				state = QRCodeLoginExpired
+				emit(state, gen.Url, r.Message)
+			case qrcodePollConfirmed:
+				emit(QRCodeLoginConfirmed, gen.Url, r.Message)
+				return c.qrcodeLoginClient(ctx, opt, r.GetCookieAuth())
+			default:
+				return nil, fmt.Errorf("qrcode poll: unexpected code %d: %s", r.Code, r.Message)
+			}
+		}
+		if refresh >= opt.MaxRefresh {
+			return nil, ErrQRCodeExpired
+		}
+	}
+}
+
+func (c *CommClient) renderQRCode(opt *QRCodeLoginOption, url string) error {
+	term := opt.Terminal
+	if term == nil && opt.PNG == nil && opt.OnQRCode == nil {
+		term = os.Stdout
+	}
+	if term != nil {
+		s, err := QRCodeString(url, opt.Render, opt.Inverse)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(term, s); err != nil {
+			return err
+		}
+	}
+	if opt.PNG != nil {
+		if err := QRCodePNG(opt.PNG, url, opt.PNGSize); err != nil {
+			return err
+		}
+	}
+	if opt.OnQRCode != nil {
+		return opt.OnQRCode(url)
+	}
+	return nil
+}
+
+func (c *CommClient) qrcodeLoginClient(ctx context.Context, opt *QRCodeLoginOption, auth *CookieAuth) (*BiliClient, error) {
+	if auth == nil || auth.SESSDATA == "" {
+		return nil, errors.New("qrcode login: missing cookies in response")
+	}
+	var s BiliSetting
+	if opt.Setting != nil {
+		s = *opt.Setting
+	} else {
+		s = BiliSetting{
+			Client:    c.client,
+			UserAgent: c.ua,
+			Logger:    c.logger,
+			Wbi:       c.wbi,
+			RateLimit: c.limit,
+			Retry:     c.retry,
+			Endpoints: c.endpoints,
+			Proxy:     c.proxy,
+		}
+	}
+	s.Auth = auth
+	return newBiliClient(ctx, &s, true)
+}
@@ -0,0 +1,126 @@
+package biligo
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iyear/biligo/biligotest"
+)
+
+func TestQRCodeString(t *testing.T) {
+	ascii, err := QRCodeString("https://www.bilibili.com", QRCodeRenderASCII, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(ascii, "\n"), "\n")
+	// 25*25的二维码加上两侧各4个模块的空白
+	if len(lines) != 33 || len(lines[0]) != 66 || strings.Trim(ascii, "# \n") != "" {
+		t.Fatalf("%d lines\n%s", len(lines), ascii)
+	}
+
+	half, err := QRCodeString("https://www.bilibili.com", QRCodeRenderHalfBlock, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines = strings.Split(strings.TrimSuffix(half, "\n"), "\n")
+	if len(lines) != 17 || len([]rune(lines[0])) != 33 {
+		t.Fatalf("%d lines\n%s", len(lines), half)
+	}
+	// 反色时空白区域为实心
+	if lines[0] != strings.Repeat("█", 33) {
+		t.Errorf("%q", lines[0])
+	}
+
+	if _, err = QRCodeString("x", QRCodeRender(9), false); err == nil {
+		t.Error("expected error for unknown render")
+	}
+}
+
+func TestOffline_LoginByQRCode(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+
+	var keys []string
+	srv.HandleFunc(biligotest.BiliPassportURL, "x/passport-login/web/qrcode/generate", func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, "key"+string(rune('0'+len(keys))))
+		biligotest.WriteJSON(w, 0, "0", map[string]interface{}{
+			"url":        "https://passport.bilibili.com/h5-app/passport/login/scan?qrcode_key=" + keys[len(keys)-1],
+			"qrcode_key": keys[len(keys)-1],
+		})
+	})
+	// 第一个二维码过期，第二个二维码扫码后确认
+	polls := map[string][]int{
+		"key0": {qrcodePollUnscanned, qrcodePollExpired},
+		"key1": {qrcodePollUnscanned, qrcodePollScanned, qrcodePollScanned, qrcodePollConfirmed},
+	}
+	srv.HandleFunc(biligotest.BiliPassportURL, "x/passport-login/web/qrcode/poll", func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("qrcode_key")
+		code := polls[key][0]
+		polls[key] = polls[key][1:]
+		data := map[string]interface{}{"code": code, "message": "msg"}
+		if code == qrcodePollConfirmed {
+			data["url"] = "https://passport.biligame.com/crossDomain?DedeUserID=2&DedeUserID__ckMd5=ck&SESSDATA=fake_sessdata&bili_jct=fake_bili_jct"
+			data["refresh_token"] = "fake_refresh_token"
+		}
+		biligotest.WriteJSON(w, 0, "0", data)
+	})
+
+	var (
+		term, img bytes.Buffer
+		urls      []string
+		states    []QRCodeLoginState
+	)
+	store := NewMemoryCredentialStore()
+	opt := &QRCodeLoginOption{
+		Terminal:   &term,
+		PNG:        &img,
+		OnQRCode:   func(url string) error { urls = append(urls, url); return nil },
+		OnEvent:    func(e *QRCodeLoginEvent) { states = append(states, e.State) },
+		Interval:   time.Millisecond,
+		MaxRefresh: 1,
+		Setting:    &BiliSetting{Endpoints: srv.Endpoints(), Store: store},
+	}
+	b, err := c.LoginByQRCode(context.Background(), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Me.MID == 0 || b.GetCookieAuth().RefreshToken != "fake_refresh_token" {
+		t.Errorf("%+v %+v", b.Me, b.GetCookieAuth())
+	}
+	want := []QRCodeLoginState{QRCodeLoginUnscanned, QRCodeLoginExpired, QRCodeLoginUnscanned, QRCodeLoginScanned, QRCodeLoginConfirmed}
+	if len(states) != len(want) {
+		t.Fatalf("%v", states)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("%v", states)
+		}
+	}
+	if len(urls) != 2 || !strings.HasSuffix(urls[1], "key1") || !strings.Contains(term.String(), "█") {
+		t.Errorf("%v\n%s", urls, term.String())
+	}
+	if _, err = png.Decode(&img); err != nil {
+		t.Error(err)
+	}
+	if a, err := store.Load("2"); err != nil || a.SESSDATA != "fake_sessdata" {
+		t.Errorf("%+v %v", a, err)
+	}
+
+	// 没有刷新次数时返回 ErrQRCodeExpired
+	polls["key2"] = []int{qrcodePollExpired}
+	opt.MaxRefresh = 0
+	if _, err = c.LoginByQRCode(context.Background(), opt); err != ErrQRCodeExpired {
+		t.Errorf("expected ErrQRCodeExpired, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	polls["key3"] = []int{qrcodePollUnscanned}
+	if _, err = c.LoginByQRCode(ctx, opt); err == nil {
+		t.Error("expected error for canceled context")
+	}
+}
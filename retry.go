@@ -0,0 +1,137 @@
+package biligo
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// RetryPolicy 请求重试策略
+//
+// GET/HEAD 请求在网络错误、HTTP 5xx/412/429 以及 Codes 中的业务错误码时重试。
+// 其他请求(如评论、投币、发送弹幕)重复发送会产生重复操作，只在确定请求没有发出(如建立连接失败)时重试，
+// 可使用 WithRetryNonIdempotent 对单次调用启用与 GET 相同的重试
+//
+// 重试间隔为指数退避加随机抖动：[d/2, d]，d = min(MaxDelay, BaseDelay * 2^(n-1))
+type RetryPolicy struct {
+	// MaxRetries 最大重试次数 0:不重试
+	MaxRetries int
+	// BaseDelay 首次重试间隔 默认500ms
+	BaseDelay time.Duration
+	// MaxDelay 最大重试间隔 默认30s
+	MaxDelay time.Duration
+	// Codes 需要重试的业务错误码 默认 -412 -509 -799
+	Codes []int
+	// OnRetry 每次重试等待前调用
+	OnRetry func(e *RetryEvent)
+}
+
+// RetryEvent 重试信息
+type RetryEvent struct {
+	Attempt    int           // 第几次重试 从1开始
+	Method     string        // 请求方法
+	URL        string        // 请求链接 不含参数
+	Err        error         // 网络错误 没有时为nil
+	StatusCode int           // HTTP状态码 网络错误时为0
+	Code       int           // 响应中的业务错误码
+	Delay      time.Duration // 本次重试前的等待时间
+}
+
+var defaultRetryCodes = []int{-412, -509, -799}
+
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	base, max := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = 500 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 30 * time.Second
+	}
+	d := base
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+type retryNonIdempotentKey struct{}
+
+// WithRetryNonIdempotent 返回的ctx用于 XxxWithContext 调用时，POST 等请求也按 GET 的规则重试
+//
+// 只应用于重复发送没有副作用的请求
+func WithRetryNonIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryNonIdempotentKey{}, true)
+}
+
+// idempotent 请求是否可以在响应丢失或出错后重复发送
+func idempotent(ctx context.Context, method string) bool {
+	if method == "" || method == http.MethodGet || method == http.MethodHead {
+		return true
+	}
+	v, _ := ctx.Value(retryNonIdempotentKey{}).(bool)
+	return v
+}
+
+// notSent 错误是否发生在建立连接阶段，此时请求一定没有到达服务器
+func notSent(err error) bool {
+	var op *net.OpError
+	return errors.As(err, &op) && (op.Op == "dial" || op.Op == "proxyconnect")
+}
+
+// shouldRetry 判断请求结果是否需要重试，返回事件信息
+func (p *RetryPolicy) shouldRetry(ctx context.Context, method string, status int, raw []byte, err error) (*RetryEvent, bool) {
+	if ctx.Err() != nil {
+		return nil, false
+	}
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, false
+		}
+		if !idempotent(ctx, method) && !notSent(err) {
+			return nil, false
+		}
+		return &RetryEvent{Err: err}, true
+	}
+	if !idempotent(ctx, method) {
+		return nil, false
+	}
+	e := &RetryEvent{StatusCode: status}
+	if status >= 500 || status == http.StatusPreconditionFailed || status == http.StatusTooManyRequests {
+		return e, true
+	}
+	// 响应不是JSON时(如protobuf接口)取不到code
+	if !gjson.ValidBytes(raw) {
+		return nil, false
+	}
+	e.Code = int(gjson.GetBytes(raw, "code").Int())
+	codes := p.Codes
+	if codes == nil {
+		codes = defaultRetryCodes
+	}
+	for _, c := range codes {
+		if c != 0 && c == e.Code {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// sleep 等待d或ctx结束
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
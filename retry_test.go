@@ -0,0 +1,102 @@
+package biligo
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBaseClient_Retry(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) != "aid=1" {
+			t.Errorf("body: %s", body)
+		}
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			_, _ = w.Write([]byte(`{"code":-412,"message":"请求被拦截"}`))
+		default:
+			_, _ = w.Write([]byte(`{"code":0,"message":"0","data":1}`))
+		}
+	}))
+	defer srv.Close()
+
+	var events []*RetryEvent
+	c := NewCommClient(&CommSetting{
+		Retry: &RetryPolicy{
+			MaxRetries: 3,
+			BaseDelay:  time.Millisecond,
+			OnRetry:    func(e *RetryEvent) { events = append(events, e) },
+		},
+	})
+	// 需要显式启用POST重试，重试时重新发送body
+	resp, err := c.RawParseWithContext(WithRetryNonIdempotent(context.Background()), srv.URL+"/", "x/test", "POST", map[string]string{"aid": "1"})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if string(resp.Data) != "1" || calls != 3 || len(events) != 2 {
+		t.Errorf("calls: %d,events: %d", calls, len(events))
+		t.FailNow()
+	}
+	if events[0].StatusCode != http.StatusBadGateway || events[1].Code != -412 || events[1].Attempt != 2 {
+		t.Errorf("%+v %+v", events[0], events[1])
+	}
+}
+func TestBaseClient_RetryNonIdempotent(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	// 第一次建立连接失败
+	var dials int32
+	transport := &http.Transport{DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if atomic.AddInt32(&dials, 1) == 1 {
+			return nil, &net.OpError{Op: "dial", Net: network, Err: errors.New("connection refused")}
+		}
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}}
+	c := NewCommClient(&CommSetting{
+		Client: &http.Client{Transport: transport},
+		Retry:  &RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond},
+	})
+	// 连接失败时重试，5xx时请求可能已被处理，不重试
+	_, err := c.RawParse(srv.URL+"/", "x/reply/add", "POST", map[string]string{"message": "1"})
+	if err == nil || calls != 1 || dials != 2 {
+		t.Errorf("calls: %d,dials: %d,err: %v", calls, dials, err)
+	}
+}
+func TestBaseClient_RetryExhausted(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_, _ = w.Write([]byte(`{"code":-412,"message":"请求被拦截"}`))
+	}))
+	defer srv.Close()
+
+	c := NewCommClient(&CommSetting{Retry: &RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond}})
+	_, err := c.RawParse(srv.URL+"/", "x/test", "GET", nil)
+	if !IsRateLimited(err) || calls != 3 {
+		t.Errorf("calls: %d,err: %v", calls, err)
+	}
+}
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := &RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt, max := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+		if d := p.delay(attempt); d < max/2 || d > max {
+			t.Errorf("attempt %d: %s", attempt, d)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package biligo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// EncodeSRT 以SRT格式写入字幕，序号从1开始
+func (s *Subtitle) EncodeSRT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for i, c := range s.Body {
+		fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n\n", i+1, srtTime(c.From, ','), srtTime(c.To, ','), cueText(c.Content))
+	}
+	return bw.Flush()
+}
+
+// EncodeWebVTT 以WebVTT格式写入字幕
+//
+// 位置不为底部的字幕使用 line 设置显示在顶部
+func (s *Subtitle) EncodeWebVTT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("WEBVTT\n\n")
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	for _, c := range s.Body {
+		fmt.Fprintf(bw, "%s --> %s", srtTime(c.From, '.'), srtTime(c.To, '.'))
+		if c.Location >= 7 {
+			bw.WriteString(" line:0")
+		}
+		fmt.Fprintf(bw, "\n%s\n\n", escape.Replace(cueText(c.Content)))
+	}
+	return bw.Flush()
+}
+
+// SubtitleASSOption ASS字幕输出设置
+type SubtitleASSOption struct {
+	// Width Height 画面分辨率 默认1920x1080
+	Width  int
+	Height int
+	// FontName 字体 默认 Microsoft YaHei
+	FontName string
+	// FontSize 字号 默认为画面高度的1/20
+	FontSize float64
+	// MarginV 距画面底部的距离 默认为画面高度的1/20
+	MarginV int
+}
+
+// EncodeASS 以ASS字幕格式写入字幕，Location 转为 \an 对齐方式
+//
+// opt可为nil
+func (s *Subtitle) EncodeASS(w io.Writer, opt *SubtitleASSOption) error {
+	o := SubtitleASSOption{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.Width <= 0 || o.Height <= 0 {
+		o.Width, o.Height = 1920, 1080
+	}
+	if o.FontName == "" {
+		o.FontName = "Microsoft YaHei"
+	}
+	if o.FontSize <= 0 {
+		o.FontSize = float64(o.Height) / 20
+	}
+	if o.MarginV <= 0 {
+		o.MarginV = o.Height / 20
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "[Script Info]\n")
+	fmt.Fprintf(bw, "ScriptType: v4.00+\n")
+	fmt.Fprintf(bw, "PlayResX: %d\n", o.Width)
+	fmt.Fprintf(bw, "PlayResY: %d\n", o.Height)
+	fmt.Fprintf(bw, "ScaledBorderAndShadow: yes\n\n")
+	fmt.Fprintf(bw, "[V4+ Styles]\n")
+	fmt.Fprintf(bw, "Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding\n")
+	fmt.Fprintf(bw, "Style: Default,%s,%.0f,&H00FFFFFF,&H00FFFFFF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,2,0,2,20,20,%d,1\n\n",
+		o.FontName, o.FontSize, o.MarginV)
+	fmt.Fprintf(bw, "[Events]\n")
+	fmt.Fprintf(bw, "Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
+	for _, c := range s.Body {
+		text := assEscape(c.Content)
+		if c.Location > 0 && c.Location <= 9 && c.Location != 2 {
+			text = fmt.Sprintf(`{\an%d}`, c.Location) + text
+		}
+		fmt.Fprintf(bw, "Dialogue: 0,%s,%s,Default,,0,0,0,,%s\n", assTime(c.From), assTime(c.To), text)
+	}
+	return bw.Flush()
+}
+
+// srtTime 秒 -> HH:MM:SS,mmm，WebVTT的毫秒分隔符为 .
+func srtTime(sec float64, sep byte) string {
+	ms := int64(math.Round(sec * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// cueText 去除空行，SRT与WebVTT中空行表示字幕条结束
+func cueText(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	r := lines[:0]
+	for _, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			r = append(r, l)
+		}
+	}
+	return strings.Join(r, "\n")
+}
+
+var srtTimeLine = regexp.MustCompile(`^(\d+):(\d{2}):(\d{2})[,.](\d{1,3})\s*-->\s*(\d+):(\d{2}):(\d{2})[,.](\d{1,3})`)
+
+// DecodeSubtitleSRT 读取SRT字幕，Location 均为2
+//
+// 兼容没有序号、使用 . 作为毫秒分隔符以及带BOM的文件
+func DecodeSubtitleSRT(r io.Reader) (*Subtitle, error) {
+	s := &Subtitle{}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var (
+		cur  *SubtitleCue
+		text []string
+		line int
+	)
+	flush := func() {
+		if cur != nil {
+			cur.Content = strings.Join(text, "\n")
+			s.Body = append(s.Body, cur)
+		}
+		cur, text = nil, nil
+	}
+	for sc.Scan() {
+		line++
+		l := strings.TrimRight(sc.Text(), "\r")
+		if line == 1 {
+			l = strings.TrimPrefix(l, "\ufeff")
+		}
+		if cur == nil {
+			if m := srtTimeLine.FindStringSubmatch(l); m != nil {
+				cur = &SubtitleCue{
+					From:     srtSeconds(m[1:5]),
+					To:       srtSeconds(m[5:9]),
+					SID:      len(s.Body) + 1,
+					Location: 2,
+				}
+				continue
+			}
+			// 序号或多余的空行
+			if _, err := strconv.Atoi(strings.TrimSpace(l)); err == nil || strings.TrimSpace(l) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("srt line %d: unexpected %q", line, l)
+		}
+		if strings.TrimSpace(l) == "" {
+			flush()
+			continue
+		}
+		text = append(text, l)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return s, nil
+}
+
+// srtSeconds 时 分 秒 毫秒 -> 秒
+func srtSeconds(p []string) float64 {
+	var v [4]int64
+	for i, s := range p {
+		v[i], _ = strconv.ParseInt(s, 10, 64)
+	}
+	// 毫秒不足三位时按小数处理 如 ,5 为500ms
+	for i := len(p[3]); i < 3; i++ {
+		v[3] *= 10
+	}
+	return float64(v[0]*3600000+v[1]*60000+v[2]*1000+v[3]) / 1000
+}
@@ -0,0 +1,129 @@
+package biligo
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/iyear/biligo/biligotest"
+)
+
+var testSubtitle = &Subtitle{Body: []*SubtitleCue{
+	{From: 0.5, To: 2.25, Location: 2, Content: "第一行\n第二行"},
+	{From: 3661.001, To: 3662, Location: 8, Content: "a < b & c"},
+}}
+
+func TestSubtitle_EncodeSRT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testSubtitle.EncodeSRT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "1\n00:00:00,500 --> 00:00:02,250\n第一行\n第二行\n\n2\n01:01:01,001 --> 01:01:02,000\na < b & c\n\n"
+	if buf.String() != want {
+		t.Errorf("%q", buf.String())
+	}
+
+	s, err := DecodeSubtitleSRT(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Body) != 2 {
+		t.Fatalf("%+v", s.Body)
+	}
+	for i, c := range s.Body {
+		w := testSubtitle.Body[i]
+		if c.From != w.From || c.To != w.To || c.Content != w.Content || c.SID != i+1 {
+			t.Errorf("%d: %+v", i, c)
+		}
+	}
+}
+
+func TestDecodeSubtitleSRT(t *testing.T) {
+	s, err := DecodeSubtitleSRT(strings.NewReader("\ufeff1\r\n00:00:01.5 --> 00:00:02,000\r\nhello\r\n\r\n\r\n00:00:03,000 --> 00:00:04,000 X1:0\r\nworld\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Body) != 2 || s.Body[0].From != 1.5 || s.Body[0].Content != "hello" || s.Body[1].Content != "world" {
+		t.Errorf("%+v %+v", s.Body[0], s.Body[1])
+	}
+	if _, err = DecodeSubtitleSRT(strings.NewReader("not a subtitle")); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestSubtitle_EncodeWebVTT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testSubtitle.EncodeWebVTT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "WEBVTT\n\n00:00:00.500 --> 00:00:02.250\n第一行\n第二行\n\n01:01:01.001 --> 01:01:02.000 line:0\na &lt; b &amp; c\n\n"
+	if buf.String() != want {
+		t.Errorf("%q", buf.String())
+	}
+}
+
+func TestSubtitle_EncodeASS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testSubtitle.EncodeASS(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"PlayResY: 1080\n",
+		"Dialogue: 0,0:00:00.50,0:00:02.25,Default,,0,0,0,,第一行\\N第二行\n",
+		"Dialogue: 0,1:01:01.00,1:01:02.00,Default,,0,0,0,,{\\an8}a < b & c\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %q in\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestOffline_VideoGetSubtitles(t *testing.T) {
+	c, srv := newFakeCommClient(t)
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"font_size":0.4,"font_color":"#FFFFFF","background_alpha":0.5,"background_color":"#9C27B0","Stroke":"none","body":[{"from":0.0,"to":1.5,"sid":1,"location":2,"content":"` + r.URL.Path + `"}]}`))
+	}))
+	defer files.Close()
+
+	srv.HandleJSON(biligotest.BiliApiURL, "x/player/wbi/v2", 0, "0", map[string]interface{}{
+		"subtitle": map[string]interface{}{
+			"subtitles": []map[string]interface{}{
+				{"id": 1, "lan": "zh-CN", "lan_doc": "中文（中国）", "subtitle_url": files.URL + "/zh.json"},
+				{"id": 2, "lan": "ai-zh", "lan_doc": "中文（自动生成）", "subtitle_url": files.URL + "/ai.json", "type": 1},
+				{"id": 3, "lan": "ai-en", "lan_doc": "English（自动生成）", "subtitle_url": "", "type": 1},
+			},
+		},
+	})
+	tracks, err := c.VideoGetSubtitles(170001, 279786)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracks) != 2 || tracks[1].Lan != "ai-zh" || tracks[1].Type != 1 {
+		t.Fatalf("%+v", tracks)
+	}
+	if b := tracks[0].Subtitle.Body; len(b) != 1 || b[0].Content != "/zh.json" || b[0].To != 1.5 {
+		t.Errorf("%+v", tracks[0].Subtitle)
+	}
+	q := srv.LastRequest().Query
+	if q.Get("cid") != "279786" || q.Get("w_rid") == "" {
+		t.Errorf("%v", q)
+	}
+
+	// 没有字幕的视频不返回subtitles，未登录时AI字幕没有地址
+	for _, data := range []interface{}{
+		map[string]interface{}{"subtitle": map[string]interface{}{"allow_submit": false}},
+		map[string]interface{}{"subtitle": map[string]interface{}{"subtitles": []interface{}{}}},
+		map[string]interface{}{"subtitle": map[string]interface{}{"subtitles": nil}},
+		map[string]interface{}{"subtitle": map[string]interface{}{"subtitles": []map[string]interface{}{
+			{"id": 2, "lan": "ai-zh", "lan_doc": "中文（自动生成）", "subtitle_url": "", "type": 1},
+		}}},
+		map[string]interface{}{},
+	} {
+		srv.HandleJSON(biligotest.BiliApiURL, "x/player/wbi/v2", 0, "0", data)
+		if tracks, err = c.VideoGetSubtitles(170001, 279786); err != nil || tracks == nil || len(tracks) != 0 {
+			t.Errorf("%v: %+v %v", data, tracks, err)
+		}
+	}
+}
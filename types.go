@@ -341,8 +341,9 @@ type FavRes struct {
 	Type int `json:"type"`
 }
 type FavResDetail struct {
-	Info   *FavDetail           `json:"info"`   // 收藏夹元数据
-	Medias []*FavResDetailMedia `json:"medias"` // 收藏夹内容
+	Info    *FavDetail           `json:"info"`     // 收藏夹元数据
+	Medias  []*FavResDetailMedia `json:"medias"`   // 收藏夹内容
+	HasMore bool                 `json:"has_more"` // 是否还有下一页
 }
 type FavResDetailMedia struct {
 	// 内容id
@@ -621,6 +622,32 @@ type VideoSubtitleAuthor struct {
 	IsFakeAccount int    `json:"is_fake_account"` // 恒为0 作用尚不明确
 	IsDeleted     int    `json:"is_deleted"`      // 恒为0 作用尚不明确
 }
+type VideoSubtitleTrack struct {
+	ID          int64     `json:"id"`           // 字幕ID
+	Lan         string    `json:"lan"`          // 字幕语言 如 zh-CN ai-zh
+	LanDoc      string    `json:"lan_doc"`      // 字幕语言名称
+	IsLock      bool      `json:"is_lock"`      // 是否锁定
+	SubtitleURL string    `json:"subtitle_url"` // JSON格式字幕文件URL
+	Type        int       `json:"type"`         // 0:人工字幕 1:AI字幕
+	AIType      int       `json:"ai_type"`      // AI字幕类型
+	AIStatus    int       `json:"ai_status"`    // AI字幕状态
+	Subtitle    *Subtitle `json:"-"`            // 字幕内容 由 SubtitleURL 下载
+}
+type Subtitle struct {
+	FontSize        float64        `json:"font_size"`        // 字号 相对画面高度 如0.4
+	FontColor       string         `json:"font_color"`       // 字体颜色 如#FFFFFF
+	BackgroundAlpha float64        `json:"background_alpha"` // 背景不透明度
+	BackgroundColor string         `json:"background_color"` // 背景颜色
+	Stroke          string         `json:"Stroke"`           // 描边
+	Body            []*SubtitleCue `json:"body"`             // 字幕条
+}
+type SubtitleCue struct {
+	From     float64 `json:"from"`     // 开始时间 单位为秒
+	To       float64 `json:"to"`       // 结束时间 单位为秒
+	SID      int     `json:"sid"`      // 序号 部分字幕没有
+	Location int     `json:"location"` // 位置 同小键盘布局 2:底部居中
+	Content  string  `json:"content"`  // 内容 可能包含换行
+}
 type VideoStaff struct {
 	MID      int64               `json:"mid"`      // 成员MID
 	Title    string              `json:"title"`    // 成员名称
@@ -753,21 +780,34 @@ type VideoShot struct {
 	Index    []int    `json:"index"`      // json数组格式截取时间表 单位为秒
 }
 type SearchAll struct {
-	SEID           string
-	Page           int
-	PageSize       int
-	NumResults     int
-	NumPages       int
-	SuggestKeyword string
-	RqtType        string
-	CostTime       *SearchCostTime
+	SEID           string          `json:"seid"`            // 搜索id
+	Page           int             `json:"page"`            // 当前页码
+	PageSize       int             `json:"pagesize"`        // 每页条数
+	NumResults     int             `json:"numResults"`      // 总条数 最大值为1000
+	NumPages       int             `json:"numPages"`        // 总页数 最大值为50
+	SuggestKeyword string          `json:"suggest_keyword"` // 空 作用尚不明确
+	RqtType        string          `json:"rqt_type"`        // search
+	CostTime       *SearchCostTime `json:"cost_time"`       // 详细搜索用时
 	// ExpList 作用尚不明确
-	EggHit         int
-	PageInfo       *SearchPage
-	TopTlist       *SearchTopTlist
-	ShowColumn     int
-	ShowModuleList []string
-	Result         *SearchResult
+	EggHit         int             `json:"egg_hit"`          // 作用尚不明确
+	PageInfo       *SearchPage     `json:"pageinfo"`         // 各类型结果数
+	TopTlist       *SearchTopTlist `json:"top_tlist"`        // 各类型结果数
+	ShowColumn     int             `json:"show_column"`      // 作用尚不明确
+	ShowModuleList []string        `json:"show_module_list"` // 返回结果类型列表
+	Result         *SearchResult   `json:"-"`                // 各类型的结果
+}
+type SearchTypeResult struct {
+	SEID           string          `json:"seid"`            // 搜索id
+	Page           int             `json:"page"`            // 当前页码
+	PageSize       int             `json:"pagesize"`        // 每页条数
+	NumResults     int             `json:"numResults"`      // 总条数 最大值为1000
+	NumPages       int             `json:"numPages"`        // 总页数 最大值为50
+	SuggestKeyword string          `json:"suggest_keyword"` // 空 作用尚不明确
+	RqtType        string          `json:"rqt_type"`        // search
+	CostTime       *SearchCostTime `json:"cost_time"`       // 详细搜索用时
+	EggHit         int             `json:"egg_hit"`         // 作用尚不明确
+	ShowColumn     int             `json:"show_column"`     // 作用尚不明确
+	Result         *SearchResult   `json:"-"`               // 对应类型的结果
 }
 type SearchCostTime struct {
 	ParamsCheck         string `json:"params_check"`
@@ -834,10 +874,17 @@ type SearchTopTlist struct {
 }
 
 // SearchResult 在原搜索接口进行魔改，方便使用
+//
+// 原接口的综合搜索结果为 [{result_type,data}] 数组，这里按类型展开，没有结果的类型为空
+//
+// 标题等字段中的关键字用xml标签<em class="keyword">标注，可使用 StripHighlight 去除
 type SearchResult struct {
-	ResultType string
-	Video      []*SearchResultVideo
-	Media      []*SearchResultMedia
+	ResultType string                  // 分类搜索时为搜索类型 综合搜索时为空
+	Video      []*SearchResultVideo    // 视频
+	Media      []*SearchResultMedia    // 番剧与影视 通过Type区分
+	User       []*SearchResultUser     // 用户
+	LiveRoom   []*SearchResultLiveRoom // 直播间
+	Article    []*SearchResultArticle  // 专栏
 }
 type SearchResultVideo struct {
 	Type         string   `json:"type"`           // 结果类型 固定为video
@@ -945,6 +992,77 @@ type SearchResultEpBadge struct {
 	BorderColorNight string `json:"border_color_night"` // 空
 	BgStyle          int    `json:"bg_style"`           // 恒为1
 }
+type SearchResultUser struct {
+	Type           string                   `json:"type"`        // 结果类型 固定为bili_user
+	MID            int64                    `json:"mid"`         // 用户mid
+	Uname          string                   `json:"uname"`       // 用户昵称
+	USign          string                   `json:"usign"`       // 用户签名
+	Fans           int                      `json:"fans"`        // 粉丝数
+	Videos         int                      `json:"videos"`      // 稿件数
+	UPic           string                   `json:"upic"`        // 用户头像url
+	VerifyInfo     string                   `json:"verify_info"` // 认证信息
+	Level          int                      `json:"level"`       // 当前等级
+	Gender         int                      `json:"gender"`      // 性别 1:男 2:女 3:私密
+	IsUpUser       int                      `json:"is_upuser"`   // 是否为UP主 0:否 1:是
+	IsLive         int                      `json:"is_live"`     // 是否正在直播 0:否 1:是
+	RoomID         int64                    `json:"room_id"`     // 直播间id
+	Res            []*SearchResultUserVideo `json:"res"`         // 用户投稿内容
+	OfficialVerify *struct {
+		Type int    `json:"type"` // 认证类型 0:个人认证 1:机构认证 127:无
+		Desc string `json:"desc"` // 认证名称
+	} `json:"official_verify"`
+	HitColumns []string `json:"hit_columns"` // 关键字匹配类型
+}
+type SearchResultUserVideo struct {
+	AID          int64  `json:"aid"`            // 稿件avid
+	BVID         string `json:"bvid"`           // 稿件bvid
+	Title        string `json:"title"`          // 稿件标题
+	PubDate      int64  `json:"pubdate"`        // 投稿时间 时间戳
+	ArcURL       string `json:"arcurl"`         // 视频url
+	Pic          string `json:"pic"`            // 封面url
+	Dm           int    `json:"dm"`             // 弹幕数
+	Coin         int    `json:"coin"`           // 投币数
+	Fav          int    `json:"fav"`            // 收藏数
+	Desc         string `json:"desc"`           // 简介
+	Duration     string `json:"duration"`       // 时长 格式: MM:SS
+	IsPay        int    `json:"is_pay"`         // 作用尚不明确
+	IsUnionVideo int    `json:"is_union_video"` // 是否为合作视频
+}
+type SearchResultLiveRoom struct {
+	Type       string   `json:"type"`        // 结果类型 固定为live_room
+	RoomID     int64    `json:"roomid"`      // 直播间id
+	ShortID    int64    `json:"short_id"`    // 直播间短号 没有时为0
+	UID        int64    `json:"uid"`         // 主播mid
+	Uname      string   `json:"uname"`       // 主播昵称
+	UFace      string   `json:"uface"`       // 主播头像url
+	Title      string   `json:"title"`       // 直播间标题 关键字用xml标签<em class="keyword">标注
+	Cover      string   `json:"cover"`       // 关键帧截图url
+	UserCover  string   `json:"user_cover"`  // 封面url
+	Tags       string   `json:"tags"`        // 直播间TAG
+	LiveTime   string   `json:"live_time"`   // 开播时间 格式: YYYY-MM-DD hh:mm:ss
+	Online     int      `json:"online"`      // 在线人数
+	Attentions int      `json:"attentions"`  // 主播粉丝数
+	CateName   string   `json:"cate_name"`   // 子分区名
+	Area       int      `json:"area"`        // 父分区id
+	RankScore  int64    `json:"rank_score"`  // 结果排序量化值
+	HitColumns []string `json:"hit_columns"` // 关键字匹配类型
+}
+type SearchResultArticle struct {
+	Type         string   `json:"type"`          // 结果类型 固定为article
+	ID           int64    `json:"id"`            // 专栏cvid
+	MID          int64    `json:"mid"`           // UP主mid
+	Title        string   `json:"title"`         // 专栏标题 关键字用xml标签<em class="keyword">标注
+	Desc         string   `json:"desc"`          // 专栏预览
+	ImageURLs    []string `json:"image_urls"`    // 封面图组
+	CategoryID   int      `json:"category_id"`   // 分区id
+	CategoryName string   `json:"category_name"` // 分区名
+	View         int      `json:"view"`          // 阅读数
+	Like         int      `json:"like"`          // 点赞数
+	Reply        int      `json:"reply"`         // 评论数
+	PubTime      int64    `json:"pub_time"`      // 投稿时间 时间戳
+	TemplateID   int      `json:"template_id"`   // 作用尚不明确
+	RankScore    int64    `json:"rank_score"`    // 结果排序量化值
+}
 type DanmakuPostResult struct {
 	Action  string `json:"action"`   // 空 作用尚不明确
 	Dmid    uint64 `json:"dmid"`     // 弹幕dmid
@@ -1516,26 +1634,18 @@ type LiveGuardList struct {
 		Now              int `json:"now"`  // 该次请求的页数
 		AchievementLevel int `json:"achievement_level"`
 	} `json:"info"`
-	List []*struct {
-		UID           int64  `json:"uid"`
-		RUID          int64  `json:"ruid"` // 主播mid
-		Rank          int    `json:"rank"` // 在该数组中的排名
-		Username      string `json:"username"`
-		Face          string `json:"face"`
-		IsAlive       int    `json:"is_alive"`
-		GuardLevel    int    `json:"guard_level"` // 1:总督 2:提督 3:舰长
-		GuardSubLevel int    `json:"guard_sub_level"`
-	} `json:"list"`
-	Top3 []*struct {
-		UID           int    `json:"uid"`
-		RUID          int    `json:"ruid"` // 主播mid
-		Rank          int    `json:"rank"` // 在该数组中的排名
-		Username      string `json:"username"`
-		Face          string `json:"face"`
-		IsAlive       int    `json:"is_alive"`
-		GuardLevel    int    `json:"guard_level"` // 1:总督 2:提督 3:舰长
-		GuardSubLevel int    `json:"guard_sub_level"`
-	} `json:"top3"`
+	List []*LiveGuardItem `json:"list"`
+	Top3 []*LiveGuardItem `json:"top3"` // 前三名，仅第一页返回
+}
+type LiveGuardItem struct {
+	UID           int64  `json:"uid"`
+	RUID          int64  `json:"ruid"` // 主播mid
+	Rank          int    `json:"rank"` // 在该数组中的排名
+	Username      string `json:"username"`
+	Face          string `json:"face"`
+	IsAlive       int    `json:"is_alive"`
+	GuardLevel    int    `json:"guard_level"` // 1:总督 2:提督 3:舰长
+	GuardSubLevel int    `json:"guard_sub_level"`
 }
 type LiveMedalRank struct {
 	Medal struct {
@@ -2307,3 +2417,167 @@ type LiveInfo struct {
 	IsNft    int    `json:"is_nft"`
 	NftDmark string `json:"nft_dmark"`
 }
+
+// VideoUploadPart 已上传的分P
+type VideoUploadPart struct {
+	Filename string // 上传后的文件名，无扩展名
+	Title    string // 分P标题
+	Desc     string // 分P简介
+}
+
+// VideoSubmitForm 投稿信息
+type VideoSubmitForm struct {
+	Copyright int    // 1:自制 2:转载
+	Source    string // 转载来源，转载时必填
+	TID       int    // 分区ID，见 GetVideoZone
+	Cover     string // 封面地址，由 VideoUploadCover 获取
+	Title     string // 标题，最多80字
+	Desc      string // 简介，最多2000字
+	Tags      []string
+	Dynamic   string // 粉丝动态
+	NoReprint bool   // 自制时禁止转载
+	DTime     int64  // 定时发布的时间戳，0为立即发布
+	Videos    []*VideoUploadPart
+}
+type VideoSubmitResp struct {
+	AID  int64  `json:"aid"`
+	BVID string `json:"bvid"`
+}
+
+// ArticleInfo 专栏文章信息及当前用户的互动状态
+type ArticleInfo struct {
+	Like            int           `json:"like"`      // 是否点赞 0:未点赞 1:已点赞
+	Attention       bool          `json:"attention"` // 是否关注作者
+	Favorite        bool          `json:"favorite"`  // 是否收藏
+	Coin            int           `json:"coin"`      // 投币数
+	Stats           *ArticleStats `json:"stats"`
+	Title           string        `json:"title"`
+	BannerURL       string        `json:"banner_url"` // 头图
+	MID             int64         `json:"mid"`        // 作者mid
+	AuthorName      string        `json:"author_name"`
+	IsAuthor        bool          `json:"is_author"`
+	ImageURLs       []string      `json:"image_urls"` // 封面
+	OriginImageURLs []string      `json:"origin_image_urls"`
+	Shareable       bool          `json:"shareable"`
+	InList          bool          `json:"in_list"` // 是否属于文集
+	Pre             int64         `json:"pre"`     // 文集中的上一篇cvid 0为无
+	Next            int64         `json:"next"`    // 文集中的下一篇cvid 0为无
+}
+type ArticleStats struct {
+	View     int64 `json:"view"`
+	Favorite int64 `json:"favorite"`
+	Like     int64 `json:"like"`
+	Dislike  int64 `json:"dislike"`
+	Reply    int64 `json:"reply"`
+	Share    int64 `json:"share"`
+	Coin     int64 `json:"coin"`
+	Dynamic  int64 `json:"dynamic"`
+}
+type ArticleAuthor struct {
+	MID  int64  `json:"mid"`
+	Name string `json:"name"`
+	Face string `json:"face"`
+}
+type ArticleCategory struct {
+	ID       int    `json:"id"`
+	ParentID int    `json:"parent_id"`
+	Name     string `json:"name"`
+}
+type ArticleTag struct {
+	TID  int64  `json:"tid"`
+	Name string `json:"name"`
+}
+
+// ArticleMeta 专栏列表中的文章
+type ArticleMeta struct {
+	ID              int64              `json:"id"` // cvid
+	Category        *ArticleCategory   `json:"category"`
+	Categories      []*ArticleCategory `json:"categories"`
+	Title           string             `json:"title"`
+	Summary         string             `json:"summary"`
+	BannerURL       string             `json:"banner_url"`
+	TemplateID      int                `json:"template_id"`
+	State           int                `json:"state"`
+	Author          *ArticleAuthor     `json:"author"`
+	Reprint         int                `json:"reprint"` // 0:原创 1:转载
+	ImageURLs       []string           `json:"image_urls"`
+	PublishTime     int64              `json:"publish_time"`
+	Ctime           int64              `json:"ctime"`
+	Stats           *ArticleStats      `json:"stats"`
+	Tags            []*ArticleTag      `json:"tags"`
+	Words           int                `json:"words"` // 字数
+	OriginImageURLs []string           `json:"origin_image_urls"`
+	List            *ArticleReadList   `json:"list"` // 所属文集 不属于时为nil
+}
+
+// ArticleContent 专栏正文
+type ArticleContent struct {
+	*ArticleMeta
+	HTML   string          `json:"content"` // 正文原始HTML
+	Blocks []*ArticleBlock `json:"-"`       // 由HTML解析的结构化正文
+}
+
+// ArticleBlock 正文中的一个段落、标题、图片、卡片等
+type ArticleBlock struct {
+	Type  string        // ArticleBlockParagraph 等常量
+	Text  string        // 纯文本，段落、标题、引用、代码时有效
+	Level int           // 标题级别 1-6
+	Items []string      // 列表项，列表时有效
+	Image *ArticleImage // 图片时有效
+	Card  *ArticleCard  // 卡片时有效
+}
+type ArticleImage struct {
+	URL     string
+	Width   int
+	Height  int
+	Caption string
+}
+
+// ArticleCard 正文中插入的视频、专栏、番剧等卡片
+type ArticleCard struct {
+	Type  string // video article bangumi music shop manga live 等
+	ID    string // 如 av170001 cv1 ss1 au1
+	Cover string // 卡片的占位图
+}
+
+// ArticleReadList 文集
+type ArticleReadList struct {
+	ID            int64  `json:"id"`
+	MID           int64  `json:"mid"`
+	Name          string `json:"name"`
+	ImageURL      string `json:"image_url"`
+	UpdateTime    int64  `json:"update_time"`
+	Ctime         int64  `json:"ctime"`
+	PublishTime   int64  `json:"publish_time"`
+	Summary       string `json:"summary"`
+	Words         int    `json:"words"`
+	Read          int64  `json:"read"`
+	ArticlesCount int    `json:"articles_count"`
+	State         int    `json:"state"`
+}
+type ArticleReadListDetail struct {
+	List      *ArticleReadList `json:"list"`
+	Articles  []*ArticleMeta   `json:"articles"`
+	Author    *ArticleAuthor   `json:"author"`
+	Attention bool             `json:"attention"`
+}
+type SpaceArticles struct {
+	Articles []*ArticleMeta `json:"articles"`
+	PN       int            `json:"pn"`
+	PS       int            `json:"ps"`
+	Count    int            `json:"count"`
+}
+
+// ArticleDraft 专栏草稿，用于 ArticleSaveDraft 与 ArticlePublish
+type ArticleDraft struct {
+	ID        int64    // 草稿ID 0为新建
+	Title     string   // 标题
+	BannerURL string   // 头图，由 ArticleUploadImage 获取
+	Content   string   // 正文HTML
+	Summary   string   // 摘要 为空时取正文前100字
+	Category  int      // 专栏分区
+	ListID    int64    // 加入的文集 0为不加入
+	Tags      []string // 标签
+	ImageURLs []string // 封面，由 ArticleUploadImage 获取
+	Original  bool     // 是否原创
+}
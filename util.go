@@ -3,6 +3,7 @@ package biligo
 import (
 	"encoding/json"
 	"fmt"
+	"html"
 	"math"
 	"regexp"
 	"strconv"
@@ -91,3 +92,48 @@ func genDynaPic(pics []*DynaUploadPic) (string, error) {
 	}
 	return string(j), nil
 }
+
+var searchKeyword = regexp.MustCompile(`<em class="keyword">(.*?)</em>`)
+
+// SearchStripHighlight 去除搜索结果中的 <em class="keyword"> 标注，并反转义HTML实体
+func SearchStripHighlight(s string) string {
+	return html.UnescapeString(searchKeyword.ReplaceAllString(s, "$1"))
+}
+
+// SearchHighlights 获取搜索结果中被 <em class="keyword"> 标注的关键字
+func SearchHighlights(s string) []string {
+	var r []string
+	for _, m := range searchKeyword.FindAllStringSubmatch(s, -1) {
+		r = append(r, html.UnescapeString(m[1]))
+	}
+	return r
+}
+
+// StripHighlight 去除全部结果标题、简介等字段中的关键字标注
+//
+// 需要关键字位置时应在调用前使用 SearchHighlights
+func (r *SearchResult) StripHighlight() {
+	strip := func(ss ...*string) {
+		for _, s := range ss {
+			*s = SearchStripHighlight(*s)
+		}
+	}
+	for _, v := range r.Video {
+		strip(&v.Title, &v.Description, &v.Tag)
+	}
+	for _, m := range r.Media {
+		strip(&m.Title, &m.OrgTitle, &m.Desc)
+		for _, e := range m.Eps {
+			strip(&e.Title, &e.LongTitle)
+		}
+	}
+	for _, u := range r.User {
+		strip(&u.Uname, &u.USign)
+	}
+	for _, l := range r.LiveRoom {
+		strip(&l.Title, &l.Uname, &l.Tags)
+	}
+	for _, a := range r.Article {
+		strip(&a.Title, &a.Desc)
+	}
+}
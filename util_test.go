@@ -12,3 +12,12 @@ func TestBV2AV(t *testing.T) {
 		t.FailNow()
 	}
 }
+func TestSearchStripHighlight(t *testing.T) {
+	s := `【<em class="keyword">洛天依</em>】Tom &amp; <em class="keyword">Jerry</em>`
+	if r := SearchStripHighlight(s); r != "【洛天依】Tom & Jerry" {
+		t.Error(r)
+	}
+	if r := SearchHighlights(s); len(r) != 2 || r[0] != "洛天依" || r[1] != "Jerry" {
+		t.Error(r)
+	}
+}
@@ -0,0 +1,569 @@
+package biligo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// 视频编码 对应 VideoPlayURLDashMedia.Codecid
+const (
+	VideoCodecAVC  = 7  // H.264
+	VideoCodecHEVC = 12 // H.265
+	VideoCodecAV1  = 13
+)
+
+// Codec 视频编码，Codecid缺失时由Codecs推断，音频或未知编码返回0
+func (m *VideoPlayURLDashMedia) Codec() int {
+	if m.Codecid != 0 {
+		return m.Codecid
+	}
+	switch {
+	case strings.HasPrefix(m.Codecs, "avc"):
+		return VideoCodecAVC
+	case strings.HasPrefix(m.Codecs, "hev"), strings.HasPrefix(m.Codecs, "hvc"):
+		return VideoCodecHEVC
+	case strings.HasPrefix(m.Codecs, "av01"):
+		return VideoCodecAV1
+	}
+	return 0
+}
+
+// URLs 主地址与备用地址
+func (m *VideoPlayURLDashMedia) URLs() []string {
+	return append([]string{m.BaseURL}, m.BackupURL...)
+}
+
+// DashSelectOption 选择dash流的条件
+type DashSelectOption struct {
+	// MaxQuality 最高清晰度代码，0为不限制
+	MaxQuality int
+	// Codecs 编码偏好，越靠前越优先，不在其中的编码排在最后
+	//
+	// 默认为 VideoCodecAVC VideoCodecHEVC VideoCodecAV1
+	Codecs []int
+	// MaxBandwidth 最高带宽，0为不限制
+	MaxBandwidth int64
+}
+
+// SelectVideo 选择最佳视频流
+//
+// 依次比较清晰度、编码偏好与带宽，没有满足 MaxQuality MaxBandwidth 的流时返回带宽最低的流
+//
+// opt可为nil
+func (d *VideoPlayURLDash) SelectVideo(opt *DashSelectOption) *VideoPlayURLDashMedia {
+	if opt == nil {
+		opt = &DashSelectOption{}
+	}
+	codecs := opt.Codecs
+	if len(codecs) == 0 {
+		codecs = []int{VideoCodecAVC, VideoCodecHEVC, VideoCodecAV1}
+	}
+	rank := func(m *VideoPlayURLDashMedia) int {
+		for i, c := range codecs {
+			if m.Codec() == c {
+				return i
+			}
+		}
+		return len(codecs)
+	}
+	return selectDash(d.Video, opt, func(a, b *VideoPlayURLDashMedia) bool {
+		if a.ID != b.ID {
+			return a.ID > b.ID
+		}
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra < rb
+		}
+		return a.Bandwidth > b.Bandwidth
+	})
+}
+
+// SelectAudio 选择带宽最高的音频流，MaxBandwidth 同样生效
+//
+// opt可为nil
+func (d *VideoPlayURLDash) SelectAudio(opt *DashSelectOption) *VideoPlayURLDashMedia {
+	if opt == nil {
+		opt = &DashSelectOption{}
+	}
+	return selectDash(d.Audio, &DashSelectOption{MaxBandwidth: opt.MaxBandwidth}, func(a, b *VideoPlayURLDashMedia) bool {
+		return a.Bandwidth > b.Bandwidth
+	})
+}
+
+func selectDash(media []*VideoPlayURLDashMedia, opt *DashSelectOption, better func(a, b *VideoPlayURLDashMedia) bool) *VideoPlayURLDashMedia {
+	if len(media) == 0 {
+		return nil
+	}
+	var (
+		best   *VideoPlayURLDashMedia
+		lowest = media[0]
+	)
+	for _, m := range media {
+		if m.Bandwidth < lowest.Bandwidth {
+			lowest = m
+		}
+		if (opt.MaxQuality > 0 && m.ID > opt.MaxQuality) || (opt.MaxBandwidth > 0 && m.Bandwidth > opt.MaxBandwidth) {
+			continue
+		}
+		if best == nil || better(m, best) {
+			best = m
+		}
+	}
+	if best == nil {
+		return lowest
+	}
+	return best
+}
+
+// DownloadOption 下载设置
+type DownloadOption struct {
+	// Client 下载使用的http client 默认使用 CommClient 的http client与 ProxyProvider
+	Client *http.Client
+	// Referer 默认为 https://www.bilibili.com ，缺少时CDN返回403
+	Referer string
+	// ChunkSize 每个Range分块的大小 默认4MB
+	ChunkSize int64
+	// Concurrency 并行下载的分块数 默认4
+	Concurrency int
+	// Retry 每个分块在所有地址都失败后的重试轮数 默认2
+	Retry int
+	// OnProgress 每完成一个分块时调用，total为全部文件的大小，不会并发调用
+	OnProgress func(done, total int64)
+}
+
+// Downloader 视频下载
+//
+// 使用Range分块并行下载到 {path}.part，进度保存在 {path}.part.json，
+// 中断后再次下载同一路径时跳过已完成的分块。下载完成并校验大小后重命名为path
+type Downloader struct {
+	c   *CommClient
+	opt DownloadOption
+}
+
+// NewDownloader 创建下载器
+//
+// opt可为nil
+func (c *CommClient) NewDownloader(opt *DownloadOption) *Downloader {
+	d := &Downloader{c: c}
+	if opt != nil {
+		d.opt = *opt
+	}
+	if d.opt.Referer == "" {
+		d.opt.Referer = "https://www.bilibili.com"
+	}
+	if d.opt.ChunkSize <= 0 {
+		d.opt.ChunkSize = 4 << 20
+	}
+	if d.opt.Concurrency <= 0 {
+		d.opt.Concurrency = 4
+	}
+	if d.opt.Retry <= 0 {
+		d.opt.Retry = 2
+	}
+	return d
+}
+
+// DownloadDash 按sel选择音视频流，分别下载到videoPath与audioPath，可直接交给ffmpeg合并
+//
+// 没有音频流时audio为nil且不创建audioPath
+//
+// sel可为nil
+func (d *Downloader) DownloadDash(ctx context.Context, dash *VideoPlayURLDash, sel *DashSelectOption, videoPath, audioPath string) (video, audio *VideoPlayURLDashMedia, err error) {
+	if video = dash.SelectVideo(sel); video == nil {
+		return nil, nil, errors.New("no dash video stream")
+	}
+	audio = dash.SelectAudio(sel)
+
+	jobs := []*downloadJob{{urls: video.URLs(), size: -1, path: videoPath}}
+	if audio != nil {
+		jobs = append(jobs, &downloadJob{urls: audio.URLs(), size: -1, path: audioPath})
+	}
+	if err = d.run(ctx, jobs); err != nil {
+		return nil, nil, err
+	}
+	return video, audio, nil
+}
+
+// DownloadMedia 下载单个dash流
+func (d *Downloader) DownloadMedia(ctx context.Context, m *VideoPlayURLDashMedia, path string) error {
+	return d.run(ctx, []*downloadJob{{urls: m.URLs(), size: -1, path: path}})
+}
+
+// DownloadDURL 下载flv/mp4分段，并按 Order 顺序拼接到path
+//
+// 各分段先下载到 {path}.{order}，全部完成后拼接并删除
+func (d *Downloader) DownloadDURL(ctx context.Context, durl []*VideoPlayDURL, path string) error {
+	if len(durl) == 0 {
+		return errors.New("empty durl")
+	}
+	segs := make([]*VideoPlayDURL, len(durl))
+	copy(segs, durl)
+	sort.SliceStable(segs, func(i, j int) bool { return segs[i].Order < segs[j].Order })
+
+	var (
+		jobs  []*downloadJob
+		total int64
+		known = true
+	)
+	for _, s := range segs {
+		size := s.Size
+		if size <= 0 {
+			size, known = -1, false
+		}
+		jobs = append(jobs, &downloadJob{
+			urls: append([]string{s.URL}, s.BackupURL...),
+			size: size,
+			path: path + "." + strconv.Itoa(s.Order),
+		})
+		total += size
+	}
+	if known && checkSize(path, total) == nil {
+		return nil
+	}
+	if err := d.run(ctx, jobs); err != nil {
+		return err
+	}
+	total = 0
+	for _, j := range jobs {
+		total += j.size
+	}
+
+	tmp := path + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	for _, j := range jobs {
+		if err = appendFile(f, j.path); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = checkSize(tmp, total); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return err
+	}
+	for _, j := range jobs {
+		_ = os.Remove(j.path)
+	}
+	return nil
+}
+
+// DownloadURL 下载任意B站CDN地址，urls依次为主地址与备用地址
+//
+// size为预期大小，小于0时通过Range请求获取
+func (d *Downloader) DownloadURL(ctx context.Context, urls []string, size int64, path string) error {
+	return d.run(ctx, []*downloadJob{{urls: urls, size: size, path: path}})
+}
+
+func appendFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func checkSize(path string, size int64) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if fi.Size() != size {
+		return fmt.Errorf("size mismatch: %s is %d bytes, expected %d", path, fi.Size(), size)
+	}
+	return nil
+}
+
+// downloadJob 一个文件的下载任务
+type downloadJob struct {
+	urls []string
+	size int64
+	path string
+
+	// preferred 当前可用的地址下标，某个地址失败后后续分块从下一个地址开始
+	preferred int32
+	state     *downloadState
+	f         *os.File
+	mu        sync.Mutex
+}
+
+// downloadState 保存在 {path}.part.json 的下载进度
+type downloadState struct {
+	Size      int64  `json:"size"`
+	ChunkSize int64  `json:"chunk_size"`
+	Done      []bool `json:"done"`
+}
+
+// downloadChunk 一个Range分块
+type downloadChunk struct {
+	job   *downloadJob
+	index int
+	start int64
+	end   int64 // 闭区间
+}
+
+// run 并行下载全部任务的分块
+func (d *Downloader) run(ctx context.Context, jobs []*downloadJob) error {
+	var (
+		chunks []*downloadChunk
+		total  int64
+		done   int64
+	)
+	defer func() {
+		for _, j := range jobs {
+			if j.f != nil {
+				j.f.Close()
+			}
+		}
+	}()
+	for _, j := range jobs {
+		if len(j.urls) == 0 || j.urls[0] == "" {
+			return errors.New("empty download url: " + j.path)
+		}
+		if j.size < 0 {
+			size, err := d.probe(ctx, j)
+			if err != nil {
+				return err
+			}
+			j.size = size
+		}
+		total += j.size
+		// 已经下载完成
+		if checkSize(j.path, j.size) == nil {
+			done += j.size
+			continue
+		}
+		cs, err := d.prepare(j)
+		if err != nil {
+			return err
+		}
+		for _, c := range cs {
+			if j.state.Done[c.index] {
+				done += c.end - c.start + 1
+				continue
+			}
+			chunks = append(chunks, c)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		first    error
+		progress sync.Mutex
+		sem      = make(chan struct{}, d.opt.Concurrency)
+	)
+	for _, c := range chunks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(c *downloadChunk) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := d.chunk(ctx, c); err != nil {
+				errOnce.Do(func() {
+					first = err
+					cancel()
+				})
+				return
+			}
+			if d.opt.OnProgress != nil {
+				progress.Lock()
+				done += c.end - c.start + 1
+				d.opt.OnProgress(done, total)
+				progress.Unlock()
+			}
+		}(c)
+	}
+	wg.Wait()
+	if first != nil {
+		return first
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for _, j := range jobs {
+		if j.f == nil {
+			continue
+		}
+		if err := j.f.Close(); err != nil {
+			return err
+		}
+		j.f = nil
+		part := j.path + ".part"
+		if err := checkSize(part, j.size); err != nil {
+			return err
+		}
+		if err := os.Rename(part, j.path); err != nil {
+			return err
+		}
+		_ = os.Remove(part + ".json")
+	}
+	return nil
+}
+
+// prepare 打开 .part 文件并读取进度，进度与当前大小、分块不一致时重新下载
+func (d *Downloader) prepare(j *downloadJob) ([]*downloadChunk, error) {
+	part := j.path + ".part"
+	n := int((j.size + d.opt.ChunkSize - 1) / d.opt.ChunkSize)
+
+	j.state = &downloadState{}
+	if b, err := ioutil.ReadFile(part + ".json"); err == nil {
+		_ = json.Unmarshal(b, j.state)
+	}
+	if _, err := os.Stat(part); err != nil || j.state.Size != j.size || j.state.ChunkSize != d.opt.ChunkSize || len(j.state.Done) != n {
+		j.state = &downloadState{Size: j.size, ChunkSize: d.opt.ChunkSize, Done: make([]bool, n)}
+	}
+
+	f, err := os.OpenFile(part, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j.f = f
+	if err = f.Truncate(j.size); err != nil {
+		return nil, err
+	}
+
+	chunks := make([]*downloadChunk, n)
+	for i := range chunks {
+		end := int64(i+1)*d.opt.ChunkSize - 1
+		if end >= j.size {
+			end = j.size - 1
+		}
+		chunks[i] = &downloadChunk{job: j, index: i, start: int64(i) * d.opt.ChunkSize, end: end}
+	}
+	return chunks, nil
+}
+
+// saveState 标记分块完成并写入进度文件
+func (j *downloadJob) saveState(index int) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.state.Done[index] = true
+	b, err := json.Marshal(j.state)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(j.path+".part.json", b, 0644)
+}
+
+func (d *Downloader) request(ctx context.Context, u string, start, end int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", d.c.ua)
+	req.Header.Set("Referer", d.opt.Referer)
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	var resp *http.Response
+	if d.opt.Client != nil {
+		resp, err = d.opt.Client.Do(req)
+	} else {
+		resp, _, err = d.c.doStream(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return nil, fmt.Errorf("download %s: %s", u, resp.Status)
+	}
+	return resp, nil
+}
+
+// probe 通过 Range: bytes=0-0 获取文件大小，依次尝试各个地址
+func (d *Downloader) probe(ctx context.Context, j *downloadJob) (int64, error) {
+	var err error
+	for i, u := range j.urls {
+		var resp *http.Response
+		if resp, err = d.request(ctx, u, 0, 0); err != nil {
+			continue
+		}
+		resp.Body.Close()
+		cr := resp.Header.Get("Content-Range")
+		size, perr := strconv.ParseInt(cr[strings.LastIndexByte(cr, '/')+1:], 10, 64)
+		if perr != nil || size <= 0 {
+			err = fmt.Errorf("download %s: invalid Content-Range %q", u, cr)
+			continue
+		}
+		atomic.StoreInt32(&j.preferred, int32(i))
+		return size, nil
+	}
+	return 0, err
+}
+
+// chunk 下载一个分块，当前地址失败时切换到下一个地址
+func (d *Downloader) chunk(ctx context.Context, c *downloadChunk) error {
+	j := c.job
+	var err error
+	for round := 0; round <= d.opt.Retry; round++ {
+		for range j.urls {
+			i := int(atomic.LoadInt32(&j.preferred))
+			if err = d.fetchChunk(ctx, j.urls[i%len(j.urls)], c); err == nil {
+				return j.saveState(c.index)
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			atomic.CompareAndSwapInt32(&j.preferred, int32(i), int32((i+1)%len(j.urls)))
+		}
+	}
+	return err
+}
+
+func (d *Downloader) fetchChunk(ctx context.Context, u string, c *downloadChunk) error {
+	resp, err := d.request(ctx, u, c.start, c.end)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	want := c.end - c.start + 1
+	n, err := io.Copy(&offsetWriter{f: c.job.f, off: c.start}, io.LimitReader(resp.Body, want))
+	if err != nil {
+		return err
+	}
+	if n != want {
+		return fmt.Errorf("download %s: chunk %d got %d bytes, expected %d", u, c.index, n, want)
+	}
+	return nil
+}
+
+// offsetWriter 从off开始顺序写入f
+type offsetWriter struct {
+	f   *os.File
+	off int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.f.WriteAt(p, w.off)
+	w.off += int64(n)
+	return n, err
+}
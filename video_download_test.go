@@ -0,0 +1,185 @@
+package biligo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestVideoPlayURLDash_Select(t *testing.T) {
+	d := &VideoPlayURLDash{
+		Video: []*VideoPlayURLDashMedia{
+			{ID: 80, Codecs: "avc1.640032", Bandwidth: 800},
+			{ID: 116, Codecid: VideoCodecHEVC, Bandwidth: 1000},
+			{ID: 116, Codecid: VideoCodecAVC, Bandwidth: 2000},
+			{ID: 116, Codecid: VideoCodecAVC, Bandwidth: 1500},
+			{ID: 80, Codecs: "av01.0.08M.08", Bandwidth: 500},
+		},
+		Audio: []*VideoPlayURLDashMedia{
+			{ID: 30216, Bandwidth: 67000},
+			{ID: 30280, Bandwidth: 320000},
+		},
+	}
+	for _, c := range []struct {
+		opt       *DashSelectOption
+		id, codec int
+		bandwidth int64
+	}{
+		{nil, 116, VideoCodecAVC, 2000},
+		{&DashSelectOption{Codecs: []int{VideoCodecHEVC}}, 116, VideoCodecHEVC, 1000},
+		{&DashSelectOption{MaxQuality: 80, Codecs: []int{VideoCodecAV1, VideoCodecAVC}}, 80, VideoCodecAV1, 500},
+		{&DashSelectOption{MaxBandwidth: 1600}, 116, VideoCodecAVC, 1500},
+		{&DashSelectOption{MaxBandwidth: 1}, 80, VideoCodecAV1, 500},
+	} {
+		v := d.SelectVideo(c.opt)
+		if v.ID != c.id || v.Codec() != c.codec || v.Bandwidth != c.bandwidth {
+			t.Errorf("%+v: %+v", c.opt, v)
+		}
+	}
+	if a := d.SelectAudio(nil); a.ID != 30280 {
+		t.Errorf("%+v", a)
+	}
+	if a := d.SelectAudio(&DashSelectOption{MaxBandwidth: 100000}); a.ID != 30216 {
+		t.Errorf("%+v", a)
+	}
+}
+
+// fakeCDN /bad 返回500，其余路径返回files中的内容并支持Range
+func fakeCDN(t *testing.T, files map[string][]byte) (*httptest.Server, *[]string) {
+	var (
+		mu     sync.Mutex
+		ranges []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Referer") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/bad") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		ranges = append(ranges, r.URL.Path+" "+r.Header.Get("Range"))
+		mu.Unlock()
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &ranges
+}
+
+func randBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+func TestDownloader_Dash(t *testing.T) {
+	video, audio := randBytes(100000), randBytes(30001)
+	srv, _ := fakeCDN(t, map[string][]byte{"/v.m4s": video, "/a.m4s": audio})
+
+	dash := &VideoPlayURLDash{
+		Video: []*VideoPlayURLDashMedia{{ID: 80, BaseURL: srv.URL + "/bad/v.m4s", BackupURL: []string{srv.URL + "/v.m4s"}}},
+		Audio: []*VideoPlayURLDashMedia{{ID: 30280, BaseURL: srv.URL + "/a.m4s"}},
+	}
+	dir := t.TempDir()
+	var progress int64
+	d := NewCommClient(&CommSetting{}).NewDownloader(&DownloadOption{
+		ChunkSize:   8192,
+		Concurrency: 3,
+		OnProgress:  func(done, total int64) { progress = total },
+	})
+	v, a, err := d.DownloadDash(context.Background(), dash, nil, filepath.Join(dir, "v.m4s"), filepath.Join(dir, "a.m4s"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != 80 || a.ID != 30280 || progress != 130001 {
+		t.Errorf("%+v %+v %d", v, a, progress)
+	}
+	for name, want := range map[string][]byte{"v.m4s": video, "a.m4s": audio} {
+		got, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.part*")); len(files) != 0 {
+		t.Errorf("%v", files)
+	}
+}
+
+func TestDownloader_Resume(t *testing.T) {
+	data := randBytes(50000)
+	srv, ranges := fakeCDN(t, map[string][]byte{"/v.flv": data})
+	path := filepath.Join(t.TempDir(), "v.flv")
+
+	// 前三个分块已完成
+	const chunk = 10000
+	part := make([]byte, len(data))
+	copy(part, data[:3*chunk])
+	if err := ioutil.WriteFile(path+".part", part, 0644); err != nil {
+		t.Fatal(err)
+	}
+	state, _ := json.Marshal(&downloadState{Size: int64(len(data)), ChunkSize: chunk, Done: []bool{true, true, true, false, false}})
+	if err := ioutil.WriteFile(path+".part.json", state, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 默认使用CommClient的http client
+	var reqs int32
+	c := NewCommClient(&CommSetting{Client: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&reqs, 1)
+		return http.DefaultTransport.RoundTrip(r)
+	})}})
+	d := c.NewDownloader(&DownloadOption{ChunkSize: chunk})
+	if err := d.DownloadURL(context.Background(), []string{srv.URL + "/v.flv"}, int64(len(data)), path); err != nil {
+		t.Fatal(err)
+	}
+	if len(*ranges) != 2 || int(atomic.LoadInt32(&reqs)) != len(*ranges) {
+		t.Errorf("%v %d", *ranges, reqs)
+	}
+	if got, _ := ioutil.ReadFile(path); !bytes.Equal(got, data) {
+		t.Error("content mismatch")
+	}
+
+	// 大小不一致时报错
+	if err := d.DownloadURL(context.Background(), []string{srv.URL + "/v.flv"}, int64(len(data))+1, path+"2"); err == nil {
+		t.Error("expected size error")
+	}
+}
+
+func TestDownloader_DURL(t *testing.T) {
+	seg1, seg2 := randBytes(12345), randBytes(6789)
+	srv, _ := fakeCDN(t, map[string][]byte{"/1.flv": seg1, "/2.flv": seg2})
+	path := filepath.Join(t.TempDir(), "v.flv")
+
+	d := NewCommClient(&CommSetting{}).NewDownloader(&DownloadOption{ChunkSize: 4096})
+	err := d.DownloadDURL(context.Background(), []*VideoPlayDURL{
+		{Order: 2, Size: int64(len(seg2)), URL: srv.URL + "/bad/2.flv", BackupURL: []string{srv.URL + "/2.flv"}},
+		{Order: 1, Size: int64(len(seg1)), URL: srv.URL + "/1.flv"},
+	}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := ioutil.ReadFile(path); !bytes.Equal(got, append(seg1, seg2...)) {
+		t.Error("content mismatch")
+	}
+	if _, err = os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Errorf("segment not removed: %v", err)
+	}
+}
@@ -0,0 +1,546 @@
+package biligo
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// VideoUploadOption 视频上传设置
+type VideoUploadOption struct {
+	// Client 上传分块使用的http client 默认使用 BiliClient 的http client与代理
+	Client *http.Client
+	// Profile 上传配置 默认 ugcupos/bup
+	Profile string
+	// Upcdn 上传线路，如 bda2 ws qn bldsa，默认由服务器分配
+	Upcdn string
+	// Concurrency 并行上传的分块数 默认3
+	Concurrency int
+	// Retry 每个分块失败后的重试次数 默认3，重试间隔按 BiliClient 的 RetryPolicy 指数退避
+	Retry int
+	// OnProgress 每完成一个分块时调用，不会并发调用
+	OnProgress func(path string, done, total int64)
+}
+
+// VideoUploader 投稿视频上传
+//
+// 上传流程为 preupload -> upos分块上传 -> 合并，得到的 VideoUploadPart 用于 VideoSubmit 投稿。
+// 上传进度保存在 {path}.upload.json，中断后再次上传同一文件时跳过已完成的分块
+type VideoUploader struct {
+	b   *BiliClient
+	opt VideoUploadOption
+}
+
+// NewVideoUploader 创建上传器
+//
+// opt可为nil
+func (b *BiliClient) NewVideoUploader(opt *VideoUploadOption) *VideoUploader {
+	u := &VideoUploader{b: b}
+	if opt != nil {
+		u.opt = *opt
+	}
+	if u.opt.Profile == "" {
+		u.opt.Profile = "ugcupos/bup"
+	}
+	if u.opt.Concurrency <= 0 {
+		u.opt.Concurrency = 3
+	}
+	if u.opt.Retry <= 0 {
+		u.opt.Retry = 3
+	}
+	return u
+}
+
+// videoPreupload preupload接口的响应，该接口没有通用的code data结构
+type videoPreupload struct {
+	OK        int    `json:"OK"`
+	Message   string `json:"message"`
+	Auth      string `json:"auth"`
+	BizID     int64  `json:"biz_id"`
+	ChunkSize int64  `json:"chunk_size"`
+	Endpoint  string `json:"endpoint"`
+	UposURI   string `json:"upos_uri"`
+	Threads   int    `json:"threads"`
+}
+
+// videoUploadState 保存在 {path}.upload.json 的上传进度
+type videoUploadState struct {
+	Size      int64           `json:"size"`
+	ModTime   int64           `json:"mod_time"`
+	Preupload *videoPreupload `json:"preupload"`
+	UploadID  string          `json:"upload_id"`
+	// ETags 各分块的etag，空字符串表示未完成
+	ETags []string `json:"etags"`
+}
+
+// Upload 上传视频文件，返回的 VideoUploadPart 的标题默认为文件名
+func (u *VideoUploader) Upload(ctx context.Context, path string) (*VideoUploadPart, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() == 0 {
+		return nil, errors.New("empty video file: " + path)
+	}
+
+	st := u.loadState(path, fi)
+	if st == nil {
+		if st, err = u.begin(ctx, fi); err != nil {
+			return nil, err
+		}
+		if err = u.saveState(path, st); err != nil {
+			return nil, err
+		}
+	}
+	if err = u.chunks(ctx, f, path, st); err != nil {
+		return nil, err
+	}
+	if err = u.complete(ctx, fi.Name(), st); err != nil {
+		return nil, err
+	}
+	_ = os.Remove(path + ".upload.json")
+
+	key := filepath.Base(uposKey(st.Preupload.UposURI))
+	return &VideoUploadPart{
+		Filename: strings.TrimSuffix(key, filepath.Ext(key)),
+		Title:    strings.TrimSuffix(fi.Name(), filepath.Ext(fi.Name())),
+	}, nil
+}
+
+// loadState 读取上传进度，文件已改变或分块信息不完整时返回nil
+func (u *VideoUploader) loadState(path string, fi os.FileInfo) *videoUploadState {
+	b, err := ioutil.ReadFile(path + ".upload.json")
+	if err != nil {
+		return nil
+	}
+	st := &videoUploadState{}
+	if err = json.Unmarshal(b, st); err != nil || st.Preupload == nil || st.Preupload.ChunkSize <= 0 || st.UploadID == "" {
+		return nil
+	}
+	if st.Size != fi.Size() || st.ModTime != fi.ModTime().UnixNano() || len(st.ETags) != chunkCount(st.Size, st.Preupload.ChunkSize) {
+		return nil
+	}
+	return st
+}
+
+func (u *VideoUploader) saveState(path string, st *videoUploadState) error {
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path+".upload.json", b, 0644)
+}
+
+func chunkCount(size, chunk int64) int {
+	return int((size + chunk - 1) / chunk)
+}
+
+// uposKey upos://ugcboss/n1.mp4 -> ugcboss/n1.mp4
+func uposKey(uri string) string {
+	return strings.TrimPrefix(uri, "upos://")
+}
+
+// uposURL 分块上传的地址
+func uposURL(p *videoPreupload) string {
+	return strings.TrimSuffix(httpsURL(p.Endpoint), "/") + "/" + uposKey(p.UposURI)
+}
+
+// begin 获取上传地址并初始化分块上传
+func (u *VideoUploader) begin(ctx context.Context, fi os.FileInfo) (*videoUploadState, error) {
+	params := map[string]string{
+		"name":          fi.Name(),
+		"size":          strconv.FormatInt(fi.Size(), 10),
+		"r":             "upos",
+		"profile":       u.opt.Profile,
+		"ssl":           "0",
+		"version":       "2.14.0",
+		"build":         "2140000",
+		"probe_version": "20221109",
+	}
+	if u.opt.Upcdn != "" {
+		params["upcdn"] = u.opt.Upcdn
+	}
+	raw, err := u.b.RawWithContext(ctx, BiliMemberURL, "preupload", "GET", params)
+	if err != nil {
+		return nil, err
+	}
+	p := &videoPreupload{}
+	if err = json.Unmarshal(raw, p); err != nil {
+		return nil, err
+	}
+	if p.OK != 1 || p.Endpoint == "" || p.UposURI == "" || p.ChunkSize <= 0 {
+		return nil, fmt.Errorf("preupload failed: %s", string(raw))
+	}
+
+	var r struct {
+		OK       int    `json:"OK"`
+		UploadID string `json:"upload_id"`
+	}
+	if err = u.upos(ctx, http.MethodPost, uposURL(p)+"?uploads&output=json", p.Auth, nil, &r); err != nil {
+		return nil, err
+	}
+	if r.OK != 1 || r.UploadID == "" {
+		return nil, errors.New("upos init failed: empty upload_id")
+	}
+	return &videoUploadState{
+		Size:      fi.Size(),
+		ModTime:   fi.ModTime().UnixNano(),
+		Preupload: p,
+		UploadID:  r.UploadID,
+		ETags:     make([]string, chunkCount(fi.Size(), p.ChunkSize)),
+	}, nil
+}
+
+// chunks 并行上传未完成的分块
+func (u *VideoUploader) chunks(ctx context.Context, f *os.File, path string, st *videoUploadState) error {
+	var (
+		p     = st.Preupload
+		n     = len(st.ETags)
+		done  int64
+		todo  []int
+		mu    sync.Mutex // 保护st与进度
+		first error
+		once  sync.Once
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, u.opt.Concurrency)
+	)
+	for i, tag := range st.ETags {
+		if tag != "" {
+			done += chunkLen(st.Size, p.ChunkSize, i)
+			continue
+		}
+		todo = append(todo, i)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for _, i := range todo {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			start, size := int64(i)*p.ChunkSize, chunkLen(st.Size, p.ChunkSize, i)
+			q := url.Values{}
+			q.Set("partNumber", strconv.Itoa(i+1))
+			q.Set("uploadId", st.UploadID)
+			q.Set("chunk", strconv.Itoa(i))
+			q.Set("chunks", strconv.Itoa(n))
+			q.Set("size", strconv.FormatInt(size, 10))
+			q.Set("start", strconv.FormatInt(start, 10))
+			q.Set("end", strconv.FormatInt(start+size, 10))
+			q.Set("total", strconv.FormatInt(st.Size, 10))
+
+			var (
+				tag string
+				err error
+			)
+			for attempt := 0; attempt <= u.opt.Retry; attempt++ {
+				if attempt > 0 {
+					if err = sleep(ctx, u.backoff(attempt)); err != nil {
+						break
+					}
+				}
+				if tag, err = u.put(ctx, uposURL(p)+"?"+q.Encode(), p.Auth, io.NewSectionReader(f, start, size)); err == nil || ctx.Err() != nil {
+					break
+				}
+			}
+			if err != nil {
+				once.Do(func() {
+					first = fmt.Errorf("upload chunk %d: %w", i, err)
+					cancel()
+				})
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			st.ETags[i] = tag
+			// 保存失败只影响断点续传
+			_ = u.saveState(path, st)
+			done += size
+			if u.opt.OnProgress != nil {
+				u.opt.OnProgress(path, done, st.Size)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if first != nil {
+		return first
+	}
+	return ctx.Err()
+}
+
+func chunkLen(total, chunk int64, i int) int64 {
+	if end := int64(i+1) * chunk; end < total {
+		return chunk
+	}
+	return total - int64(i)*chunk
+}
+
+// complete 合并分块
+func (u *VideoUploader) complete(ctx context.Context, name string, st *videoUploadState) error {
+	type part struct {
+		PartNumber int    `json:"partNumber"`
+		ETag       string `json:"eTag"`
+	}
+	parts := make([]part, len(st.ETags))
+	for i, tag := range st.ETags {
+		parts[i] = part{PartNumber: i + 1, ETag: tag}
+	}
+	body, err := json.Marshal(map[string]interface{}{"parts": parts})
+	if err != nil {
+		return err
+	}
+	q := url.Values{}
+	q.Set("output", "json")
+	q.Set("name", name)
+	q.Set("profile", u.opt.Profile)
+	q.Set("uploadId", st.UploadID)
+	q.Set("biz_id", strconv.FormatInt(st.Preupload.BizID, 10))
+
+	var r struct {
+		OK      int    `json:"OK"`
+		Message string `json:"message"`
+	}
+	if err = u.upos(ctx, http.MethodPost, uposURL(st.Preupload)+"?"+q.Encode(), st.Preupload.Auth, body, &r); err != nil {
+		return err
+	}
+	if r.OK != 1 {
+		return fmt.Errorf("upos complete failed: %s", r.Message)
+	}
+	return nil
+}
+
+func (u *VideoUploader) newRequest(ctx context.Context, method, link, auth string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, link, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", u.b.ua)
+	req.Header.Set("Origin", "https://member.bilibili.com")
+	req.Header.Set("Referer", "https://member.bilibili.com/")
+	req.Header.Set("X-Upos-Auth", auth)
+	return req, nil
+}
+
+// backoff 分块第attempt次重试前的等待时间
+func (u *VideoUploader) backoff(attempt int) time.Duration {
+	if u.b.retry != nil {
+		return u.b.retry.delay(attempt)
+	}
+	return (&RetryPolicy{}).delay(attempt)
+}
+
+// do 发出upos请求，没有设置 VideoUploadOption.Client 时使用 BiliClient 的http client与代理
+func (u *VideoUploader) do(req *http.Request) (*http.Response, error) {
+	if u.opt.Client != nil {
+		return u.opt.Client.Do(req)
+	}
+	resp, _, err := u.b.doStream(req)
+	return resp, err
+}
+
+// upos 请求upos接口并解析json响应
+func (u *VideoUploader) upos(ctx context.Context, method, link, auth string, body []byte, v interface{}) error {
+	req, err := u.newRequest(ctx, method, link, auth, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp, err := u.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upos %s: %s %s", req.URL.Path, resp.Status, string(raw))
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// put 上传一个分块，返回etag
+func (u *VideoUploader) put(ctx context.Context, link, auth string, body *io.SectionReader) (string, error) {
+	req, err := u.newRequest(ctx, http.MethodPut, link, auth, body)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = body.Size()
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := u.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("upos %s: %s", req.URL.Path, resp.Status)
+	}
+	tag := strings.Trim(resp.Header.Get("ETag"), `"`)
+	if tag == "" {
+		return "", fmt.Errorf("upos %s: missing etag", req.URL.Path)
+	}
+	return tag, nil
+}
+
+// VideoUploadCover 上传投稿封面，返回的地址用于 VideoSubmit.Cover
+//
+// 支持jpg和png，推荐16:10 1146x717以上
+func (b *BiliClient) VideoUploadCover(cover io.Reader) (string, error) {
+	return b.VideoUploadCoverWithContext(context.Background(), cover)
+}
+
+// VideoUploadCoverWithContext 同 VideoUploadCover，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoUploadCoverWithContext(ctx context.Context, cover io.Reader) (string, error) {
+	img, err := ioutil.ReadAll(cover)
+	if err != nil {
+		return "", err
+	}
+	ct := http.DetectContentType(img)
+	if ct != "image/jpeg" && ct != "image/png" {
+		return "", fmt.Errorf("unsupported cover type: %s", ct)
+	}
+	resp, err := b.RawParseWithContext(ctx, BiliMemberURL, "x/vu/web/cover/up", "POST", map[string]string{
+		"cover": "data:" + ct + ";base64," + base64.StdEncoding.EncodeToString(img),
+	})
+	if err != nil {
+		return "", err
+	}
+	var r struct {
+		URL string `json:"url"`
+	}
+	if err = json.Unmarshal(resp.Data, &r); err != nil {
+		return "", err
+	}
+	return r.URL, nil
+}
+
+// VideoSubmit 投稿
+//
+// 提交前会校验分区、标题、标签等，不合法时直接返回错误
+func (b *BiliClient) VideoSubmit(s *VideoSubmitForm) (*VideoSubmitResp, error) {
+	return b.VideoSubmitWithContext(context.Background(), s)
+}
+
+// VideoSubmitWithContext 同 VideoSubmit，ctx 用于取消请求或设置超时
+func (b *BiliClient) VideoSubmitWithContext(ctx context.Context, s *VideoSubmitForm) (*VideoSubmitResp, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	noReprint := 0
+	if s.NoReprint && s.Copyright == 1 {
+		noReprint = 1
+	}
+	videos := make([]map[string]string, len(s.Videos))
+	for i, v := range s.Videos {
+		videos[i] = map[string]string{"filename": v.Filename, "title": v.Title, "desc": v.Desc}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"copyright":  s.Copyright,
+		"source":     s.Source,
+		"tid":        s.TID,
+		"cover":      s.Cover,
+		"title":      s.Title,
+		"tag":        strings.Join(s.Tags, ","),
+		"desc":       s.Desc,
+		"dynamic":    s.Dynamic,
+		"no_reprint": noReprint,
+		"dtime":      s.DTime,
+		"videos":     videos,
+		"subtitle":   map[string]interface{}{"open": 0, "lan": ""},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := "x/vu/web/add/v3"
+	auth := b.GetCookieAuth()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		b.resolve(BiliMemberURL)+endpoint+"?csrf="+url.QueryEscape(auth.BiliJCT), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Origin", "https://member.bilibili.com")
+	req.Header.Add("Referer", "https://member.bilibili.com/")
+	req.Header.Add("Content-type", "application/json; charset=utf-8")
+	req.Header.Add("User-Agent", b.ua)
+	req.Header.Add("Cookie", auth.Cookie())
+	raw, err := b.request(BiliMemberURL, endpoint, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.parse(endpoint, raw)
+	if err != nil {
+		return nil, err
+	}
+	var r = &VideoSubmitResp{}
+	if err = json.Unmarshal(resp.Data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (s *VideoSubmitForm) validate() error {
+	if s.Copyright != 1 && s.Copyright != 2 {
+		return fmt.Errorf("invalid copyright: %d", s.Copyright)
+	}
+	if s.Copyright == 2 && s.Source == "" {
+		return errors.New("source is required for reprinted video")
+	}
+	if GetVideoZone(s.TID).Name == "" {
+		return fmt.Errorf("invalid tid: %d", s.TID)
+	}
+	if s.Title == "" || utf8.RuneCountInString(s.Title) > 80 {
+		return errors.New("title must be 1-80 characters")
+	}
+	if utf8.RuneCountInString(s.Desc) > 2000 {
+		return errors.New("desc must be at most 2000 characters")
+	}
+	if len(s.Tags) == 0 || len(s.Tags) > 12 {
+		return errors.New("tags must be 1-12")
+	}
+	for _, t := range s.Tags {
+		if t == "" || strings.Contains(t, ",") || utf8.RuneCountInString(t) > 20 {
+			return fmt.Errorf("invalid tag: %q", t)
+		}
+	}
+	if len(s.Videos) == 0 {
+		return errors.New("no video to submit")
+	}
+	for i, v := range s.Videos {
+		if v.Filename == "" {
+			return fmt.Errorf("video %d: empty filename", i)
+		}
+	}
+	return nil
+}
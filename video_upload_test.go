@@ -0,0 +1,233 @@
+package biligo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iyear/biligo/biligotest"
+)
+
+// fakeUpos 分块上传服务器，fail为true时第3个分块返回500，noETag为true时分块响应不带ETag
+type fakeUpos struct {
+	*httptest.Server
+	mu     sync.Mutex
+	data   []byte
+	puts   map[int]int
+	parts  int
+	fail   bool
+	noETag bool
+	inited int
+}
+
+func newFakeUpos(t *testing.T, size int) *fakeUpos {
+	u := &fakeUpos{data: make([]byte, size), puts: map[int]int{}}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Upos-Auth") != "fake_auth" || r.URL.Path != "/ugcboss/n230101abc.mp4" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		q := r.URL.Query()
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && q["uploads"] != nil:
+			u.inited++
+			_, _ = w.Write([]byte(`{"OK":1,"upload_id":"fake_upload_id"}`))
+		case r.Method == http.MethodPut:
+			chunk, _ := strconv.Atoi(q.Get("chunk"))
+			start, _ := strconv.Atoi(q.Get("start"))
+			if u.fail && chunk == 2 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			b, _ := ioutil.ReadAll(r.Body)
+			if q.Get("uploadId") != "fake_upload_id" || strconv.Itoa(len(b)) != q.Get("size") {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			copy(u.data[start:], b)
+			u.puts[chunk]++
+			if !u.noETag {
+				w.Header().Set("ETag", `"etag`+q.Get("partNumber")+`"`)
+			}
+		case r.Method == http.MethodPost:
+			var body struct {
+				Parts []struct {
+					PartNumber int    `json:"partNumber"`
+					ETag       string `json:"eTag"`
+				} `json:"parts"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			u.parts = len(body.Parts)
+			if q.Get("biz_id") != "1234" || body.Parts[1].ETag != "etag2" {
+				_, _ = w.Write([]byte(`{"OK":0,"message":"bad parts"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"OK":1}`))
+		}
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+func TestVideoUploader_Upload(t *testing.T) {
+	b, srv := newFakeBiliClient(t)
+	b.retry = &RetryPolicy{BaseDelay: time.Millisecond}
+	data := randBytes(10 << 10)
+	upos := newFakeUpos(t, len(data))
+	upos.fail = true
+	srv.HandleFunc(biligotest.BiliMemberURL, "preupload", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("size") != strconv.Itoa(len(data)) || r.URL.Query().Get("name") != "test.mp4" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"OK":1,"auth":"fake_auth","biz_id":1234,"chunk_size":3072,"endpoint":"` +
+			upos.URL + `","upos_uri":"upos://ugcboss/n230101abc.mp4","threads":3}`))
+	})
+
+	path := filepath.Join(t.TempDir(), "test.mp4")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var last int64
+	u := b.NewVideoUploader(&VideoUploadOption{
+		Concurrency: 1,
+		Retry:       1,
+		OnProgress: func(p string, done, total int64) {
+			if p != path || done < last || total != int64(len(data)) {
+				t.Errorf("progress %s %d %d", p, done, total)
+			}
+			last = done
+		},
+	})
+	if _, err := u.Upload(context.Background(), path); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := os.Stat(path + ".upload.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 断点续传 不重新preupload 不重传已完成的分块
+	upos.fail = false
+	part, err := u.Upload(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part.Filename != "n230101abc" || part.Title != "test" {
+		t.Errorf("%+v", part)
+	}
+	if !bytes.Equal(upos.data, data) || upos.parts != 4 || upos.inited != 1 || last != int64(len(data)) {
+		t.Errorf("parts %d inited %d progress %d", upos.parts, upos.inited, last)
+	}
+	for i := 0; i < 4; i++ {
+		if upos.puts[i] != 1 {
+			t.Errorf("chunk %d uploaded %d times", i, upos.puts[i])
+		}
+	}
+	if _, err = os.Stat(path + ".upload.json"); !os.IsNotExist(err) {
+		t.Error("state file not removed")
+	}
+	n := 0
+	for _, r := range srv.Requests() {
+		if r.Endpoint == "preupload" {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("preupload %d times", n)
+	}
+}
+
+func TestVideoUploader_MissingETag(t *testing.T) {
+	b, srv := newFakeBiliClient(t)
+	b.retry = &RetryPolicy{BaseDelay: time.Millisecond}
+	data := randBytes(4 << 10)
+	upos := newFakeUpos(t, len(data))
+	upos.noETag = true
+	srv.HandleFunc(biligotest.BiliMemberURL, "preupload", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"OK":1,"auth":"fake_auth","biz_id":1234,"chunk_size":3072,"endpoint":"` +
+			upos.URL + `","upos_uri":"upos://ugcboss/n230101abc.mp4","threads":3}`))
+	})
+
+	path := filepath.Join(t.TempDir(), "test.mp4")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := b.NewVideoUploader(&VideoUploadOption{Concurrency: 1, Retry: 1}).Upload(context.Background(), path)
+	if err == nil || !strings.Contains(err.Error(), "missing etag") {
+		t.Fatalf("expected missing etag error, got %v", err)
+	}
+	// 每个分块重试一次，不会合并
+	if upos.parts != 0 || upos.puts[0] != 2 {
+		t.Errorf("parts %d puts %v", upos.parts, upos.puts)
+	}
+}
+
+func TestOffline_VideoSubmit(t *testing.T) {
+	b, srv := newFakeBiliClient(t)
+	var body map[string]interface{}
+	srv.HandleFunc(biligotest.BiliMemberURL, "x/vu/web/add/v3", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("csrf") != "fake_bili_jct" || !strings.Contains(r.Header.Get("Cookie"), "SESSDATA=fake_sessdata") {
+			t.Errorf("%v %v", r.URL, r.Header)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		biligotest.WriteJSON(w, 0, "0", map[string]interface{}{"aid": 170001, "bvid": "BV17x411w7KC"})
+	})
+	srv.HandleJSON(biligotest.BiliMemberURL, "x/vu/web/cover/up", 0, "0", map[string]interface{}{"url": "https://i0.hdslb.com/bfs/archive/cover.png"})
+
+	var png bytes.Buffer
+	png.Write(testSprite(0))
+	cover, err := b.VideoUploadCover(&png)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := srv.LastRequest().Form; !strings.HasPrefix(f.Get("cover"), "data:image/png;base64,") || f.Get("csrf") != "fake_bili_jct" {
+		t.Errorf("%v", f)
+	}
+
+	form := &VideoSubmitForm{
+		Copyright: 1,
+		TID:       171,
+		Cover:     cover,
+		Title:     "标题",
+		Tags:      []string{"a", "b"},
+		NoReprint: true,
+		Videos:    []*VideoUploadPart{{Filename: "n1", Title: "P1"}, {Filename: "n2", Title: "P2"}},
+	}
+	r, err := b.VideoSubmit(form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.AID != 170001 || r.BVID != "BV17x411w7KC" {
+		t.Errorf("%+v", r)
+	}
+	if body["tag"] != "a,b" || body["tid"].(float64) != 171 || body["no_reprint"].(float64) != 1 || len(body["videos"].([]interface{})) != 2 || body["cover"] != cover {
+		t.Errorf("%v", body)
+	}
+
+	for _, f := range []func(*VideoSubmitForm){
+		func(s *VideoSubmitForm) { s.TID = 99999 },
+		func(s *VideoSubmitForm) { s.Copyright = 2 },
+		func(s *VideoSubmitForm) { s.Tags = nil },
+		func(s *VideoSubmitForm) { s.Title = strings.Repeat("长", 81) },
+		func(s *VideoSubmitForm) { s.Videos = nil },
+	} {
+		s := *form
+		f(&s)
+		if _, err = b.VideoSubmit(&s); err == nil {
+			t.Errorf("expected error for %+v", s)
+		}
+	}
+}
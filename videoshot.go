@@ -0,0 +1,177 @@
+package biligo
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // 拼版为jpg
+	_ "image/png"
+	"sort"
+	"strings"
+)
+
+// DecodeVideoShotPvdata 解析bin格式截取时间表，每2字节为一个大端序uint16，单位为秒
+func DecodeVideoShotPvdata(data []byte) ([]int, error) {
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("invalid pvdata length: %d", len(data))
+	}
+	r := make([]int, len(data)/2)
+	for i := range r {
+		r[i] = int(binary.BigEndian.Uint16(data[i*2:]))
+	}
+	return r, nil
+}
+
+// VideoShotGetPvdata 下载并解析 shot.Pvdata，结果同时写入 shot.Index
+func (c *CommClient) VideoShotGetPvdata(shot *VideoShot) ([]int, error) {
+	return c.VideoShotGetPvdataWithContext(context.Background(), shot)
+}
+
+// VideoShotGetPvdataWithContext 同 VideoShotGetPvdata，ctx 用于取消请求或设置超时
+func (c *CommClient) VideoShotGetPvdataWithContext(ctx context.Context, shot *VideoShot) ([]int, error) {
+	if shot.Pvdata == "" {
+		return nil, errors.New("empty pvdata url")
+	}
+	raw, err := c.get(ctx, httpsURL(shot.Pvdata))
+	if err != nil {
+		return nil, err
+	}
+	index, err := DecodeVideoShotPvdata(raw)
+	if err != nil {
+		return nil, err
+	}
+	shot.Index = index
+	return index, nil
+}
+
+// httpsURL 补全 //i0.hdslb.com 这类省略协议的地址
+func httpsURL(u string) string {
+	if strings.HasPrefix(u, "//") {
+		return "https:" + u
+	}
+	return u
+}
+
+// VideoShotFrame 一张快照在拼版中的位置
+type VideoShotFrame struct {
+	Index  int             // 快照序号 从0开始
+	Time   int             // 截取时间 单位为秒 没有截取时间表时为-1
+	Sprite int             // 所在拼版在 Image 中的下标
+	URL    string          // 所在拼版URL
+	Rect   image.Rectangle // 在拼版中的裁剪区域
+}
+
+// perSprite 每张拼版的快照数
+func (s *VideoShot) perSprite() int {
+	return s.ImgXLen * s.ImgYLen
+}
+
+// Count 快照总数，有截取时间表时为表的长度，否则为全部拼版的格子数
+func (s *VideoShot) Count() int {
+	if len(s.Index) > 0 {
+		return len(s.Index)
+	}
+	return s.perSprite() * len(s.Image)
+}
+
+// Frame 获取第i张快照的位置，超出范围时返回nil
+func (s *VideoShot) Frame(i int) *VideoShotFrame {
+	n := s.perSprite()
+	if i < 0 || i >= s.Count() || n == 0 || i/n >= len(s.Image) {
+		return nil
+	}
+	x, y := i%n%s.ImgXLen, i%n/s.ImgXLen
+	f := &VideoShotFrame{
+		Index:  i,
+		Time:   -1,
+		Sprite: i / n,
+		URL:    httpsURL(s.Image[i/n]),
+		Rect:   image.Rect(x*s.ImgXSize, y*s.ImgYSize, (x+1)*s.ImgXSize, (y+1)*s.ImgYSize),
+	}
+	if i < len(s.Index) {
+		f.Time = s.Index[i]
+	}
+	return f
+}
+
+// FrameAt 获取播放到sec秒时应显示的快照，即截取时间不大于sec的最后一张
+//
+// 需要先通过 VideoShot 的index参数或 VideoShotGetPvdata 获取截取时间表，否则返回nil
+func (s *VideoShot) FrameAt(sec int) *VideoShotFrame {
+	if len(s.Index) == 0 {
+		return nil
+	}
+	i := sort.Search(len(s.Index), func(i int) bool { return s.Index[i] > sec }) - 1
+	if i < 0 {
+		i = 0
+	}
+	return s.Frame(i)
+}
+
+// VideoShotImage 切分后的单张快照
+type VideoShotImage struct {
+	*VideoShotFrame
+	Image image.Image
+}
+
+// Slice 将第sprite张拼版切分为单张快照，img为该拼版解码后的图片
+//
+// 超出图片范围的格子会被忽略
+func (s *VideoShot) Slice(sprite int, img image.Image) []*VideoShotImage {
+	var r []*VideoShotImage
+	n := s.perSprite()
+	for i := sprite * n; i < (sprite+1)*n; i++ {
+		f := s.Frame(i)
+		if f == nil {
+			break
+		}
+		rect := f.Rect.Add(img.Bounds().Min)
+		if !rect.In(img.Bounds()) {
+			continue
+		}
+		r = append(r, &VideoShotImage{VideoShotFrame: f, Image: subImage(img, rect)})
+	}
+	return r
+}
+
+func subImage(img image.Image, r image.Rectangle) image.Image {
+	if s, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return s.SubImage(r)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Src)
+	return dst
+}
+
+// VideoShotSlice 下载全部拼版并切分为单张快照
+//
+// shot.Index 为空时 Time 均为-1，可先调用 VideoShotGetPvdata
+func (c *CommClient) VideoShotSlice(shot *VideoShot) ([]*VideoShotImage, error) {
+	return c.VideoShotSliceWithContext(context.Background(), shot)
+}
+
+// VideoShotSliceWithContext 同 VideoShotSlice，ctx 用于取消请求或设置超时
+func (c *CommClient) VideoShotSliceWithContext(ctx context.Context, shot *VideoShot) ([]*VideoShotImage, error) {
+	var r []*VideoShotImage
+	for i, u := range shot.Image {
+		if shot.perSprite()*i >= shot.Count() {
+			break
+		}
+		raw, err := c.get(ctx, httpsURL(u))
+		if err != nil {
+			return nil, err
+		}
+		img, _, err := image.Decode(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("decode sprite %d: %w", i, err)
+		}
+		r = append(r, shot.Slice(i, img)...)
+	}
+	return r, nil
+}
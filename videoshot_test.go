@@ -0,0 +1,113 @@
+package biligo
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeVideoShotPvdata(t *testing.T) {
+	index, err := DecodeVideoShotPvdata([]byte{0, 0, 0, 5, 0x01, 0x2c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index) != 3 || index[1] != 5 || index[2] != 300 {
+		t.Errorf("%v", index)
+	}
+	if _, err = DecodeVideoShotPvdata([]byte{0, 1, 2}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+// testSprite 2x2的拼版，每格4x3，第i格的颜色为 (base+i, 0, 0)
+func testSprite(base uint8) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	for i := 0; i < 4; i++ {
+		x, y := i%2*4, i/2*3
+		for dx := 0; dx < 4; dx++ {
+			for dy := 0; dy < 3; dy++ {
+				img.Set(x+dx, y+dy, color.RGBA{R: base + uint8(i), A: 255})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func TestVideoShot_Frame(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pv.bin":
+			_, _ = w.Write([]byte{0, 0, 0, 0, 0, 5, 0, 10, 0, 15, 0, 20})
+		case "/0.png":
+			_, _ = w.Write(testSprite(0))
+		case "/1.png":
+			_, _ = w.Write(testSprite(100))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	shot := &VideoShot{
+		Pvdata:   srv.URL + "/pv.bin",
+		ImgXLen:  2,
+		ImgYLen:  2,
+		ImgXSize: 4,
+		ImgYSize: 3,
+		Image:    []string{srv.URL + "/0.png", srv.URL + "/1.png"},
+	}
+	if f := shot.FrameAt(3); f != nil {
+		t.Errorf("expected nil without index: %+v", f)
+	}
+	c := NewCommClient(&CommSetting{})
+	if _, err := c.VideoShotGetPvdata(shot); err != nil {
+		t.Fatal(err)
+	}
+	if shot.Count() != 6 {
+		t.Errorf("count: %d", shot.Count())
+	}
+
+	for _, tc := range []struct {
+		sec, index, sprite int
+		rect               image.Rectangle
+	}{
+		{0, 1, 0, image.Rect(4, 0, 8, 3)},
+		{7, 2, 0, image.Rect(0, 3, 4, 6)},
+		{16, 4, 1, image.Rect(0, 0, 4, 3)},
+		{999, 5, 1, image.Rect(4, 0, 8, 3)},
+	} {
+		f := shot.FrameAt(tc.sec)
+		if f == nil || f.Index != tc.index || f.Sprite != tc.sprite || f.Rect != tc.rect || !strings.HasSuffix(f.URL, ".png") {
+			t.Errorf("%d: %+v", tc.sec, f)
+		}
+	}
+	if shot.Frame(6) != nil {
+		t.Error("expected nil for out of range frame")
+	}
+
+	frames, err := c.VideoShotSlice(shot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 6 {
+		t.Fatalf("frames: %d", len(frames))
+	}
+	for i, f := range frames {
+		want := uint8(i)
+		if i >= 4 {
+			want = uint8(100 + i - 4)
+		}
+		b := f.Image.Bounds()
+		r, _, _, _ := f.Image.At(b.Min.X, b.Min.Y).RGBA()
+		if b.Dx() != 4 || b.Dy() != 3 || uint8(r>>8) != want || f.Time != shot.Index[i] {
+			t.Errorf("%d: %v %d %d", i, b, r>>8, f.Time)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package biligo
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iyear/biligo/internal/util"
+	"github.com/tidwall/gjson"
+)
+
+var mixinKeyEncTab = []int{
+	46, 47, 18, 2, 53, 8, 23, 32, 15, 50, 10, 31, 58, 3, 45, 35, 27, 43, 5, 49,
+	33, 9, 42, 19, 29, 28, 14, 39, 12, 38, 41, 13, 37, 48, 7, 16, 24, 55, 40,
+	61, 26, 17, 0, 1, 60, 51, 30, 4, 22, 25, 54, 21, 56, 59, 6, 63, 57, 62, 11,
+	36, 20, 34, 44, 52,
+}
+
+// getMixinKey 对 img_key+sub_key 进行字符顺序打乱编码，取前32位
+func getMixinKey(e string) string {
+	t := make([]byte, 0, len(mixinKeyEncTab))
+	for _, r := range mixinKeyEncTab {
+		if r < len(e) {
+			t = append(t, e[r])
+		}
+	}
+	if len(t) > 32 {
+		t = t[:32]
+	}
+	return string(t)
+}
+
+// WbiKeyFetcher 获取 img_key 与 sub_key
+type WbiKeyFetcher func(ctx context.Context) (imgKey string, subKey string, err error)
+
+// WbiSigner WBI签名器
+//
+// 文档：https://github.com/SocialSisterYi/bilibili-API-collect/blob/master/docs/misc/sign/wbi.md
+//
+// 密钥缓存在内存中，跨天(东八区)或调用 Invalidate 后重新获取
+type WbiSigner struct {
+	mu      sync.RWMutex
+	imgKey  string
+	subKey  string
+	updated time.Time
+	fetch   WbiKeyFetcher
+
+	// now 当前时间，测试时替换
+	now func() time.Time
+}
+
+// NewWbiSigner 使用fetch获取密钥，fetch为nil时只能使用 SetKeys 设置的密钥
+func NewWbiSigner(fetch WbiKeyFetcher) *WbiSigner {
+	return &WbiSigner{fetch: fetch, now: time.Now}
+}
+
+// NewWbiSignerWithKeys 使用固定密钥，不会自动刷新，一般用于测试
+func NewWbiSignerWithKeys(imgKey, subKey string) *WbiSigner {
+	s := NewWbiSigner(nil)
+	s.SetKeys(imgKey, subKey)
+	return s
+}
+
+// SetKeys 手动设置密钥
+func (s *WbiSigner) SetKeys(imgKey, subKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.imgKey, s.subKey, s.updated = imgKey, subKey, s.now()
+}
+
+// Keys 获取当前缓存的密钥
+func (s *WbiSigner) Keys() (imgKey string, subKey string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.imgKey, s.subKey
+}
+
+// Invalidate 使缓存的密钥失效，下次签名时重新获取
+//
+// 没有fetch时无效
+func (s *WbiSigner) Invalidate() {
+	if s.fetch == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updated = time.Time{}
+}
+
+// expired 密钥每天更新，跨天即视为过期
+func (s *WbiSigner) expired() bool {
+	if s.imgKey == "" || s.subKey == "" {
+		return true
+	}
+	if s.fetch == nil {
+		return false
+	}
+	y1, m1, d1 := util.GetCST8Time(s.updated).Date()
+	y2, m2, d2 := util.GetCST8Time(s.now()).Date()
+	return y1 != y2 || m1 != m2 || d1 != d2
+}
+
+func (s *WbiSigner) mixinKey(ctx context.Context) (string, error) {
+	s.mu.RLock()
+	if !s.expired() {
+		defer s.mu.RUnlock()
+		return getMixinKey(s.imgKey + s.subKey), nil
+	}
+	s.mu.RUnlock()
+
+	if s.fetch == nil {
+		return "", errors.New("wbi keys are empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 可能已被其他goroutine刷新
+	if !s.expired() {
+		return getMixinKey(s.imgKey + s.subKey), nil
+	}
+	img, sub, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	if img == "" || sub == "" {
+		return "", errors.New("wbi keys are empty")
+	}
+	s.imgKey, s.subKey, s.updated = img, sub, s.now()
+	return getMixinKey(img + sub), nil
+}
+
+// Sign 为参数添加 wts 与 w_rid
+func (s *WbiSigner) Sign(ctx context.Context, params url.Values) error {
+	key, err := s.mixinKey(ctx)
+	if err != nil {
+		return err
+	}
+	wbiSign(params, key, s.now().Unix())
+	return nil
+}
+
+// wbiSign 签名的具体实现，会覆盖已有的 wts 与 w_rid
+func wbiSign(params url.Values, mixinKey string, wts int64) {
+	params.Del("w_rid")
+	params.Set("wts", strconv.FormatInt(wts, 10))
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte('&')
+		}
+		v := ""
+		if vs := params[k]; len(vs) > 0 {
+			v = wbiFilter(vs[0])
+		}
+		// 与 encodeURIComponent 保持一致，空格为%20
+		buf.WriteString(strings.ReplaceAll(url.QueryEscape(k), "+", "%20"))
+		buf.WriteByte('=')
+		buf.WriteString(strings.ReplaceAll(url.QueryEscape(v), "+", "%20"))
+	}
+
+	sum := md5.Sum([]byte(buf.String() + mixinKey))
+	params.Set("w_rid", hex.EncodeToString(sum[:]))
+}
+
+// wbiFilter 过滤value中的 !'()* 字符
+func wbiFilter(v string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '!', '\'', '(', ')', '*':
+			return -1
+		}
+		return r
+	}, v)
+}
+
+// isWbiEndpoint 接口路径中带有wbi的需要签名
+func isWbiEndpoint(endpoint string) bool {
+	return strings.Contains("/"+endpoint+"/", "/wbi/")
+}
+
+// isWbiRejected 签名失效时返回-403或-352
+func isWbiRejected(raw []byte) bool {
+	code := gjson.GetBytes(raw, "code").Int()
+	return code == -403 || code == -352
+}
+
+// wbiKeyFromURL https://i0.hdslb.com/bfs/wbi/7cd084941338484aae1ad9425b84077c.png -> 7cd084941338484aae1ad9425b84077c
+func wbiKeyFromURL(u string) string {
+	return strings.TrimSuffix(path.Base(u), path.Ext(u))
+}
+
+// fetchWbiKeys 从导航栏接口获取密钥，未登录时接口返回-101但依然带有wbi_img
+//
+// 请求不携带Cookie，也不经过中间件，避免-101被当作调用方的请求结果
+func (h *baseClient) fetchWbiKeys(ctx context.Context) (string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.resolve(BiliApiURL)+"x/web-interface/nav", nil)
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Add("Origin", "https://www.bilibili.com")
+	req.Header.Add("Referer", "https://www.bilibili.com")
+	req.Header.Add("User-Agent", h.ua)
+	resp, err := h.sendRetry(BiliApiURL, req)
+	if err != nil {
+		return "", "", err
+	}
+	raw := resp.Body
+	img := gjson.GetBytes(raw, "data.wbi_img.img_url").String()
+	sub := gjson.GetBytes(raw, "data.wbi_img.sub_url").String()
+	if img == "" || sub == "" {
+		if _, err = h.parse("x/web-interface/nav", raw); err != nil {
+			return "", "", err
+		}
+		return "", "", errors.New("wbi_img not found in nav")
+	}
+	return wbiKeyFromURL(img), wbiKeyFromURL(sub), nil
+}
@@ -0,0 +1,111 @@
+package biligo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testWbiImgKey = "7cd084941338484aae1ad9425b84077c"
+	testWbiSubKey = "4932caff0ff746eab6f01bf08b70ac45"
+)
+
+func TestGetMixinKey(t *testing.T) {
+	if k := getMixinKey(testWbiImgKey + testWbiSubKey); k != "ea1db124af3c7062474693fa704f4ff8" {
+		t.Error(k)
+		t.FailNow()
+	}
+}
+func TestWbiSign(t *testing.T) {
+	params := url.Values{}
+	params.Set("foo", "114")
+	params.Set("bar", "514")
+	params.Set("zab", "1919810")
+	wbiSign(params, getMixinKey(testWbiImgKey+testWbiSubKey), 1702204169)
+	if params.Get("wts") != "1702204169" || params.Get("w_rid") != "8f6f2b5b3d485fe1886cec6a0be8c5d4" {
+		t.Error(params.Encode())
+		t.FailNow()
+	}
+}
+func TestWbiSigner_Refresh(t *testing.T) {
+	var fetched int32
+	s := NewWbiSigner(func(ctx context.Context) (string, string, error) {
+		atomic.AddInt32(&fetched, 1)
+		return testWbiImgKey, testWbiSubKey, nil
+	})
+	now := time.Date(2023, 12, 10, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if err := s.Sign(context.Background(), url.Values{}); err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+	}
+	if fetched != 1 {
+		t.Errorf("fetched %d times", fetched)
+	}
+	// 东八区跨天
+	now = now.Add(15 * time.Hour)
+	_ = s.Sign(context.Background(), url.Values{})
+	if fetched != 2 {
+		t.Errorf("fetched %d times", fetched)
+	}
+	s.Invalidate()
+	_ = s.Sign(context.Background(), url.Values{})
+	if fetched != 3 {
+		t.Errorf("fetched %d times", fetched)
+	}
+}
+func TestWbiKeyFromURL(t *testing.T) {
+	if k := wbiKeyFromURL("https://i0.hdslb.com/bfs/wbi/7cd084941338484aae1ad9425b84077c.png"); k != testWbiImgKey {
+		t.Error(k)
+	}
+}
+func TestBaseClient_RawWbi(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("w_rid") == "" || q.Get("wts") == "" {
+			t.Errorf("unsigned request: %s", r.URL)
+		}
+		// 第一次请求模拟签名失效
+		if atomic.AddInt32(&calls, 1) == 1 {
+			_, _ = w.Write([]byte(`{"code":-352,"message":"风控校验失败"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":0,"message":"0","data":{}}`))
+	}))
+	defer srv.Close()
+
+	var fetched, through int32
+	c := NewCommClient(&CommSetting{
+		Wbi: NewWbiSigner(func(ctx context.Context) (string, string, error) {
+			atomic.AddInt32(&fetched, 1)
+			return testWbiImgKey, testWbiSubKey, nil
+		}),
+		// 重新签名在中间件内层，中间件只看到最终结果
+		Middlewares: []Middleware{func(next RoundTrip) RoundTrip {
+			return func(req *RawRequest) (*RawResponse, error) {
+				atomic.AddInt32(&through, 1)
+				resp, err := next(req)
+				if err == nil && isWbiRejected(resp.Body) {
+					t.Error("middleware saw rejected response")
+				}
+				return resp, err
+			}
+		}},
+	})
+	if _, err := c.RawParse(srv.URL+"/", "x/space/wbi/arc/search", "GET", map[string]string{"mid": "1"}); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if calls != 2 || fetched != 2 || through != 1 {
+		t.Errorf("calls: %d,fetched: %d,through: %d", calls, fetched, through)
+	}
+}